@@ -15,9 +15,30 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/decks": {
+        "/api/v1/classes": {
+            "get": {
+                "description": "Lists the classes taught by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Classes"
+                ],
+                "summary": "Get the user's classes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Class"
+                            }
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Creates a new deck in Firestore and returns its ID",
+                "description": "Creates a class taught by the user, with a join code students can join it with",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,17 +46,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Create a deck",
+                "summary": "Create a class",
                 "parameters": [
                     {
-                        "description": "Deck info",
-                        "name": "user",
+                        "description": "Class info",
+                        "name": "class",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateDeck"
+                            "$ref": "#/definitions/models.CreateClass"
                         }
                     }
                 ],
@@ -43,30 +64,50 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.ReturnID"
+                            "$ref": "#/definitions/models.Class"
                         }
                     }
                 }
             }
         },
-        "/api/v1/decks/{deckID}": {
+        "/api/v1/classes/invites": {
             "get": {
-                "description": "Retrieves deck information from Firestore",
-                "consumes": [
+                "description": "Lists the classes the email of the user is invited to",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Classes"
+                ],
+                "summary": "Get the class invites of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ClassInvite"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/classes/join/{code}": {
+            "post": {
+                "description": "Enrolls the user in the class with the join code, and gives them access to its assigned decks",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Get a deck",
+                "summary": "Join a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Join code of the class",
+                        "name": "code",
                         "in": "path",
                         "required": true
                     }
@@ -75,117 +116,118 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Deck"
+                            "$ref": "#/definitions/models.ReturnID"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a deck from Firestore by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/v1/classes/{classID}": {
+            "get": {
+                "description": "Retrieves a class taught by the user, with its students",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Delete a deck along with its cards",
+                "summary": "Get a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Class"
+                        }
                     }
                 }
             },
-            "patch": {
-                "description": "Updates a decks data in Firestore by ID",
-                "consumes": [
-                    "application/json"
+            "delete": {
+                "description": "Deletes a class taught by the user with its assignments, students keep access to the assigned decks",
+                "tags": [
+                    "Classes"
                 ],
-                "produces": [
-                    "application/json"
+                "summary": "Delete a class",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Class ID",
+                        "name": "classID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/classes/{classID}/accept": {
+            "post": {
+                "description": "Enrolls the user in a class their email is invited to, and gives them access to its assigned decks. The email of the account must be verified",
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Update a deck",
+                "summary": "Accept an invite to a class",
                 "parameters": [
                     {
-                        "description": "Deck info",
-                        "name": "deck",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.UpdateDeck"
-                        }
+                        "type": "string",
+                        "description": "Class ID",
+                        "name": "classID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.Deck"
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/api/v1/decks/{deckID}/cards": {
+        "/api/v1/classes/{classID}/assignments": {
             "get": {
-                "description": "Retrieves cards from a specified deck in Firestore",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists the decks assigned to a class taught by the user, the earliest due first. Decks in the trash are left out until they are restored",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Get cards in a deck",
+                "summary": "List the assignments of a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "default": "20",
-                        "description": "Number of cards to retrieve",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Cursor for pagination",
-                        "name": "cursor",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CardsResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Assignment"
+                            }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Creates a new card in a specified deck and returns the cards",
+                "description": "Assigns a deck to a class with a due date, the students become viewers of the deck unless they have access already. Only owners and co-owners of the deck can assign it",
                 "consumes": [
                     "application/json"
                 ],
@@ -193,24 +235,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Create a card in a deck",
+                "summary": "Assign a deck to a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Card info (can be MultipleChoiceCard, FrontBackCard, OrderedCard, or BlanksCard)",
-                        "name": "card",
+                        "description": "Deck and due date",
+                        "name": "assignment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/models.CreateAssignment"
                         }
                     }
                 ],
@@ -218,82 +260,57 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.AnyCard"
+                            "$ref": "#/definitions/models.Assignment"
                         }
                     }
                 }
             }
         },
-        "/api/v1/decks/{deckID}/cards/due": {
-            "get": {
-                "description": "Retrieves due cards from a specified deck for a user in Firestore, prioritizing unstudied cards",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/api/v1/classes/{classID}/assignments/{assignmentID}": {
+            "delete": {
+                "description": "Deletes an assignment of a class taught by the user, the students keep access to the deck",
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Get due cards in a deck for a user",
+                "summary": "Delete an assignment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "default": "20",
-                        "description": "Number of cards to retrieve",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Cursor for pagination",
-                        "name": "cursor",
-                        "in": "query"
+                        "description": "Assignment ID",
+                        "name": "assignmentID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.AnyCardWithPaging"
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/api/v1/decks/{deckID}/cards/{cardID}": {
+        "/api/v1/classes/{classID}/progress": {
             "get": {
-                "description": "Retrieves card information from Firestore by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Sums up the progress of every student on every assignment: cards studied, reviews, retention and cards overdue for review. Assignments of decks in the trash are left out",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Get a card in a deck",
+                "summary": "Get the progress of a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "cardID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     }
@@ -302,13 +319,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AnyCard"
+                            "$ref": "#/definitions/models.ClassProgress"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates a card in a deck in Firestore by ID",
+            }
+        },
+        "/api/v1/classes/{classID}/students": {
+            "post": {
+                "description": "Invites the emails to a class taught by the user. Their users are enrolled and given access to its assigned decks once they accept. Whether an email has a registered user is not told",
                 "consumes": [
                     "application/json"
                 ],
@@ -316,67 +335,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Update a card in a deck",
+                "summary": "Invite students to a class",
                 "parameters": [
-                    {
-                        "description": "Deck info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.AnyCard"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "cardID",
-                        "in": "path",
-                        "required": true
+                        "description": "Emails of the students",
+                        "name": "students",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EnrollStudents"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.AnyCard"
+                            "$ref": "#/definitions/models.Enrollment"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/classes/{classID}/students/{studentID}": {
             "delete": {
-                "description": "Deletes a card from a specified deck by its ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Removes a student from a class taught by the user, the student keeps access to the assigned decks",
                 "tags": [
-                    "Decks"
+                    "Classes"
                 ],
-                "summary": "Delete a card in a deck",
+                "summary": "Remove a student from a class",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
+                        "description": "Class ID",
+                        "name": "classID",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Card ID",
-                        "name": "cardID",
+                        "description": "User ID of the student",
+                        "name": "studentID",
                         "in": "path",
                         "required": true
                     }
@@ -388,9 +396,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/decks/{deckID}/cards/{cardID}/progress": {
-            "get": {
-                "description": "Retrieves progress information of a card for a user from Firestore",
+        "/api/v1/decks": {
+            "post": {
+                "description": "Creates a new deck in Firestore and returns its ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -400,34 +408,31 @@ const docTemplate = `{
                 "tags": [
                     "Decks"
                 ],
-                "summary": "Get progress of a card for a user",
+                "summary": "Create a deck",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "cardID",
-                        "in": "path",
-                        "required": true
+                        "description": "Deck info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateDeck"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.CardProgress"
+                            "$ref": "#/definitions/models.ReturnID"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates progress information of a card for a user in Firestore",
+            }
+        },
+        "/api/v1/decks/import": {
+            "post": {
+                "description": "Recreates an exported deck owned by the caller, with new IDs for the deck, its cards, its notes and their note types, which the caller owns as well. Exports with an unknown schema version, or with progress out of the bounds of the scheduler, are rejected and nothing is created",
                 "consumes": [
                     "application/json"
                 ],
@@ -437,44 +442,39 @@ const docTemplate = `{
                 "tags": [
                     "Decks"
                 ],
-                "summary": "Update progress of a card for a user",
+                "summary": "Import an exported deck",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Deck ID",
-                        "name": "deckID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Card ID",
-                        "name": "cardID",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Progress info",
-                        "name": "progress",
+                        "description": "Exported deck",
+                        "name": "export",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CardRating"
+                            "$ref": "#/definitions/models.DeckExport"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Restore the exported progress for the caller",
+                        "name": "include_progress",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSummary"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/decks/{deckID}/emails": {
-            "patch": {
-                "description": "Updates a decks shared emails in Firestore by ID",
+        "/api/v1/decks/import/anki": {
+            "post": {
+                "description": "Creates a deck from an Anki .apkg package. Basic notes become front/back cards, reversed notes are reviewed in both directions and every cloze number becomes a blanks card. Media is not imported, the summary counts what was skipped by reason. A failed import leaves no deck behind",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -482,69 +482,182 @@ const docTemplate = `{
                 "tags": [
                     "Decks"
                 ],
-                "summary": "Update a decks' emails",
+                "summary": "Import an Anki deck",
                 "parameters": [
                     {
-                        "description": "Deck info",
-                        "name": "deck",
-                        "in": "body",
-                        "required": true,
+                        "type": "file",
+                        "description": "Anki package (.apkg)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Title of the deck, defaults to the name of the Anki deck",
+                        "name": "title",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Import the review history as the progress of the caller",
+                        "name": "include_progress",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateDeckEmails"
+                            "$ref": "#/definitions/models.ImportSummary"
                         }
                     }
+                }
+            }
+        },
+        "/api/v1/decks/join/{token}": {
+            "post": {
+                "description": "Makes the caller a member of the deck of a share link with the role of the link. Owners and members keep their role",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Join a deck through a share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token of the share link",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.DeckResponse"
+                            "$ref": "#/definitions/models.ShareLinkRedemption"
                         }
                     }
                 }
             }
         },
-        "/api/v1/status": {
+        "/api/v1/decks/library": {
             "get": {
-                "description": "Returns version and uptime",
+                "description": "Lists the public decks with their listing and card count, the most studied first unless sorted by recency. Decks can be filtered by language and subject, and searched by the words of their title and description. A search reads a bounded number of decks per request, so a page may hold fewer decks than the limit while has_more is true",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Decks"
+                ],
+                "summary": "Browse the public library",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words the title or description must contain",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the cards, such as en",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subject the deck is tagged with",
+                        "name": "subject",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "popular",
+                        "description": "popular or recent",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "20",
+                        "description": "Number of decks to retrieve, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Status page for the API",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/status.Status"
+                            "$ref": "#/definitions/models.PublicDecksResponse"
                         }
                     }
                 }
             }
         },
-        "/api/v1/users": {
+        "/api/v1/decks/trash": {
             "get": {
-                "description": "Return user information",
+                "description": "Lists the decks in the trash of the user, the latest deleted first, with when each will be purged",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Decks"
                 ],
-                "summary": "GET a user from firestore by their ID",
+                "summary": "List the user's deleted decks",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeletedDeck"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/decks/trash/{deckID}/restore": {
             "post": {
-                "description": "Creates a new user",
+                "description": "Moves a deck out of the trash of the user, with its members, cards and notes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Restore a deleted deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Deck"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}": {
+            "get": {
+                "description": "Retrieves deck information from Firestore. Public decks can be read by anyone, without the emails they are shared with and their roles",
                 "consumes": [
                     "application/json"
                 ],
@@ -552,31 +665,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Decks"
                 ],
-                "summary": "Create a user and return their ID",
+                "summary": "Get a deck",
                 "parameters": [
                     {
-                        "description": "User info",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateUser"
-                        }
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ReturnID"
+                            "$ref": "#/definitions/models.Deck"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Return card information",
+                "description": "Moves a deck to the trash of its owner by its ID, it can be restored until it is purged after the retention period",
                 "consumes": [
                     "application/json"
                 ],
@@ -584,19 +695,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Decks"
+                ],
+                "summary": "Delete a deck along with its cards",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Deletes a user from firestore",
                 "responses": {
                     "204": {
                         "description": "No Content"
                     }
                 }
-            }
-        },
-        "/api/v1/users/": {
+            },
             "patch": {
-                "description": "Return updated user information",
+                "description": "Updates a decks data in Firestore by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -604,17 +722,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Decks"
                 ],
-                "summary": "Update a user in firestore",
+                "summary": "Update a deck",
                 "parameters": [
                     {
-                        "description": "User info to update",
-                        "name": "user",
+                        "description": "Deck info",
+                        "name": "deck",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UpdateDeck"
                         }
                     }
                 ],
@@ -622,345 +740,4457 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CreateUser"
+                            "$ref": "#/definitions/models.Deck"
                         }
                     }
                 }
             }
         },
-        "/api/v1/users/decks": {
+        "/api/v1/decks/{deckID}/audit": {
             "get": {
-                "description": "Return the user's owned and shared decks",
+                "description": "Lists the changes made to the deck and its cards, the latest first, with who made them, the request they were made in and the fields they changed. Only the owner can read the log",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Decks"
+                ],
+                "summary": "Get the audit log of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "20",
+                        "description": "Number of events to retrieve",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "GET a users' owned and shared decks from firestore",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.UserDecks"
+                            "$ref": "#/definitions/models.AuditLogResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AnyCard": {
+        },
+        "/api/v1/decks/{deckID}/cards": {
+            "get": {
+                "description": "Retrieves cards from a specified deck in Firestore.\nCards are returned as stored to editors, and as presented to the other members, so matching cards do not give away their pairs.\nOnly tags_any with at most 30 tags narrows the cards read, the other tag filters are applied while reading the deck.\nReading stops after skipping 1000 cards, returning a short page with has_more, or a 400 when no card matched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get cards in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "20",
+                        "description": "Number of cards to retrieve",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have at least one of them",
+                        "name": "tags_any",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have all of them",
+                        "name": "tags_all",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have none of them",
+                        "name": "tags_not",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CardsResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new card in a specified deck and returns the cards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Create a card in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Card info (can be MultipleChoiceCard, FrontBackCard, OrderedCard, BlanksCard, NumericCard, MatchingCard or CodeCard), note cards are created through notes",
+                        "name": "card",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/due": {
+            "get": {
+                "description": "Retrieves due cards from a specified deck for a user in Firestore, prioritizing unstudied cards.\nFront/back cards reviewed in both directions are returned once per direction, marked by review_direction.\nCards are returned as presented, so matching cards come with both sides shuffled and without their pairs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get due cards in a deck for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "20",
+                        "description": "Number of cards to retrieve",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have at least one of them",
+                        "name": "tags_any",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have all of them",
+                        "name": "tags_all",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, cards must have none of them",
+                        "name": "tags_not",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCardWithPaging"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}": {
+            "get": {
+                "description": "Retrieves card information from Firestore by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get a card in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a card in a deck in Firestore by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Update a card in a deck",
+                "parameters": [
+                    {
+                        "description": "Deck info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Moves a card to the trash of its deck by its ID, it can be restored until it is purged after the retention period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Delete a card in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/grade": {
+            "post": {
+                "description": "Checks an answer to a card that can be graded.\nNumeric cards accept a number with an optional unit, such as \"9.81 m/s²\", converting\nequivalent units like km and m before comparing within the tolerance of the card.\nMatching cards accept matches from left to right items, giving credit for each correct match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Grade an answer to a card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Answer to the card",
+                        "name": "answer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GradeAnswer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.GradeResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/highlight": {
+            "get": {
+                "description": "Renders the code of a code card to HTML with inline styles and line numbers,\nso every client shows it the same way. The highlighted line range of the card is marked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get the highlighted code of a code card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "github",
+                        "description": "Highlighting style",
+                        "name": "style",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CodeHighlight"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/html": {
+            "get": {
+                "description": "Retrieves a card with every text field rendered to sanitized HTML.\nMarkdown is rendered, plain text is escaped, and math delimiters are kept for the client.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get a card in a deck as HTML",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/presentation": {
+            "get": {
+                "description": "Retrieves a card as it should be shown to the learner.\nMatching cards have both sides shuffled separately, other cards are returned as stored.\nThe due cards are not shuffled, so this is how a matching card in the review queue is shown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get a card in a deck to present it",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MatchingPresentation"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/progress": {
+            "get": {
+                "description": "Retrieves progress information of a card for a user from Firestore",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get progress of a card for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Direction the card is reviewed in (forward or reverse), the first one of the card when left out",
+                        "name": "direction",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CardProgress"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates progress information of a card for a user in Firestore. The direction must be one the card is reviewed in, the first one when left out",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Update progress of a card for a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Progress info",
+                        "name": "progress",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CardRating"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/revisions": {
+            "get": {
+                "description": "Lists the fields a card had before each of its updates, the latest first, with who updated it and when. Bulk changes such as tag updates, imports, upstream pulls and note edits are listed too",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "List the revisions of a card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CardRevision"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/revisions/diff": {
+            "get": {
+                "description": "Lists the fields that differ between two revisions of a card, or between a revision and the card as it is now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Compare two revisions of a card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Revision ID to compare from",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "current",
+                        "description": "Revision ID to compare to, the current card by default",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionDiff"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/cards/{cardID}/revisions/{revisionID}/restore": {
+            "post": {
+                "description": "Updates a card back to the fields of one of its revisions, clearing the fields the revision did not have. The restore is checked like any update, and the fields it replaces are kept as a new revision",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Restore a revision of a card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Revision ID",
+                        "name": "revisionID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/emails": {
+            "patch": {
+                "description": "Updates a decks shared emails in Firestore by ID, added emails get the given role (viewer, editor or co_owner), editor by default. Emails without a registered user are invited, and become members when they register. Only co-owners and the owner can share a deck",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Update a decks' emails",
+                "parameters": [
+                    {
+                        "description": "Deck info",
+                        "name": "deck",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateDeckEmails"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeckResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/export": {
+            "get": {
+                "description": "Exports a deck with every card, the notes generating cards and their note types, and the library description of the deck. The JSON format is a versioned document that can be imported again, while Markdown and plain text are streamed questions and answers meant for reading and printing",
+                "produces": [
+                    "application/json",
+                    "text/markdown",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Export a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "markdown",
+                            "text"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the progress of the caller, only in JSON",
+                        "name": "include_progress",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeckExport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/fork": {
+            "post": {
+                "description": "Copies a deck the caller can access and all its cards into a new deck owned by the caller. The copy records the source deck and when it was forked. Note cards become front/back cards, and the progress of the caller can be carried over onto the new cards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Fork a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fork options",
+                        "name": "options",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ForkDeck"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSummary"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/import/csv": {
+            "post": {
+                "description": "Creates cards in a deck from the rows of a CSV or TSV file. The options map the columns to the fields of each card type, and every row is validated like a created card. Nothing is created when a row has an error or on a dry run, and the report lists the errors of every row",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Import cards from a CSV file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV or TSV file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Import options as JSON, see models.CSVImportOptions",
+                        "name": "options",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run without errors",
+                        "schema": {
+                            "$ref": "#/definitions/models.CSVImportReport"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CSVImportReport"
+                        }
+                    },
+                    "422": {
+                        "description": "Rows with errors",
+                        "schema": {
+                            "$ref": "#/definitions/models.CSVImportReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/import/markdown": {
+            "post": {
+                "description": "Creates cards in a deck from a Markdown document: \"Q:\" lines followed by \"A:\" lines and \"term :: definition\" lines become front/back cards, lines with {{cloze}} spans become blanks cards. Every block is remembered by the id of an \"\u003c!-- id: name --\u003e\" comment on the line before it, or else by its question, term or cloze text, so importing the same source again updates the changed cards instead of duplicating them. Editing the text of a block without an id creates a new card, so blocks without an id are reported as warnings when missing cards are removed. Nothing is changed when a block has an error",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Import cards from Markdown notes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Markdown document",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the document, defaults to the file name",
+                        "name": "source",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Delete the cards of blocks no longer in the document",
+                        "name": "remove_missing",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MarkdownImportReport"
+                        }
+                    },
+                    "422": {
+                        "description": "Blocks with errors",
+                        "schema": {
+                            "$ref": "#/definitions/models.MarkdownImportReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/invites": {
+            "get": {
+                "description": "Lists the emails without a registered user the deck was shared with, they become members when they register",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "List the pending invites to a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Invite"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/invites/{inviteID}": {
+            "delete": {
+                "description": "Deletes a pending invite, so the email does not become a member when it registers",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Revoke an invite to a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Invite ID",
+                        "name": "inviteID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/links": {
+            "get": {
+                "description": "Lists the share links of a deck with their uses, without their tokens",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "List the share links of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ShareLink"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a link making anyone who redeems its token a viewer or editor of the deck, until it expires or reaches its maximum number of uses. The token is only returned once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Create a share link of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role, expiry and maximum number of uses of the link",
+                        "name": "link",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateShareLink"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatedShareLink"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/links/{linkID}": {
+            "delete": {
+                "description": "Deletes a share link, users who joined through it stay members",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Revoke a share link of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Share link ID",
+                        "name": "linkID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/members": {
+            "get": {
+                "description": "Lists the users a deck is shared with and their role: viewers can study the deck, editors can change its cards and co-owners can manage its members",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "List the members of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeckMembers"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/members/{member}": {
+            "put": {
+                "description": "Changes the role of a user the deck is shared with, only co-owners and the owner can manage members",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Change the role of a member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID or email of the member",
+                        "name": "member",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateMemberRole"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DeckMember"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stops sharing a deck with a user, only co-owners and the owner can manage members",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Remove a member from a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID or email of the member",
+                        "name": "member",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/notes": {
+            "get": {
+                "description": "Retrieves the notes in a deck with cursor-based pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get notes in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "20",
+                        "description": "Number of notes to retrieve",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotesResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a note from a note type of the user or of the deck owner, or one already used in the deck,\nand generates a card for every template rendering a non-empty front.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Create a note in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note info",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateNote"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.NoteWithCards"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/notes/{noteID}": {
+            "get": {
+                "description": "Retrieves a note along with the cards generated from it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get a note in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NoteWithCards"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the fields and tags of a note, and generates its cards again.\nCards keep their progress as long as their template still exists.\nThe note type is checked as when creating a note.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Update a note in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note info",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateNote"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NoteWithCards"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Moves a note to the trash of its deck along with the cards generated from it, they can be restored until they are purged",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Delete a note in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/notes/{noteID}/restore": {
+            "post": {
+                "description": "Moves a note out of the trash of its deck, along with the cards deleted with it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Restore a deleted note",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Note ID",
+                        "name": "noteID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Note"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/publish": {
+            "put": {
+                "description": "Lists a deck in the public library with a description, language and subjects, making it readable by anyone. Publishing again updates the listing",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Publish a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Listing of the deck",
+                        "name": "listing",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PublishDeck"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Deck"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a deck from the public library, so only its owner and shared users can read it again",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Unpublish a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/study": {
+            "post": {
+                "description": "Adds a public deck to the study list of the caller, listed with their decks",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Add a deck to the study list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a deck from the study list of the caller",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Remove a deck from the study list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/subscribe": {
+            "post": {
+                "description": "Creates a linked copy of a deck the caller can access, owned by the caller. The copy records its source deck and sync point, so later changes of the source can be reviewed and pulled in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Subscribe to a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Copy options",
+                        "name": "options",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ForkDeck"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSummary"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/tags": {
+            "get": {
+                "description": "Lists the tags used by the cards in a deck, with the number of cards having each tag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get tags in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagCount"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Adds or removes tags on the given cards, or every card in the deck if no card IDs are given.\nRename replaces the tag \"from\" with \"to\" on every card in the deck.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Update tags of cards in a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tag operation",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateTags"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateTagsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/transfer": {
+            "post": {
+                "description": "Asks a member of the deck to take it over, replacing any pending transfer. The deck changes owner once the member accepts. Only the owner can transfer a deck",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Offer a deck to a member",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User ID or email of the member",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RequestTransfer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.OwnershipTransfer"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Withdraws the pending transfer of a deck, the owner can cancel it and the member it was offered to can decline it",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Cancel or decline the transfer of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/transfer/accept": {
+            "post": {
+                "description": "Makes the user the owner of a deck offered to them, the former owner stays on the deck as a co-owner",
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Accept the transfer of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/trash": {
+            "get": {
+                "description": "Lists the cards and notes in the trash of a deck, the latest deleted first, with when each will be purged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "List the deleted cards of a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DeletedCard"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/trash/{cardID}/restore": {
+            "post": {
+                "description": "Moves a card out of the trash of a deck, with its revisions. Cards deleted along with their note come back by restoring the note",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Restore a deleted card",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Card ID",
+                        "name": "cardID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AnyCard"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/upstream/changes": {
+            "get": {
+                "description": "Lists the cards added, modified and removed in the source deck of a linked copy since the last sync. Changes to cards also edited in the copy are flagged as conflicts, and cards deleted from the copy are offered again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Get upstream changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID of the linked copy",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpstreamChanges"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/decks/{deckID}/upstream/pull": {
+            "post": {
+                "description": "Applies the selected changes of the source deck to a linked copy, keeping the tags of the local cards. Conflicting changes are left out unless overwrite is set, which replaces the local edits",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Decks"
+                ],
+                "summary": "Pull upstream changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deck ID of the linked copy",
+                        "name": "deckID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changes to pull",
+                        "name": "pull",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpstreamPull"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpstreamPullResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/note-types": {
+            "get": {
+                "description": "Lists the note types owned by the user, ordered by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Get the user's note types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.NoteType"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a note type with named fields and one or more card templates.\nTemplates use {{Field}} to insert a field, and the back can use {{FrontSide}}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Create a note type",
+                "parameters": [
+                    {
+                        "description": "Note type info",
+                        "name": "noteType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateNoteType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReturnID"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/note-types/{noteTypeID}": {
+            "get": {
+                "description": "Retrieves a note type owned by the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Get a note type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note type ID",
+                        "name": "noteTypeID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NoteType"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replaces the name, fields and templates of a note type.\nThe cards of the notes using the note type are generated again, in the decks the owner can still edit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Update a note type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note type ID",
+                        "name": "noteTypeID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note type info",
+                        "name": "noteType",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateNoteType"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NoteType"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a note type, only allowed when no note uses it",
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Delete a note type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note type ID",
+                        "name": "noteTypeID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/note-types/{noteTypeID}/preview": {
+            "post": {
+                "description": "Renders the cards a note with the given fields would generate, without saving it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Note types"
+                ],
+                "summary": "Preview the cards of a note",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Note type ID",
+                        "name": "noteTypeID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Note fields",
+                        "name": "note",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PreviewNote"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.NoteCard"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/status": {
+            "get": {
+                "description": "Returns version and uptime",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Status page for the API",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/status.Status"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
+            "get": {
+                "description": "Return user information",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GET a user from firestore by their ID",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new user. The pending invites of their email become memberships once the email is verified",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a user and return their ID",
+                "parameters": [
+                    {
+                        "description": "User info",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReturnID"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the user with the decks they own and the classes they teach, and removes them from the classes they are enrolled in. Decks the member given in transfer_to belongs to are handed over to that member instead. Note types still used by notes in decks of other users are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Deletes a user from firestore",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or email of the member to hand decks over to",
+                        "name": "transfer_to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/users/": {
+            "patch": {
+                "description": "Return updated user information. The email can only be set to the verified email of the signed-in account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update a user in firestore",
+                "parameters": [
+                    {
+                        "description": "User info to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/assignments": {
+            "get": {
+                "description": "Return the decks assigned in the classes the user is enrolled in, the earliest due first, with the progress of the user on them. Decks in the trash are left out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GET the assignments of a user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.StudentAssignment"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/decks": {
+            "get": {
+                "description": "Return the user's owned and shared decks",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GET a users' owned and shared decks from firestore",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserDecks"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/invites": {
+            "get": {
+                "description": "Return the invites to decks sent to the email of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GET the pending invites of a user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Invite"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/invites/{inviteID}/accept": {
+            "post": {
+                "description": "Makes the user a member of the deck with the invited role. The email of the account must be verified. Invites are accepted when registering as well",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Accept an invite to a deck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invite ID",
+                        "name": "inviteID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/api/v1/users/transfers": {
+            "get": {
+                "description": "Return the decks the user was asked to take over",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "GET the decks offered to a user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TransferOffer"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.AnyCard": {
+            "type": "object",
+            "properties": {
+                "blanksCard": {
+                    "$ref": "#/definitions/models.BlanksCard"
+                },
+                "codeCard": {
+                    "$ref": "#/definitions/models.CodeCard"
+                },
+                "frontBackCard": {
+                    "description": "@swagger:oneOf",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FrontBackCard"
+                        }
+                    ]
+                },
+                "matchingCard": {
+                    "$ref": "#/definitions/models.MatchingCard"
+                },
+                "multipleChoiceCard": {
+                    "$ref": "#/definitions/models.MultipleChoiceCard"
+                },
+                "noteCard": {
+                    "$ref": "#/definitions/models.NoteCard"
+                },
+                "numericCard": {
+                    "$ref": "#/definitions/models.NumericCard"
+                },
+                "orderedCard": {
+                    "$ref": "#/definitions/models.OrderedCard"
+                }
+            }
+        },
+        "models.AnyCardWithPaging": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AnyCard"
+                    }
+                },
+                "cursor": {
+                    "type": "string"
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Assignment": {
+            "type": "object",
+            "properties": {
+                "assigned_at": {
+                    "type": "string"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "deck_title": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AssignmentProgress": {
+            "type": "object",
+            "properties": {
+                "assignment_id": {
+                    "type": "string"
+                },
+                "cards": {
+                    "type": "integer"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "overdue": {
+                    "type": "integer"
+                },
+                "retention": {
+                    "type": "number"
+                },
+                "reviews": {
+                    "type": "integer"
+                },
+                "studied": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "card_id": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEvent"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.BlanksCard": {
+            "type": "object",
+            "required": [
+                "answers",
+                "question",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CSVImportReport": {
+            "type": "object",
+            "properties": {
+                "card_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CardProgress": {
+            "type": "object",
+            "required": [
+                "due"
+            ],
+            "properties": {
+                "due": {
+                    "type": "string"
+                },
+                "ease_factor": {
+                    "type": "integer",
+                    "maximum": 3000,
+                    "minimum": 1300
+                },
+                "interval": {
+                    "type": "number",
+                    "maximum": 36500,
+                    "minimum": 0
+                },
+                "lapses": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "last_reviewed_at": {
+                    "type": "string"
+                },
+                "reps": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.CardRating": {
+            "type": "object",
+            "properties": {
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "forward",
+                        "reverse"
+                    ]
+                },
+                "rating": {
+                    "type": "string",
+                    "enum": [
+                        "again",
+                        "hard",
+                        "good",
+                        "easy"
+                    ]
+                }
+            }
+        },
+        "models.CardRevision": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "id": {
+                    "type": "string"
+                },
+                "restored_from": {
+                    "description": "RestoredFrom is the revision the change restored, empty for edits",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CardSource": {
+            "type": "object",
+            "properties": {
+                "document": {
+                    "description": "Document names the imported document, such as its file name",
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "Hash is the hash of the content of the card when last imported",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key is the hash identifying the block in the document",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CardTemplate": {
+            "type": "object",
+            "required": [
+                "back",
+                "front",
+                "name"
+            ],
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "front": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "models.CardUpstream": {
+            "type": "object",
+            "properties": {
+                "card_id": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "Hash is the hash of the content of both cards when last synced,\ntelling which side changed since",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CardsResponse": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {}
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Class": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invited_emails": {
+                    "description": "InvitedEmails are the emails invited to the class that have not joined it yet",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "join_code": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "students": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ClassStudent"
+                    }
+                },
+                "teacher_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClassInvite": {
+            "type": "object",
+            "properties": {
+                "class_id": {
+                    "type": "string"
+                },
+                "class_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ClassProgress": {
+            "type": "object",
+            "properties": {
+                "class_id": {
+                    "type": "string"
+                },
+                "students": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StudentProgress"
+                    }
+                }
+            }
+        },
+        "models.ClassStudent": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "joined_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CodeCard": {
+            "type": "object",
+            "required": [
+                "code",
+                "language",
+                "question",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "maxLength": 20000
+                },
+                "explanation": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "highlight_from": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "highlight_to": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.CodeHighlight": {
+            "type": "object",
+            "properties": {
+                "html": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "style": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateAssignment": {
+            "type": "object",
+            "required": [
+                "deck_id",
+                "due_at"
+            ],
+            "properties": {
+                "deck_id": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateClass": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateDeck": {
+            "type": "object",
+            "required": [
+                "owner_id",
+                "title"
+            ],
+            "properties": {
+                "owner_id": {
+                    "type": "string"
+                },
+                "shared_emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateNote": {
+            "type": "object",
+            "required": [
+                "fields",
+                "note_type_id",
+                "tags"
+            ],
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "note_type_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.CreateNoteType": {
+            "type": "object",
+            "required": [
+                "fields",
+                "name",
+                "templates"
+            ],
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "templates": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "$ref": "#/definitions/models.CardTemplate"
+                    }
+                }
+            }
+        },
+        "models.CreateShareLink": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "max_uses": {
+                    "description": "Most users joining through the link, unlimited when 0",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "role": {
+                    "description": "Co-owners are never granted by link, only by email",
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor"
+                    ]
+                }
+            }
+        },
+        "models.CreateUser": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreatedShareLink": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Deck": {
+            "type": "object",
+            "properties": {
+                "forked_at": {
+                    "type": "string"
+                },
+                "forked_from": {
+                    "description": "ForkedFrom is the ID of the deck this deck was copied from",
+                    "type": "string"
+                },
+                "memberships": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.Membership"
+                    }
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "public": {
+                    "description": "Public decks can be read by anyone, and are listed in the library with their publication",
+                    "type": "boolean"
+                },
+                "publication": {
+                    "$ref": "#/definitions/models.Publication"
+                },
+                "shared_emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "transfer": {
+                    "description": "Transfer is set while the owner waits for a member to take the deck over",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.OwnershipTransfer"
+                        }
+                    ]
+                },
+                "upstream": {
+                    "description": "Upstream is set on linked copies, which can pull the changes of their source deck",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DeckUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.DeckExport": {
+            "type": "object",
+            "required": [
+                "cards",
+                "schema_version"
+            ],
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "deck": {
+                    "$ref": "#/definitions/models.ExportedDeck"
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "note_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NoteType"
+                    }
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Note"
+                    }
+                },
+                "progress": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExportedProgress"
+                    }
+                },
+                "schema_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.DeckMember": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeckMembers": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeckMember"
+                    }
+                },
+                "owner_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeckResponse": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {}
+                },
+                "id": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "shared_emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeckUpstream": {
+            "type": "object",
+            "properties": {
+                "deck_id": {
+                    "type": "string"
+                },
+                "synced_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeletedCard": {
+            "type": "object",
+            "properties": {
+                "card": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "$ref": "#/definitions/models.Note"
+                },
+                "purge_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DeletedDeck": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "purge_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DisplayDeck": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EnrollStudents": {
+            "type": "object",
+            "required": [
+                "emails"
+            ],
+            "properties": {
+                "emails": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Enrollment": {
+            "type": "object",
+            "properties": {
+                "invited": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ExportedDeck": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "card_count": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "subjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExportedProgress": {
+            "type": "object",
+            "required": [
+                "card_id",
+                "direction",
+                "due"
+            ],
+            "properties": {
+                "card_id": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "forward",
+                        "reverse"
+                    ]
+                },
+                "due": {
+                    "type": "string"
+                },
+                "ease_factor": {
+                    "type": "integer",
+                    "maximum": 3000,
+                    "minimum": 1300
+                },
+                "interval": {
+                    "type": "number",
+                    "maximum": 36500,
+                    "minimum": 0
+                },
+                "lapses": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "last_reviewed_at": {
+                    "type": "string"
+                },
+                "reps": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {},
+                "old": {}
+            }
+        },
+        "models.ForkDeck": {
+            "type": "object",
+            "properties": {
+                "include_progress": {
+                    "type": "boolean"
+                },
+                "title": {
+                    "description": "Title of the copy, the title of the source deck by default",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FrontBackCard": {
+            "type": "object",
+            "required": [
+                "back",
+                "front",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "direction": {
+                    "type": "string",
+                    "enum": [
+                        "forward",
+                        "reverse",
+                        "both"
+                    ]
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "front": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "review_direction": {
+                    "description": "ReviewDirection is only set on cards returned from a due queue,\nand tells which side of the card is being asked for.",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.GradeAnswer": {
+            "type": "object",
+            "properties": {
+                "answer": {
+                    "type": "string"
+                },
+                "matches": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.GradeResult": {
+            "type": "object",
+            "properties": {
+                "correct": {
+                    "type": "boolean"
+                },
+                "matches": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ImportSummary": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "integer"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "Skipped counts what could not be imported, keyed by the reason",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "models.Invite": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "deck_title": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "invited_by": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MarkdownImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "Errors report the blocks that could not become cards, Row being their line",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowError"
+                    }
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "warnings": {
+                    "description": "Warnings report the blocks without an ID when missing cards are removed,\nas editing their text replaces their card",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RowError"
+                    }
+                }
+            }
+        },
+        "models.MatchingCard": {
+            "type": "object",
+            "required": [
+                "pairs",
+                "question",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "pairs": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 2,
+                    "uniqueItems": true,
+                    "items": {
+                        "$ref": "#/definitions/models.MatchingPair"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.MatchingPair": {
+            "type": "object",
+            "required": [
+                "left",
+                "right"
+            ],
+            "properties": {
+                "left": {
+                    "type": "string"
+                },
+                "right": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MatchingPresentation": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "left": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "right": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Membership": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MultipleChoiceCard": {
+            "type": "object",
+            "required": [
+                "options",
+                "question",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.Note": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note_type_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.NoteCard": {
+            "type": "object",
+            "required": [
+                "front",
+                "note_id",
+                "tags",
+                "template",
+                "type"
+            ],
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "front": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "template": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.NoteType": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "templates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CardTemplate"
+                    }
+                }
+            }
+        },
+        "models.NoteWithCards": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NoteCard"
+                    }
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note_type_id": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.NotesResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Note"
+                    }
+                }
+            }
+        },
+        "models.NumericCard": {
+            "type": "object",
+            "required": [
+                "answer",
+                "question",
+                "tags",
+                "tolerance_mode",
+                "type"
+            ],
+            "properties": {
+                "answer": {
+                    "type": "number"
+                },
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tolerance": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "tolerance_mode": {
+                    "type": "string",
+                    "enum": [
+                        "absolute",
+                        "relative"
+                    ]
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.OrderedCard": {
+            "type": "object",
+            "required": [
+                "options",
+                "question",
+                "tags",
+                "type"
+            ],
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "enum": [
+                        "plain",
+                        "markdown"
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source is set on cards imported from a Markdown document, to find them on re-import",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardSource"
+                        }
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "upstream": {
+                    "description": "Upstream is set on the cards of a linked copy, to find their source card",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CardUpstream"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.OwnershipTransfer": {
+            "type": "object",
+            "properties": {
+                "requested_at": {
+                    "type": "string"
+                },
+                "to_email": {
+                    "type": "string"
+                },
+                "to_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PreviewNote": {
+            "type": "object",
+            "required": [
+                "fields"
+            ],
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.PublicDeck": {
+            "type": "object",
+            "properties": {
+                "card_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "publication": {
+                    "$ref": "#/definitions/models.Publication"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PublicDecksResponse": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "type": "string"
+                },
+                "decks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PublicDeck"
+                    }
+                },
+                "has_more": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.Publication": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "learners": {
+                    "description": "Learners counts the users studying the deck, used to rank it by popularity",
+                    "type": "integer"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "subjects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PublishDeck": {
+            "type": "object",
+            "required": [
+                "language",
+                "subjects"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "language": {
+                    "description": "Language of the cards as a BCP 47 tag, such as \"en\" or \"pt-BR\"",
+                    "type": "string"
+                },
+                "subjects": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.RequestTransfer": {
+            "type": "object",
+            "required": [
+                "member"
+            ],
+            "properties": {
+                "member": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReturnID": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RevisionDiff": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RowError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "Row is the line of the row in the file, starting at 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ShareLink": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ShareLinkRedemption": {
             "type": "object",
             "properties": {
-                "blanksCard": {
-                    "$ref": "#/definitions/models.BlanksCard"
-                },
-                "frontBackCard": {
-                    "description": "@swagger:oneOf",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.FrontBackCard"
-                        }
-                    ]
-                },
-                "multipleChoiceCard": {
-                    "$ref": "#/definitions/models.MultipleChoiceCard"
+                "deck_id": {
+                    "type": "string"
                 },
-                "orderedCard": {
-                    "$ref": "#/definitions/models.OrderedCard"
+                "role": {
+                    "type": "string"
                 }
             }
         },
-        "models.AnyCardWithPaging": {
+        "models.StudentAssignment": {
             "type": "object",
             "properties": {
-                "cards": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.AnyCard"
-                    }
+                "assigned_at": {
+                    "type": "string"
                 },
-                "cursor": {
+                "class_id": {
                     "type": "string"
                 },
-                "has_more": {
-                    "type": "boolean"
+                "class_name": {
+                    "type": "string"
+                },
+                "deck_id": {
+                    "type": "string"
+                },
+                "deck_title": {
+                    "type": "string"
+                },
+                "due_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "progress": {
+                    "$ref": "#/definitions/models.AssignmentProgress"
                 }
             }
         },
-        "models.BlanksCard": {
+        "models.StudentProgress": {
             "type": "object",
-            "required": [
-                "answers",
-                "question",
-                "type"
-            ],
             "properties": {
-                "answers": {
+                "assignments": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/models.AssignmentProgress"
                     }
                 },
-                "id": {
-                    "type": "string"
-                },
-                "question": {
+                "email": {
                     "type": "string"
                 },
-                "type": {
+                "user_id": {
                     "type": "string"
                 }
             }
         },
-        "models.CardProgress": {
+        "models.TagCount": {
             "type": "object",
             "properties": {
-                "due": {
-                    "type": "string"
-                },
-                "ease_factor": {
-                    "type": "integer"
-                },
-                "interval": {
-                    "type": "number"
-                },
-                "lapses": {
+                "count": {
                     "type": "integer"
                 },
-                "last_reviewed_at": {
+                "tag": {
                     "type": "string"
-                },
-                "reps": {
-                    "type": "integer"
                 }
             }
         },
-        "models.CardRating": {
+        "models.TransferOffer": {
             "type": "object",
             "properties": {
-                "rating": {
-                    "type": "string",
-                    "enum": [
-                        "again",
-                        "hard",
-                        "good",
-                        "easy"
-                    ]
+                "deck_id": {
+                    "type": "string"
+                },
+                "deck_title": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "string"
+                },
+                "requested_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.CardsResponse": {
+        "models.UpdateDeck": {
             "type": "object",
             "properties": {
-                "cards": {
-                    "type": "array",
-                    "items": {}
-                },
-                "has_more": {
-                    "type": "boolean"
+                "title": {
+                    "type": "string"
                 }
             }
         },
-        "models.CreateDeck": {
+        "models.UpdateDeckEmails": {
             "type": "object",
             "required": [
-                "owner_id",
-                "title"
+                "opp",
+                "shared_emails"
             ],
             "properties": {
-                "owner_id": {
-                    "type": "string"
+                "opp": {
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove"
+                    ]
+                },
+                "role": {
+                    "description": "Role given to added emails, editor by default",
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "co_owner"
+                    ]
                 },
                 "shared_emails": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "title": {
-                    "type": "string"
                 }
             }
         },
-        "models.CreateUser": {
+        "models.UpdateMemberRole": {
             "type": "object",
             "required": [
-                "email",
-                "name"
+                "role"
             ],
             "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "co_owner"
+                    ]
                 }
             }
         },
-        "models.Deck": {
+        "models.UpdateNote": {
             "type": "object",
+            "required": [
+                "fields",
+                "tags"
+            ],
             "properties": {
-                "owner_id": {
-                    "type": "string"
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "shared_emails": {
+                "tags": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
-                },
-                "title": {
-                    "type": "string"
                 }
             }
         },
-        "models.DeckResponse": {
+        "models.UpdateTags": {
             "type": "object",
+            "required": [
+                "card_ids",
+                "opp",
+                "tags"
+            ],
             "properties": {
-                "cards": {
+                "card_ids": {
                     "type": "array",
-                    "items": {}
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "id": {
+                "from": {
                     "type": "string"
                 },
-                "owner_id": {
-                    "type": "string"
+                "opp": {
+                    "type": "string",
+                    "enum": [
+                        "add",
+                        "remove",
+                        "rename"
+                    ]
                 },
-                "shared_emails": {
+                "tags": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "title": {
-                    "type": "string"
+                "to": {
+                    "type": "string",
+                    "maxLength": 50
                 }
             }
         },
-        "models.DisplayDeck": {
+        "models.UpdateTagsResponse": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "owner_id": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
+                "updated": {
+                    "type": "integer"
                 }
             }
         },
-        "models.FrontBackCard": {
+        "models.UpstreamChange": {
             "type": "object",
-            "required": [
-                "back",
-                "front",
-                "type"
-            ],
             "properties": {
-                "back": {
-                    "type": "string"
+                "card": {
+                    "description": "Card is the card in the source deck, missing when it was removed",
+                    "type": "object"
                 },
-                "front": {
+                "card_id": {
+                    "description": "CardID is the ID of the card in the source deck",
                     "type": "string"
                 },
-                "id": {
+                "conflict": {
+                    "description": "Conflict is set when the local card was edited as well,\npulling the change replaces the local edits",
+                    "type": "boolean"
+                },
+                "local_card_id": {
                     "type": "string"
                 },
-                "type": {
+                "status": {
+                    "description": "Status is added, modified or removed",
                     "type": "string"
                 }
             }
         },
-        "models.MultipleChoiceCard": {
+        "models.UpstreamChanges": {
             "type": "object",
-            "required": [
-                "options",
-                "question",
-                "type"
-            ],
             "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "options": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "boolean"
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UpstreamChange"
                     }
                 },
-                "question": {
+                "deck_id": {
                     "type": "string"
                 },
-                "type": {
+                "synced_at": {
                     "type": "string"
                 }
             }
         },
-        "models.OrderedCard": {
+        "models.UpstreamPull": {
             "type": "object",
             "required": [
-                "options",
-                "question",
-                "type"
+                "card_ids"
             ],
             "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "options": {
+                "card_ids": {
+                    "description": "CardIDs are the IDs of the changed cards in the source deck",
                     "type": "array",
+                    "minItems": 1,
                     "items": {
                         "type": "string"
                     }
                 },
-                "question": {
-                    "type": "string"
-                },
-                "type": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.ReturnID": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.UpdateDeck": {
-            "type": "object",
-            "properties": {
-                "title": {
-                    "type": "string"
+                "overwrite": {
+                    "description": "Overwrite pulls conflicting changes, replacing the local edits",
+                    "type": "boolean"
                 }
             }
         },
-        "models.UpdateDeckEmails": {
+        "models.UpstreamPullResult": {
             "type": "object",
-            "required": [
-                "opp",
-                "shared_emails"
-            ],
             "properties": {
-                "opp": {
-                    "type": "string",
-                    "enum": [
-                        "add",
-                        "remove"
-                    ]
+                "added": {
+                    "type": "integer"
                 },
-                "shared_emails": {
+                "conflicts": {
+                    "description": "Conflicts are the IDs of the cards left out, as they were edited locally",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
                 }
             }
         },
@@ -992,6 +5222,13 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/models.DisplayDeck"
                     }
+                },
+                "study_decks": {
+                    "description": "StudyDecks are the public decks the user added to their study list",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DisplayDeck"
+                    }
                 }
             }
         },
@@ -0,0 +1,333 @@
+// Package anki reads Anki .apkg packages, which are zip files holding an SQLite collection.
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Maximum size of the collection once unzipped, to not fill the disk with a zip bomb
+const maxCollectionSize = 512 << 20
+
+// Collection files in the order they are preferred. Packages made for Anki 2.1
+// also hold a collection.anki2 telling the user to update, so it comes last.
+var collectionFiles = []string{"collection.anki21", "collection.anki2"}
+
+// ErrUnsupportedFormat is returned for packages only readable by recent Anki versions
+var ErrUnsupportedFormat = errors.New(
+	"unsupported anki package, export it with \"Support older Anki versions\" enabled",
+)
+
+// Collection holds the content of an Anki package.
+type Collection struct {
+	Created time.Time
+	Models  map[int64]Model
+	Decks   map[int64]string
+	Notes   []Note
+
+	// Cards of every note, ordered by template or cloze number
+	Cards map[int64][]Card
+}
+
+// Model is an Anki note type.
+type Model struct {
+	Name      string
+	Cloze     bool
+	Fields    []string
+	Templates []Template
+}
+
+// Template is a card template of a note type.
+type Template struct {
+	Name  string
+	Front string
+	Back  string
+}
+
+// Note holds the field values of a note, in the order of the fields of its model.
+type Note struct {
+	ID      int64
+	ModelID int64
+	Tags    []string
+	Fields  []string
+}
+
+// Card is a card generated from a note, along with its scheduling.
+type Card struct {
+	ID     int64
+	NoteID int64
+	DeckID int64
+
+	// Ord is the template index, or the cloze number minus one for cloze notes
+	Ord int
+
+	// Type is 0 for new cards, 1 when learning, 2 in review and 3 when relearning
+	Type int
+	// Queue is negative for suspended and buried cards, otherwise like Type
+	Queue int
+
+	// Due is a day number relative to the collection creation for cards in review,
+	// and a unix timestamp for cards being learned
+	Due int64
+	// Interval is in days when positive, and in seconds when negative
+	Interval int64
+	// Factor is the ease factor in permille
+	Factor  int
+	Reps    int
+	Lapses  int
+	Reviews int
+
+	LastReview time.Time
+}
+
+// Open reads an .apkg package.
+// Returns the collection, or an error if the package can not be read.
+func Open(r io.ReaderAt, size int64) (*Collection, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading package: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var file *zip.File
+	for _, name := range collectionFiles {
+		if f, ok := files[name]; ok {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		// Only exported by recent versions, compressed with zstd
+		if _, ok := files["collection.anki21b"]; ok {
+			return nil, ErrUnsupportedFormat
+		}
+		return nil, errors.New("no collection in package")
+	}
+
+	// SQLite needs a file on disk
+	path, err := extract(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	return readCollection(db)
+}
+
+// extract copies a file out of the package into a temporary file.
+// Returns the path of the file, which has to be removed by the caller.
+func extract(file *zip.File) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = src.Close() }()
+
+	dst, err := os.CreateTemp("", "memora-anki-*.db")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = dst.Close() }()
+
+	written, err := io.Copy(dst, io.LimitReader(src, maxCollectionSize+1))
+	if err == nil && written > maxCollectionSize {
+		err = errors.New("collection is too large")
+	}
+	if err != nil {
+		_ = os.Remove(dst.Name())
+		return "", err
+	}
+
+	return dst.Name(), nil
+}
+
+// readCollection reads the note types, decks, notes and cards of a collection.
+func readCollection(db *sql.DB) (*Collection, error) {
+	var created int64
+	var modelsJSON, decksJSON string
+	err := db.QueryRow("SELECT crt, models, decks FROM col").Scan(&created, &modelsJSON, &decksJSON)
+	if err != nil {
+		return nil, fmt.Errorf("reading collection: %w", err)
+	}
+
+	col := &Collection{
+		Created: time.Unix(created, 0),
+		Cards:   make(map[int64][]Card),
+	}
+
+	if col.Models, err = parseModels(modelsJSON); err != nil {
+		return nil, err
+	}
+	// Recent collections keep note types in their own table instead
+	if len(col.Models) == 0 {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if col.Decks, err = parseDecks(decksJSON); err != nil {
+		return nil, err
+	}
+
+	if col.Notes, err = readNotes(db); err != nil {
+		return nil, err
+	}
+
+	if err := readCards(db, col); err != nil {
+		return nil, err
+	}
+
+	return col, nil
+}
+
+// parseModels reads the note types stored as JSON in the collection.
+func parseModels(data string) (map[int64]Model, error) {
+	var raw map[string]struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+		Flds []struct {
+			Name string `json:"name"`
+			Ord  int    `json:"ord"`
+		} `json:"flds"`
+		Tmpls []struct {
+			Name string `json:"name"`
+			Qfmt string `json:"qfmt"`
+			Afmt string `json:"afmt"`
+			Ord  int    `json:"ord"`
+		} `json:"tmpls"`
+	}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("reading note types: %w", err)
+	}
+
+	models := make(map[int64]Model, len(raw))
+	for key, m := range raw {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		sort.Slice(m.Flds, func(i, j int) bool { return m.Flds[i].Ord < m.Flds[j].Ord })
+		sort.Slice(m.Tmpls, func(i, j int) bool { return m.Tmpls[i].Ord < m.Tmpls[j].Ord })
+
+		model := Model{Name: m.Name, Cloze: m.Type == 1}
+		for _, f := range m.Flds {
+			model.Fields = append(model.Fields, f.Name)
+		}
+		for _, t := range m.Tmpls {
+			model.Templates = append(model.Templates, Template{Name: t.Name, Front: t.Qfmt, Back: t.Afmt})
+		}
+		models[id] = model
+	}
+
+	return models, nil
+}
+
+// parseDecks reads the deck names stored as JSON in the collection.
+func parseDecks(data string) (map[int64]string, error) {
+	var raw map[string]struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("reading decks: %w", err)
+	}
+
+	decks := make(map[int64]string, len(raw))
+	for key, d := range raw {
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+			decks[id] = d.Name
+		}
+	}
+
+	return decks, nil
+}
+
+// readNotes reads every note in the collection.
+func readNotes(db *sql.DB) ([]Note, error) {
+	rows, err := db.Query("SELECT id, mid, tags, flds FROM notes ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("reading notes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var notes []Note
+	for rows.Next() {
+		var note Note
+		var tags, fields string
+		if err := rows.Scan(&note.ID, &note.ModelID, &tags, &fields); err != nil {
+			return nil, err
+		}
+		note.Tags = splitTags(tags)
+		note.Fields = splitFields(fields)
+		notes = append(notes, note)
+	}
+
+	return notes, rows.Err()
+}
+
+// readCards reads every card in the collection, along with when it was last reviewed.
+func readCards(db *sql.DB, col *Collection) error {
+	lastReviews := make(map[int64]time.Time)
+	reviews := make(map[int64]int)
+
+	// The review log is keyed by the review time in milliseconds
+	rows, err := db.Query("SELECT cid, MAX(id), COUNT(*) FROM revlog GROUP BY cid")
+	if err != nil {
+		return fmt.Errorf("reading review log: %w", err)
+	}
+	for rows.Next() {
+		var cardID, reviewed int64
+		var count int
+		if err := rows.Scan(&cardID, &reviewed, &count); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		lastReviews[cardID] = time.UnixMilli(reviewed)
+		reviews[cardID] = count
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = db.Query(`SELECT id, nid, did, ord, type, queue, due, ivl, factor, reps, lapses
+		FROM cards ORDER BY nid, ord`)
+	if err != nil {
+		return fmt.Errorf("reading cards: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c Card
+		err := rows.Scan(
+			&c.ID, &c.NoteID, &c.DeckID, &c.Ord, &c.Type, &c.Queue,
+			&c.Due, &c.Interval, &c.Factor, &c.Reps, &c.Lapses,
+		)
+		if err != nil {
+			return err
+		}
+		c.LastReview = lastReviews[c.ID]
+		c.Reviews = reviews[c.ID]
+		col.Cards[c.NoteID] = append(col.Cards[c.NoteID], c)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,226 @@
+package anki_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"memora/internal/anki"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testModels = `{
+	"1": {"name": "Basic (and reversed card)", "type": 0,
+		"flds": [{"name": "Front", "ord": 0}, {"name": "Back", "ord": 1}],
+		"tmpls": [
+			{"name": "Card 1", "ord": 0, "qfmt": "{{Front}}", "afmt": "{{FrontSide}}<hr id=answer>{{Back}}"},
+			{"name": "Card 2", "ord": 1, "qfmt": "{{Back}}", "afmt": "{{FrontSide}}<hr id=answer>{{Front}}"}
+		]},
+	"2": {"name": "Cloze", "type": 1,
+		"flds": [{"name": "Text", "ord": 0}, {"name": "Back Extra", "ord": 1}],
+		"tmpls": [{"name": "Cloze", "ord": 0, "qfmt": "{{cloze:Text}}", "afmt": "{{cloze:Text}}<br>{{Back Extra}}"}]}
+}`
+
+// buildPackage creates an .apkg holding a reversed note and a cloze note.
+func buildPackage(t *testing.T) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := []string{
+		`CREATE TABLE col (crt INTEGER, models TEXT, decks TEXT)`,
+		`CREATE TABLE notes (id INTEGER, mid INTEGER, tags TEXT, flds TEXT)`,
+		`CREATE TABLE cards (id INTEGER, nid INTEGER, did INTEGER, ord INTEGER, type INTEGER,
+			queue INTEGER, due INTEGER, ivl INTEGER, factor INTEGER, reps INTEGER, lapses INTEGER)`,
+		`CREATE TABLE revlog (id INTEGER, cid INTEGER)`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inserts := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO col VALUES (?, ?, ?)`, []any{1700000000, testModels, `{"1": {"name": "Spanish"}}`}},
+		{`INSERT INTO notes VALUES (?, ?, ?, ?)`, []any{10, 1, " verbs food ", "comer\x1fto eat"}},
+		{`INSERT INTO notes VALUES (?, ?, ?, ?)`, []any{11, 2, "", "{{c1::Madrid}} is in {{c2::Spain::country}}\x1f"}},
+		{`INSERT INTO cards VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []any{100, 10, 1, 0, 2, 2, 30, 12, 2300, 5, 1}},
+		{`INSERT INTO cards VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []any{101, 10, 1, 1, 0, 0, 1, 0, 0, 0, 0}},
+		{`INSERT INTO cards VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []any{102, 11, 1, 0, 0, 0, 2, 0, 0, 0, 0}},
+		{`INSERT INTO revlog VALUES (?, ?)`, []any{1700000000000, 100}},
+		{`INSERT INTO revlog VALUES (?, ?)`, []any{1700086400000, 100}},
+	}
+	for _, insert := range inserts {
+		if _, err := db.Exec(insert.query, insert.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("collection.anki2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	data := buildPackage(t)
+
+	col, err := anki.Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if len(col.Models) != 2 || !col.Models[2].Cloze || col.Models[1].Cloze {
+		t.Errorf("Models = %+v", col.Models)
+	}
+	if col.Decks[1] != "Spanish" {
+		t.Errorf("Decks = %v", col.Decks)
+	}
+
+	if len(col.Notes) != 2 {
+		t.Fatalf("got %d notes, want 2", len(col.Notes))
+	}
+	if want := []string{"verbs", "food"}; !reflect.DeepEqual(col.Notes[0].Tags, want) {
+		t.Errorf("Tags = %v, want %v", col.Notes[0].Tags, want)
+	}
+	if want := []string{"comer", "to eat"}; !reflect.DeepEqual(col.Notes[0].Fields, want) {
+		t.Errorf("Fields = %v, want %v", col.Notes[0].Fields, want)
+	}
+
+	cards := col.Cards[10]
+	if len(cards) != 2 || cards[0].Ord != 0 || cards[1].Ord != 1 {
+		t.Fatalf("Cards = %+v", cards)
+	}
+	if cards[0].Reviews != 2 || cards[0].LastReview.UnixMilli() != 1700086400000 {
+		t.Errorf("review history = %d reviews, last %v", cards[0].Reviews, cards[0].LastReview)
+	}
+	if cards[0].Factor != 2300 || cards[0].Interval != 12 {
+		t.Errorf("scheduling = %+v", cards[0])
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	if _, err := w.Create("collection.anki21b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"not a zip", []byte("not a zip")},
+		{"zstd collection", buf.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := anki.Open(bytes.NewReader(tt.data), int64(len(tt.data))); err == nil {
+				t.Error("Open() should fail")
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]string{"Front": "comer", "Back": "to eat", "Extra": ""}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"field", "{{Front}}", "comer"},
+		{"filter", "{{text:Front}}", "comer"},
+		{"type answer", "{{Front}}{{type:Back}}", "comer"},
+		{"filled section", "{{#Back}}<b>{{Back}}</b>{{/Back}}", "<b>to eat</b>"},
+		{"empty section", "{{Front}}{{#Extra}}, {{Extra}}{{/Extra}}", "comer"},
+		{"inverted section", "{{^Extra}}none{{/Extra}}", "none"},
+		{"unknown field", "{{Missing}}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anki.RenderTemplate(tt.template, fields); got != tt.want {
+				t.Errorf("RenderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderAnswer(t *testing.T) {
+	fields := map[string]string{"Front": "comer", "Back": "to eat"}
+
+	got := anki.RenderAnswer("{{FrontSide}}\n\n<hr id=answer>\n\n{{Back}}", fields)
+	if got != "\n\nto eat" {
+		t.Errorf("RenderAnswer() = %q", got)
+	}
+}
+
+func TestRenderCloze(t *testing.T) {
+	text := "{{c1::Madrid}} is the capital of {{c2::Spain::country}}, {{c1::Paris}} of France"
+
+	if got := anki.ClozeNumbers(text); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("ClozeNumbers() = %v", got)
+	}
+
+	tests := []struct {
+		number       int
+		wantQuestion string
+		wantAnswers  []string
+	}{
+		{1, "{} is the capital of Spain, {} of France", []string{"Madrid", "Paris"}},
+		{2, "Madrid is the capital of {}, Paris of France", []string{"Spain"}},
+		{3, "Madrid is the capital of Spain, Paris of France", nil},
+	}
+
+	for _, tt := range tests {
+		question, answers := anki.RenderCloze(text, tt.number)
+		if question != tt.wantQuestion || !reflect.DeepEqual(answers, tt.wantAnswers) {
+			t.Errorf("RenderCloze(%d) = %q, %v, want %q, %v",
+				tt.number, question, answers, tt.wantQuestion, tt.wantAnswers)
+		}
+	}
+}
+
+func TestStripMedia(t *testing.T) {
+	got, removed := anki.StripMedia(`hola [sound:hola.mp3]<img src="hola.jpg">`)
+	if got != "hola " || !removed {
+		t.Errorf("StripMedia() = %q, %v", got, removed)
+	}
+
+	if _, removed := anki.StripMedia("hola"); removed {
+		t.Error("StripMedia() removed media from plain text")
+	}
+}
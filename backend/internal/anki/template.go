@@ -0,0 +1,160 @@
+package anki
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fields of a note are stored in a single column, separated by this character
+const fieldSeparator = "\x1f"
+
+// Matches a cloze deletion such as {{c1::answer}} or {{c2::answer::hint}}
+var clozePattern = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)\}\}`)
+
+// Matches a field reference such as {{Front}}, {{text:Back}} or {{cloze:Text}}
+var fieldPattern = regexp.MustCompile(`\{\{([^#^/{}][^{}]*)\}\}`)
+
+// Matches a section such as {{#Field}} or {{^Field}}, and the tag closing it
+var sectionPattern = regexp.MustCompile(`\{\{([#^])\s*([^{}]+?)\s*\}\}`)
+
+// Matches media references, which are kept in the package but not imported
+var (
+	soundPattern = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	imagePattern = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+)
+
+// Separates the question from the answer on the back of most templates
+var answerSeparator = regexp.MustCompile(`(?i)<hr\s+id\s*=\s*["']?answer["']?\s*/?>`)
+
+// splitTags splits the tags of a note, which are separated by spaces.
+func splitTags(tags string) []string {
+	return strings.Fields(tags)
+}
+
+// splitFields splits the field values of a note.
+func splitFields(fields string) []string {
+	return strings.Split(fields, fieldSeparator)
+}
+
+// RenderTemplate renders one side of a card template with the fields of a note.
+// Sections are shown depending on whether their field is empty, and filters are ignored,
+// except for "type" and "cloze" fields which render nothing.
+func RenderTemplate(template string, fields map[string]string) string {
+	template = renderSections(template, fields)
+
+	return fieldPattern.ReplaceAllStringFunc(template, func(match string) string {
+		ref := strings.TrimSpace(match[2 : len(match)-2])
+		filters := strings.Split(ref, ":")
+		name := strings.TrimSpace(filters[len(filters)-1])
+
+		for _, filter := range filters[:len(filters)-1] {
+			if filter == "type" || filter == "cloze" {
+				return ""
+			}
+		}
+
+		return fields[name]
+	})
+}
+
+// RenderAnswer renders the back of a card template without repeating the front,
+// which Anki templates usually include with {{FrontSide}}.
+func RenderAnswer(template string, fields map[string]string) string {
+	if loc := answerSeparator.FindStringIndex(template); loc != nil {
+		template = template[loc[1]:]
+	}
+
+	withoutFront := make(map[string]string, len(fields)+1)
+	for name, value := range fields {
+		withoutFront[name] = value
+	}
+	withoutFront["FrontSide"] = ""
+
+	return RenderTemplate(template, withoutFront)
+}
+
+// renderSections keeps the content of the sections shown for the fields, and removes the rest.
+func renderSections(template string, fields map[string]string) string {
+	for {
+		loc := sectionPattern.FindStringSubmatchIndex(template)
+		if loc == nil {
+			return template
+		}
+
+		kind := template[loc[2]:loc[3]]
+		name := template[loc[4]:loc[5]]
+
+		// Sections run until the matching closing tag, or the end of the template
+		content := template[loc[1]:]
+		rest := ""
+		if end := strings.Index(content, "{{/"+name+"}}"); end >= 0 {
+			rest = content[end+len("{{/"+name+"}}"):]
+			content = content[:end]
+		}
+
+		filled := strings.TrimSpace(fields[name]) != ""
+		if filled != (kind == "#") {
+			content = ""
+		}
+
+		template = template[:loc[0]] + content + rest
+	}
+}
+
+// ClozeTemplateField returns the field a cloze template reads its deletions from.
+// Returns an empty string if the template has no cloze field.
+func ClozeTemplateField(template string) string {
+	for _, match := range fieldPattern.FindAllStringSubmatch(template, -1) {
+		filters := strings.Split(match[1], ":")
+		for _, filter := range filters[:len(filters)-1] {
+			if filter == "cloze" {
+				return strings.TrimSpace(filters[len(filters)-1])
+			}
+		}
+	}
+	return ""
+}
+
+// ClozeNumbers returns the distinct cloze numbers used in a text, in increasing order.
+func ClozeNumbers(text string) []int {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, match := range clozePattern.FindAllStringSubmatch(text, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// RenderCloze renders the card of a cloze number, replacing each of its deletions
+// with "{}" and showing the other deletions as plain text.
+// Returns the question and the hidden answers in order of appearance.
+func RenderCloze(text string, number int) (string, []string) {
+	var answers []string
+	question := clozePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := clozePattern.FindStringSubmatch(match)
+		answer, _, _ := strings.Cut(parts[2], "::")
+
+		if parts[1] != strconv.Itoa(number) {
+			return answer
+		}
+		answers = append(answers, answer)
+		return "{}"
+	})
+	return question, answers
+}
+
+// StripMedia removes sound and image references from a field.
+// Returns the text, and whether any media was removed.
+func StripMedia(text string) (string, bool) {
+	stripped := soundPattern.ReplaceAllString(text, "")
+	stripped = imagePattern.ReplaceAllString(stripped, "")
+	return stripped, stripped != text
+}
@@ -9,15 +9,27 @@ import (
 )
 
 var (
-	Port               string
-	Host               string
-	CurrentLevel       LogLevel
-	StartTime          time.Time
-	BasePath           = "/api/v1"
-	UsersCollection    string
-	CardsCollection    string
-	DecksCollection    string
-	ProgressCollection string
+	Port                   string
+	Host                   string
+	CurrentLevel           LogLevel
+	StartTime              time.Time
+	BasePath               = "/api/v1"
+	UsersCollection        string
+	CardsCollection        string
+	DecksCollection        string
+	ProgressCollection     string
+	NotesCollection        string
+	NoteTypesCollection    string
+	InvitesCollection      string
+	ShareLinksCollection   string
+	ClassesCollection      string
+	AssignmentsCollection  string
+	AuditCollection        string
+	RevisionsCollection    string
+	TrashCollection        string
+	DeletedDecksCollection string
+	TrashRetention         time.Duration
+	TrashPurgeInterval     time.Duration
 )
 
 func GetEnv(key, defaultValue string) string {
@@ -38,6 +50,16 @@ func Init() {
 	CardsCollection = GetEnv("CARDS_COLLECTION", "cards")
 	DecksCollection = GetEnv("DECKS_COLLECTION", "decks")
 	ProgressCollection = GetEnv("PROGRESS_COLLECTION", "progress")
+	NotesCollection = GetEnv("NOTES_COLLECTION", "notes")
+	NoteTypesCollection = GetEnv("NOTE_TYPES_COLLECTION", "note_types")
+	InvitesCollection = GetEnv("INVITES_COLLECTION", "invites")
+	ShareLinksCollection = GetEnv("SHARE_LINKS_COLLECTION", "share_links")
+	ClassesCollection = GetEnv("CLASSES_COLLECTION", "classes")
+	AssignmentsCollection = GetEnv("ASSIGNMENTS_COLLECTION", "assignments")
+	AuditCollection = GetEnv("AUDIT_COLLECTION", "audit")
+	RevisionsCollection = GetEnv("REVISIONS_COLLECTION", "revisions")
+	TrashCollection = GetEnv("TRASH_COLLECTION", "trash")
+	DeletedDecksCollection = GetEnv("DELETED_DECKS_COLLECTION", "deleted_decks")
 
 	level, err := ParseLogLevel(GetEnv("LOG_LEVEL", "info"))
 	if err != nil {
@@ -45,5 +67,16 @@ func Init() {
 	}
 	CurrentLevel = level
 
+	// Deleted decks and cards are kept in the trash for the retention period,
+	// and the trash is purged of the expired ones every purge interval
+	TrashRetention, err = time.ParseDuration(GetEnv("TRASH_RETENTION", "720h"))
+	if err != nil || TrashRetention <= 0 {
+		log.Fatalf("Configuration error: invalid TRASH_RETENTION")
+	}
+	TrashPurgeInterval, err = time.ParseDuration(GetEnv("TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil || TrashPurgeInterval <= 0 {
+		log.Fatalf("Configuration error: invalid TRASH_PURGE_INTERVAL")
+	}
+
 	StartTime = time.Now()
 }
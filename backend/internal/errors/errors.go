@@ -13,6 +13,19 @@ var (
 	ErrInvalidUser            = errors.New("invalid user data")
 	ErrInvalidCard            = errors.New("invalid card data")
 	ErrInvalidDeck            = errors.New("invalid deck data")
+	ErrInvalidNote            = errors.New("invalid note data")
+	ErrInvalidAnswer          = errors.New("invalid answer")
+	ErrInvalidNoteType        = errors.New("invalid note type data")
+	ErrNoteTypeInUse          = errors.New("note type is used by notes")
+	ErrInvalidImport          = errors.New("invalid import file")
+	ErrUnsupportedVersion     = errors.New("unsupported schema version")
+	ErrNotLinked              = errors.New("deck is not a linked copy")
+	ErrNotMember              = errors.New("email is not a member of the deck")
+	ErrInvalidShareLink       = errors.New("invalid share link data")
+	ErrShareLinkExpired       = errors.New("share link expired")
+	ErrNoTransfer             = errors.New("no ownership transfer pending")
+	ErrInvalidClass           = errors.New("invalid class data")
+	ErrNotStudent             = errors.New("user is not a student of the class")
 	ErrInvalidEmailNotPresent = errors.New("email not registerd")
 	ErrInvalidEmailPresent    = errors.New("email alredy registerd")
 	ErrInvalidId              = errors.New("invalid id")
@@ -20,6 +33,8 @@ var (
 	ErrFailedUpdatingCards    = errors.New("failed to update cards")
 	ErrAlreadyExists          = errors.New("resource already exists")
 	ErrUnauthorized           = errors.New("unauthorized")
+	ErrEmailNotVerified       = errors.New("email is not the verified email of the signed-in account")
+	ErrTagFilterTooNarrow     = errors.New("tag filter skipped too many cards")
 	ErrorMap                  = map[error]struct {
 		Status  int
 		Message string
@@ -35,7 +50,43 @@ var (
 			Status:  http.StatusBadRequest,
 			Message: "invalid deck, missing fields",
 		},
+		ErrInvalidNote:     {Status: http.StatusBadRequest, Message: "invalid note data"},
+		ErrInvalidAnswer:   {Status: http.StatusBadRequest, Message: "invalid answer"},
+		ErrInvalidNoteType: {Status: http.StatusBadRequest, Message: "invalid note type data"},
+		ErrNoteTypeInUse: {
+			Status:  http.StatusConflict,
+			Message: "note type is used by notes",
+		},
+		ErrInvalidImport: {Status: http.StatusBadRequest, Message: "invalid import file"},
+		ErrUnsupportedVersion: {
+			Status:  http.StatusBadRequest,
+			Message: "unsupported schema version",
+		},
+		ErrNotLinked:        {Status: http.StatusBadRequest, Message: "deck is not a linked copy"},
+		ErrNotMember:        {Status: http.StatusNotFound, Message: "email is not a member of the deck"},
+		ErrInvalidShareLink: {Status: http.StatusBadRequest, Message: "invalid share link data"},
+		ErrShareLinkExpired: {
+			Status:  http.StatusGone,
+			Message: "share link expired or used up",
+		},
+		ErrNoTransfer: {
+			Status:  http.StatusNotFound,
+			Message: "no ownership transfer pending",
+		},
+		ErrInvalidClass: {Status: http.StatusBadRequest, Message: "invalid class data"},
+		ErrNotStudent: {
+			Status:  http.StatusNotFound,
+			Message: "user is not a student of the class",
+		},
 		ErrInvalidEmailNotPresent: {Status: http.StatusBadRequest, Message: "email not registered"},
+		ErrEmailNotVerified: {
+			Status:  http.StatusForbidden,
+			Message: "email is not the verified email of the signed-in account",
+		},
+		ErrTagFilterTooNarrow: {
+			Status:  http.StatusBadRequest,
+			Message: "tag filter matches too few cards, filter with tags_any to narrow the cards read",
+		},
 		ErrInvalidEmailPresent: {
 			Status:  http.StatusBadRequest,
 			Message: "email already registered",
@@ -0,0 +1,116 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/errors"
+	"memora/internal/models"
+
+	"cloud.google.com/go/firestore"
+)
+
+// AuditRepository defines methods for the audit log of the changes made to decks and their cards.
+type AuditRepository interface {
+	// AddEvents stores audit events in the log of a deck.
+	// Error on fail, nil on success
+	AddEvents(ctx context.Context, deckID string, events []models.AuditEvent) error
+
+	// GetEvents fetches a page of the audit log of a deck, the latest events first.
+	// cursor is the ID of the last event of the previous page (empty string for first page)
+	// Error on fail or if the cursor is not valid, returns the events and whether there are more on success
+	GetEvents(
+		ctx context.Context,
+		deckID string,
+		limit int,
+		cursor string,
+	) ([]models.AuditEvent, bool, error)
+}
+
+// FirestoreAuditRepo implements the AuditRepository interface using Firestore.
+type FirestoreAuditRepo struct {
+	client *firestore.Client
+}
+
+// NewFirestoreAuditRepo creates and returns a pointer to the FirestoreAuditRepo.
+func NewFirestoreAuditRepo(client *firestore.Client) *FirestoreAuditRepo {
+	return &FirestoreAuditRepo{client: client}
+}
+
+// AddEvents stores audit events in the log of a deck.
+// The log is kept under the deck, outside of the subcollections deleted along with it.
+// Returns an error if one of the events could not be stored.
+func (r *FirestoreAuditRepo) AddEvents(
+	ctx context.Context,
+	deckID string,
+	events []models.AuditEvent,
+) error {
+	logRef := r.client.Collection(config.DecksCollection).Doc(deckID).Collection(config.AuditCollection)
+
+	// Bulk imports record an event per card, more than a single batch can hold
+	bulkWriter := r.client.BulkWriter(ctx)
+	jobs := make([]*firestore.BulkWriterJob, 0, len(events))
+	for _, event := range events {
+		job, err := bulkWriter.Create(logRef.NewDoc(), event)
+		if err != nil {
+			bulkWriter.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	bulkWriter.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetEvents fetches a page of the audit log of a deck, the latest events first.
+// cursor is the ID of the last event of the previous page (empty for first page)
+// Returns the events and whether there are more, or an error if the operation fails.
+func (r *FirestoreAuditRepo) GetEvents(
+	ctx context.Context,
+	deckID string,
+	limit int,
+	cursor string,
+) ([]models.AuditEvent, bool, error) {
+	logRef := r.client.Collection(config.DecksCollection).Doc(deckID).Collection(config.AuditCollection)
+
+	// Fetch one extra to check for more pages
+	query := logRef.OrderBy("created_at", firestore.Desc).Limit(limit + 1)
+
+	// Ordering by time needs the event to start after
+	if cursor != "" {
+		snap, err := logRef.Doc(cursor).Get(ctx)
+		if err != nil {
+			return nil, false, errors.ErrInvalidId
+		}
+		query = query.StartAfter(snap)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, false, err
+	}
+
+	events := make([]models.AuditEvent, 0, len(docs))
+	for _, doc := range docs {
+		var event models.AuditEvent
+		if err := doc.DataTo(&event); err != nil {
+			return nil, false, err
+		}
+		event.ID = doc.Ref.ID
+		events = append(events, event)
+	}
+
+	hasMore := false
+	if len(events) > limit {
+		hasMore = true
+		events = events[:limit] // Trim the extra event used for pagination check
+	}
+
+	return events, hasMore, nil
+}
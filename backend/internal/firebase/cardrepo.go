@@ -2,12 +2,21 @@ package firebase
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"memora/internal/config"
 	"memora/internal/errors"
 	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // CardStore defines methods for storing, retrieving and updating cards.
@@ -16,50 +25,181 @@ type CardRepository interface {
 	// Error on fail, returns the ID if succesfull
 	CreateCard(ctx context.Context, card any, deckID string) (string, error)
 
+	// CreateCards adds several cards into firestore in bulk.
+	// Error on fail, returns the IDs in the order of the cards if successful
+	CreateCards(ctx context.Context, deckID string, cards []any) ([]string, error)
+
 	// GetCardsInDeck fetches all cards in a given deck with cursor-based pagination.
 	// cursor is the ID of the last card from the previous page (empty string for first page)
-	// Error on fail, returns a list of cards on success
+	// Only cards matching the tag filter are returned, skipping at most MAX_CARD_SCAN cards.
+	// Error on fail, or if no card matches within the cards skipped,
+	// returns a list of cards on success
 	GetCardsInDeck(
 		ctx context.Context,
 		deckID string,
 		limit int,
 		cursor string,
+		filter models.TagFilter,
 	) ([]map[string]any, bool, error)
 
 	// GetCard returns the raw data for a card for a given ID.
 	// Error on fail, or if ID is not valid
 	GetCardInDeck(ctx context.Context, deckID, cardID string) (map[string]any, error)
 
-	// UpdateCard updates an existing card in firestore.
-	// Error on fail or if the ID is not valid, nil on success
+	// GetCardsByID returns the raw data of several cards of a deck, keyed by card ID.
+	// Cards that do not exist are left out.
+	// Error on fail, returns the cards on success
+	GetCardsByID(ctx context.Context, deckID string, cardIDs []string) (map[string]map[string]any, error)
+
+	// UpdateCard updates an existing card in firestore, keeping its fields before the update
+	// in the given revision.
+	// Error on fail or if the ID is not valid, returns the fields before the update on success
 	UpdateCard(
 		ctx context.Context,
 		firestoreUpdates []firestore.Update,
 		deckID, cardID string,
-	) error
+		revision models.CardRevision,
+	) (map[string]any, error)
+
+	// ReplaceCard overwrites an existing card in firestore with all its fields, keeping its fields
+	// before in the given revision.
+	// Error on fail or if the ID is not valid, returns the fields before the overwrite on success
+	ReplaceCard(
+		ctx context.Context,
+		card any,
+		deckID, cardID string,
+		revision models.CardRevision,
+	) (map[string]any, error)
+
+	// GetCardRevisions fetches the revisions of a card, the latest first.
+	// Error on fail, returns the revisions on success
+	GetCardRevisions(ctx context.Context, deckID, cardID string) ([]models.CardRevision, error)
 
-	// DeleteCard deletes an existing card in firestore.
+	// GetCardRevision fetches a revision of a card by its ID.
+	// Error on fail or if the revision does not exist
+	GetCardRevision(ctx context.Context, deckID, cardID, revisionID string) (models.CardRevision, error)
+
+	// DeleteCard moves an existing card to the trash of its deck.
 	// Error on fail or if the ID is not valid, nil on success
-	DeleteCard(ctx context.Context, deckID, cardID string) error
+	DeleteCard(ctx context.Context, deckID, cardID, deletedBy string, now time.Time) error
+
+	// GetDeletedCards fetches the cards in the trash of a deck, the latest deleted first.
+	// Error on fail, returns the deleted cards on success
+	GetDeletedCards(ctx context.Context, deckID string) ([]models.DeletedCard, error)
+
+	// RestoreCard moves a card out of the trash of its deck.
+	// Error on fail, if the card is not in the trash or its note is, returns the restored card on success
+	RestoreCard(ctx context.Context, deckID, cardID string) (map[string]any, error)
+
+	// PurgeDeletedCards permanently deletes the cards of every deck deleted before a time.
+	// Error on fail, returns the number of cards purged on success
+	PurgeDeletedCards(ctx context.Context, before time.Time) (int, error)
 
 	// GetCardProgress retrieves the progress of a card for a specific user.
+	// progressID is the card ID combined with the reviewed direction, see utils.ProgressID
 	// Error on fail, returns the progress if successful
-	GetCardProgress(ctx context.Context, deckID, cardID, userID string) (models.CardProgress, error)
+	GetCardProgress(
+		ctx context.Context,
+		deckID, progressID, userID string,
+	) (models.CardProgress, error)
 
+	// UpdateProgress sets the progress of a card for a specific user.
+	// Error on fail, nil on success
 	UpdateProgress(
 		ctx context.Context,
-		deckID, cardID, userID string,
+		deckID, progressID, userID string,
 		firestoreUpdates models.CardProgress,
 	) error
 
+	// GetProgressInDeck fetches the progress of a user on every card of a deck.
+	// Error on fail, returns the progress keyed by progress ID on success
+	GetProgressInDeck(
+		ctx context.Context,
+		deckID, userID string,
+	) (map[string]models.CardProgress, error)
+
+	// SetProgressInBulk sets the progress of several cards for a specific user,
+	// keyed by progress ID.
+	// Error on fail, nil on success
+	SetProgressInBulk(
+		ctx context.Context,
+		deckID, userID string,
+		progress map[string]models.CardProgress,
+	) error
+
+	// GetDueCardsInDeck fetches the cards a user should review, unstudied cards first.
+	// Cards reviewed in both directions are returned once per direction.
+	// Error on fail, returns the cards and the cursor for the next page on success
 	GetDueCardsInDeck(
 		ctx context.Context,
 		deckID, userID string,
 		limit int,
 		cursor string,
+		filter models.TagFilter,
 	) ([]map[string]any, string, bool, error)
+
+	// AddTagsToCards adds tags to the given cards, or every card in the deck if none are given,
+	// keeping the cards before in revisions.
+	// Error on fail, returns the number of cards updated on success
+	AddTagsToCards(
+		ctx context.Context,
+		deckID string,
+		cardIDs, tags []string,
+		revision models.CardRevision,
+	) (int, error)
+
+	// RemoveTagsFromCards removes tags from the given cards, or every card in the deck
+	// if none are given, keeping the cards before in revisions.
+	// Error on fail, returns the number of cards updated on success
+	RemoveTagsFromCards(
+		ctx context.Context,
+		deckID string,
+		cardIDs, tags []string,
+		revision models.CardRevision,
+	) (int, error)
+
+	// RenameTag renames a tag on every card in the deck, keeping the cards before in revisions.
+	// Error on fail, returns the number of cards updated on success
+	RenameTag(ctx context.Context, deckID, from, to string, revision models.CardRevision) (int, error)
+
+	// GetTagCounts counts how many cards in the deck have each tag.
+	// Error on fail, returns the count per tag on success
+	GetTagCounts(ctx context.Context, deckID string) (map[string]int, error)
+
+	// CountCards counts the cards in a deck.
+	// Error on fail, returns the number of cards on success
+	CountCards(ctx context.Context, deckID string) (int, error)
+
+	// GetCardSources fetches the source of every card imported from a document.
+	// Error on fail, returns the sources keyed by card ID on success
+	GetCardSources(ctx context.Context, deckID, document string) (map[string]models.CardSource, error)
+
+	// UpdateCards applies updates to several cards in bulk, keyed by card ID,
+	// keeping the cards before in revisions.
+	// Error on fail, returns the number of cards updated on success
+	UpdateCards(
+		ctx context.Context,
+		deckID string,
+		updates map[string][]firestore.Update,
+		revision models.CardRevision,
+	) (int, error)
+
+	// ReplaceCards overwrites several cards in bulk, keyed by card ID,
+	// keeping the cards before in revisions.
+	// Error on fail, nil on success
+	ReplaceCards(ctx context.Context, deckID string, cards map[string]any, revision models.CardRevision) error
+
+	// DeleteCards moves several cards to the trash of their deck in bulk.
+	// Error on fail, nil on success
+	DeleteCards(ctx context.Context, deckID string, cardIDs []string, deletedBy string, now time.Time) error
 }
 
+// Firestore allows at most 30 values in an array-contains-any filter
+const maxArrayContainsAny = 30
+
+// Firestore allows at most 500 writes in a transaction, and trashing a card takes two
+const maxTrashedPerTransaction = 250
+
 // FirestoreCardRepo holds the connection to the database
 type FirestoreCardRepo struct {
 	client *firestore.Client
@@ -72,46 +212,74 @@ func NewFirestoreCardRepo(client *firestore.Client) *FirestoreCardRepo {
 
 // GetCardsInDeck fetches all cards in a given deck with cursor-based pagination.
 // cursor is the document ID of the last card from the previous page (empty for first page)
-// Returns a list of cards and an error if the operation fails.
+// Cards not matching the tag filter are skipped, and more cards are fetched to fill the page,
+// skipping at most MAX_CARD_SCAN cards, so a page may be short while there are more.
+// Returns a list of cards, or an error if the operation fails or no card matches in the cards skipped.
 func (r *FirestoreCardRepo) GetCardsInDeck(
 	ctx context.Context,
 	deckID string,
 	limit int,
 	cursor string,
+	filter models.TagFilter,
 ) ([]map[string]any, bool, error) {
 	// Build the base query
 	query := r.client.Collection(config.DecksCollection).
 		Doc(deckID).
 		Collection(config.CardsCollection).
-		OrderBy(firestore.DocumentID, firestore.Asc).
-		Limit(limit + 1) // Fetch one extra to check for more pages
+		OrderBy(firestore.DocumentID, firestore.Asc)
 
-	// If we have a cursor, start after that document ID
-	// Using just the ID value is more efficient than fetching the document
-	if cursor != "" {
-		query = query.StartAfter(cursor)
+	// Let firestore narrow down the cards when it can,
+	// the rest of the filter is applied while reading the cards
+	if len(filter.Any) > 0 && len(filter.Any) <= maxArrayContainsAny {
+		query = query.Where("tags", "array-contains-any", filter.Any)
 	}
 
-	iter := query.Documents(ctx)
-	defer iter.Stop()
-
 	var result []map[string]any
+	skipped := 0
 
-	// Iterate through the documents in the collection
 	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
+		// Fetch one extra to check for more pages
+		pageQuery := query.Limit(limit + 1)
+
+		// If we have a cursor, start after that document ID
+		// Using just the ID value is more efficient than fetching the document
+		if cursor != "" {
+			pageQuery = pageQuery.StartAfter(cursor)
 		}
+
+		docs, err := pageQuery.Documents(ctx).GetAll()
 		if err != nil {
 			return nil, false, err
 		}
 
 		// Append the document data along with its ID
-		data := doc.Data()
-		data["id"] = doc.Ref.ID
+		for _, doc := range docs {
+			cursor = doc.Ref.ID
+
+			data := doc.Data()
+			if !filter.Matches(cardTags(data)) {
+				// A filter matching few cards stops early, the next page starting
+				// after the last card returned
+				if skipped++; skipped >= utils.MAX_CARD_SCAN {
+					if len(result) == 0 {
+						return nil, false, errors.ErrTagFilterTooNarrow
+					}
+					return result, true, nil
+				}
+				continue
+			}
+
+			data["id"] = doc.Ref.ID
+			result = append(result, data)
+			if len(result) > limit {
+				break
+			}
+		}
 
-		result = append(result, data)
+		// Stop when the page is full or there are no more cards
+		if len(result) > limit || len(docs) <= limit {
+			break
+		}
 	}
 
 	hasMore := false
@@ -141,6 +309,41 @@ func (r *FirestoreCardRepo) GetCardInDeck(
 	return doc.Data(), nil
 }
 
+// GetCardsByID fetches several cards of a deck in a single call, keyed by card ID.
+// Cards that do not exist are left out.
+// Returns the cards or an error if the operation fails.
+func (r *FirestoreCardRepo) GetCardsByID(
+	ctx context.Context,
+	deckID string,
+	cardIDs []string,
+) (map[string]map[string]any, error) {
+	cards := make(map[string]map[string]any, len(cardIDs))
+	if len(cardIDs) == 0 {
+		return cards, nil
+	}
+
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
+
+	refs := make([]*firestore.DocumentRef, len(cardIDs))
+	for i, id := range cardIDs {
+		refs[i] = cardsRef.Doc(id)
+	}
+
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if doc.Exists() {
+			cards[doc.Ref.ID] = doc.Data()
+		}
+	}
+
+	return cards, nil
+}
+
 // CreateCard takes a context and a card, adds it to the database, and
 // returns the created card or an error if the operation fails.
 func (r *FirestoreCardRepo) CreateCard(
@@ -157,60 +360,325 @@ func (r *FirestoreCardRepo) CreateCard(
 	return docRef.ID, nil
 }
 
+// CreateCards adds every card to the deck using a BulkWriter.
+// Returns the IDs of the cards in the same order, or an error if one of them was not created.
+func (r *FirestoreCardRepo) CreateCards(
+	ctx context.Context,
+	deckID string,
+	cards []any,
+) ([]string, error) {
+	if len(cards) == 0 {
+		return nil, nil
+	}
+
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
+
+	bulkWriter := r.client.BulkWriter(ctx)
+
+	ids := make([]string, 0, len(cards))
+	jobs := make([]*firestore.BulkWriterJob, 0, len(cards))
+	for _, card := range cards {
+		ref := cardsRef.NewDoc()
+		job, err := bulkWriter.Create(ref, card)
+		if err != nil {
+			bulkWriter.End()
+			return nil, err
+		}
+		ids = append(ids, ref.ID)
+		jobs = append(jobs, job)
+	}
+
+	// Wait for all operations to complete
+	bulkWriter.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
 // UpdateCard takes a context, an update payload, and an ID, and updates
-// the corresponding card in the database. It returns an error if the update
+// the corresponding card in the database. The fields of the card before the update
+// are stored in the revision in the same transaction, so no change is ever lost.
+// It returns the fields before the update, or an error if the update
 // fails or the card cannot be found
 func (r *FirestoreCardRepo) UpdateCard(
 	ctx context.Context,
 	firestoreUpdates []firestore.Update,
 	deckID, cardID string,
-) error {
+	revision models.CardRevision,
+) (map[string]any, error) {
+	return r.reviseCard(ctx, deckID, cardID, revision, func(tx *firestore.Transaction, docRef *firestore.DocumentRef) error {
+		return tx.Update(docRef, firestoreUpdates)
+	})
+}
+
+// ReplaceCard overwrites a card with all the fields of card, so fields left empty are
+// cleared as well. The fields of the card before are stored in the revision in the
+// same transaction.
+// It returns the fields before the overwrite, or an error if it fails or the card cannot be found
+func (r *FirestoreCardRepo) ReplaceCard(
+	ctx context.Context,
+	card any,
+	deckID, cardID string,
+	revision models.CardRevision,
+) (map[string]any, error) {
+	return r.reviseCard(ctx, deckID, cardID, revision, func(tx *firestore.Transaction, docRef *firestore.DocumentRef) error {
+		return tx.Set(docRef, card)
+	})
+}
+
+// reviseCard stores the fields of a card in a revision and changes the card with write,
+// in a single transaction.
+// Returns the fields before the change, or an error if the card cannot be found or the operation fails.
+func (r *FirestoreCardRepo) reviseCard(
+	ctx context.Context,
+	deckID, cardID string,
+	revision models.CardRevision,
+	write func(tx *firestore.Transaction, docRef *firestore.DocumentRef) error,
+) (map[string]any, error) {
 	docRef := r.client.
 		Collection(config.DecksCollection).
 		Doc(deckID).
 		Collection(config.CardsCollection).
 		Doc(cardID)
 
-	// Perform the update
-	_, err := docRef.Update(ctx, firestoreUpdates)
+	var fields map[string]any
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		fields = doc.Data()
+
+		revision.Fields = fields
+		if err := tx.Create(docRef.Collection(config.RevisionsCollection).NewDoc(), revision); err != nil {
+			return err
+		}
+
+		return write(tx, docRef)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	return fields, nil
 }
 
-// DeleteCard takes a context and ID, and deletes the corresponding
-// card in the database. It returns an error if the delete fails,
-// or if it cannot be found
-func (r *FirestoreCardRepo) DeleteCard(
+// GetCardRevisions fetches every revision of a card, the latest first.
+// Returns the revisions or an error if the operation fails.
+func (r *FirestoreCardRepo) GetCardRevisions(
 	ctx context.Context,
 	deckID, cardID string,
+) ([]models.CardRevision, error) {
+	docs, err := r.client.
+		Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.CardsCollection).Doc(cardID).
+		Collection(config.RevisionsCollection).
+		OrderBy("created_at", firestore.Desc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.CardRevision, 0, len(docs))
+	for _, doc := range docs {
+		var revision models.CardRevision
+		if err := doc.DataTo(&revision); err != nil {
+			return nil, err
+		}
+		revision.ID = doc.Ref.ID
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// GetCardRevision fetches a revision of a card by its ID.
+// Returns the revision or an error if it does not exist or the operation fails.
+func (r *FirestoreCardRepo) GetCardRevision(
+	ctx context.Context,
+	deckID, cardID, revisionID string,
+) (models.CardRevision, error) {
+	doc, err := r.client.
+		Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.CardsCollection).Doc(cardID).
+		Collection(config.RevisionsCollection).Doc(revisionID).
+		Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return models.CardRevision{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.CardRevision{}, err
+	}
+
+	var revision models.CardRevision
+	if err := doc.DataTo(&revision); err != nil {
+		return models.CardRevision{}, err
+	}
+	revision.ID = doc.Ref.ID
+
+	return revision, nil
+}
+
+// DeleteCard moves a card to the trash of its deck in a transaction,
+// so listings and due queries no longer see it. Its revisions stay under the card
+// and are found again when it is restored.
+// Error on fail or if the ID is not valid.
+// Returns nil on success
+func (r *FirestoreCardRepo) DeleteCard(
+	ctx context.Context,
+	deckID, cardID, deletedBy string,
+	now time.Time,
 ) error {
-	docRef := r.client.
-		Collection(config.DecksCollection).
+	cardRef := r.client.Collection(config.DecksCollection).
 		Doc(deckID).
 		Collection(config.CardsCollection).
 		Doc(cardID)
-	_, err := docRef.Get(ctx)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(cardRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+
+		return trashCard(tx, doc, deletedBy, now)
+	})
+}
+
+// GetDeletedCards fetches the cards in the trash of a deck, the latest deleted first.
+// Returns the deleted cards or an error if the operation fails.
+func (r *FirestoreCardRepo) GetDeletedCards(
+	ctx context.Context,
+	deckID string,
+) ([]models.DeletedCard, error) {
+	docs, err := r.client.
+		Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.TrashCollection).
+		OrderBy("deleted_at", firestore.Desc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make([]models.DeletedCard, 0, len(docs))
+	for _, doc := range docs {
+		var card models.DeletedCard
+		if err := doc.DataTo(&card); err != nil {
+			return nil, err
+		}
+		card.ID = doc.Ref.ID
+		cards = append(cards, card)
+	}
+
+	return cards, nil
+}
+
+// RestoreCard moves a card out of the trash of its deck in a transaction,
+// back under the same ID so its revisions and progress are kept.
+// Returns the restored card, or an error if it is not in the trash or the operation fails.
+func (r *FirestoreCardRepo) RestoreCard(
+	ctx context.Context,
+	deckID, cardID string,
+) (map[string]any, error) {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+	cardRef := deckRef.Collection(config.CardsCollection).Doc(cardID)
+	trashRef := deckRef.Collection(config.TrashCollection).Doc(cardID)
+
+	var card map[string]any
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(trashRef)
+		if status.Code(err) == codes.NotFound {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var deleted models.DeletedCard
+		if err := doc.DataTo(&deleted); err != nil {
+			return err
+		}
+		// Notes are restored along with their cards by the note repository
+		if deleted.Note != nil {
+			return errors.ErrInvalidCard
+		}
+		card = deleted.Card
+
+		// Cards of a note in the trash come back with the note
+		if noteID, ok := card["note_id"].(string); ok && noteID != "" {
+			_, err := tx.Get(deckRef.Collection(config.NotesCollection).Doc(noteID))
+			if status.Code(err) == codes.NotFound {
+				return errors.ErrInvalidCard
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(cardRef, card); err != nil {
+			return err
+		}
+		return tx.Delete(trashRef)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return card, nil
+}
+
+// PurgeDeletedCards permanently deletes the cards in the trash of every deck
+// deleted before a time, along with their revisions.
+// Returns the number of cards purged, or an error if the operation fails.
+func (r *FirestoreCardRepo) PurgeDeletedCards(
+	ctx context.Context,
+	before time.Time,
+) (int, error) {
+	docs, err := r.client.CollectionGroup(config.TrashCollection).
+		Where("deleted_at", "<", before).
+		Select().
+		Documents(ctx).
+		GetAll()
 	if err != nil {
-		return errors.ErrInvalidId
+		return 0, err
 	}
 
-	// Delete the document
-	_, err = docRef.Delete(ctx)
-	return err
+	var refs []*firestore.DocumentRef
+	for _, doc := range docs {
+		// The revisions are still kept under the card
+		cardRef := doc.Ref.Parent.Parent.Collection(config.CardsCollection).Doc(doc.Ref.ID)
+		revisions, err := revisionRefs(ctx, cardRef)
+		if err != nil {
+			return 0, err
+		}
+		refs = append(append(refs, revisions...), doc.Ref)
+	}
+
+	if err := deleteDocs(ctx, r.client, refs); err != nil {
+		return 0, err
+	}
+
+	return len(docs), nil
 }
 
 // GetCardProgress retrieves the progress of a card for a specific user.
 // Returns the progress or an error if the operation fails.
 func (r *FirestoreCardRepo) GetCardProgress(
 	ctx context.Context,
-	deckID, cardID, userID string,
+	deckID, progressID, userID string,
 ) (models.CardProgress, error) {
 	doc, err := r.client.
 		Collection(config.DecksCollection).Doc(deckID).
 		Collection(config.UsersCollection).Doc(userID).
-		Collection(config.ProgressCollection).Doc(cardID).
+		Collection(config.ProgressCollection).Doc(progressID).
 		Get(ctx)
 	if err != nil {
 		return models.CardProgress{}, errors.ErrInvalidId
@@ -228,13 +696,13 @@ func (r *FirestoreCardRepo) GetCardProgress(
 // Returns an error if the operation fails.
 func (r *FirestoreCardRepo) UpdateProgress(
 	ctx context.Context,
-	deckID, cardID, userID string,
+	deckID, progressID, userID string,
 	firestoreUpdates models.CardProgress,
 ) error {
 	docRef := r.client.
 		Collection(config.DecksCollection).Doc(deckID).
 		Collection(config.UsersCollection).Doc(userID).
-		Collection(config.ProgressCollection).Doc(cardID)
+		Collection(config.ProgressCollection).Doc(progressID)
 
 	_, err := docRef.Set(ctx, firestoreUpdates)
 	if err != nil {
@@ -243,158 +711,741 @@ func (r *FirestoreCardRepo) UpdateProgress(
 	return nil
 }
 
-// GetDueCardsInDeck fetches due cards for a user in a deck with pagination support
+// GetProgressInDeck fetches every progress document of a user in a deck.
+// Returns the progress keyed by progress ID, or an error if the operation fails.
+func (r *FirestoreCardRepo) GetProgressInDeck(
+	ctx context.Context,
+	deckID, userID string,
+) (map[string]models.CardProgress, error) {
+	iter := r.client.
+		Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.UsersCollection).Doc(userID).
+		Collection(config.ProgressCollection).
+		Documents(ctx)
+	defer iter.Stop()
+
+	progress := make(map[string]models.CardProgress)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var p models.CardProgress
+		if err := doc.DataTo(&p); err != nil {
+			return nil, err
+		}
+		progress[doc.Ref.ID] = p
+	}
+
+	return progress, nil
+}
+
+// SetProgressInBulk sets the progress of several cards for a user using a BulkWriter.
+// Returns an error if one of the writes fails.
+func (r *FirestoreCardRepo) SetProgressInBulk(
+	ctx context.Context,
+	deckID, userID string,
+	progress map[string]models.CardProgress,
+) error {
+	if len(progress) == 0 {
+		return nil
+	}
+
+	progressRef := r.client.
+		Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.UsersCollection).Doc(userID).
+		Collection(config.ProgressCollection)
+
+	bulkWriter := r.client.BulkWriter(ctx)
+
+	jobs := make([]*firestore.BulkWriterJob, 0, len(progress))
+	for progressID, p := range progress {
+		job, err := bulkWriter.Set(progressRef.Doc(progressID), p)
+		if err != nil {
+			bulkWriter.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+
+	// Wait for all operations to complete
+	bulkWriter.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetDueCardsInDeck fetches due cards for a user in a deck with pagination support.
+// Every direction a card is reviewed in is its own item, marked with the direction.
 // Returns a list of cards, next cursor, hasMore flag, and an error if the operation fails.
 func (r *FirestoreCardRepo) GetDueCardsInDeck(
 	ctx context.Context,
 	deckID, userID string,
 	limit int,
 	cursor string,
+	filter models.TagFilter,
 ) ([]map[string]any, string, bool, error) {
 
 	var cards []map[string]any
-	var nextCursor string
 
-	// Fetch all progress documents for the user to identify studied cards
-	progressMap := make(map[string]bool)
-	allProgressIter := r.client.
-		Collection(config.DecksCollection).Doc(deckID).
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+	progressCollection := deckRef.
 		Collection(config.UsersCollection).Doc(userID).
-		Collection(config.ProgressCollection).
-		Documents(ctx)
+		Collection(config.ProgressCollection)
+
+	// Unstudied cards are all returned before the due cards, so a due cursor
+	// means the unstudied cards have already been paginated through
+	if !strings.HasPrefix(cursor, "due_") {
+		// Fetch all progress documents for the user to identify studied cards
+		progressMap := make(map[string]bool)
+		allProgressIter := progressCollection.Documents(ctx)
 
+		for {
+			doc, err := allProgressIter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, "", false, err
+			}
+			progressMap[doc.Ref.ID] = true
+		}
+
+		allProgressIter.Stop()
+
+		// Prioritize unstudied cards first
+		unstudiedQuery := deckRef.
+			Collection(config.CardsCollection).
+			OrderBy(firestore.DocumentID, firestore.Asc).
+			Limit(limit + 1)
+
+		// Apply a cursor if provided
+		if after, ok := strings.CutPrefix(cursor, "unstudied_"); ok && after != "" {
+			unstudiedQuery = unstudiedQuery.StartAfter(after)
+		}
+
+		unstudiedIter := unstudiedQuery.Documents(ctx)
+		defer unstudiedIter.Stop()
+
+		lastUnstudiedID := ""
+		read := 0
+		hasMoreUnstudied := false
+
+		// Iterate through unstudied cards
+		for {
+			cardDoc, err := unstudiedIter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, "", false, err
+			}
+
+			// Stop once the page is full, so every direction of a card ends up on the same page
+			if len(cards) >= limit {
+				hasMoreUnstudied = true
+				break
+			}
+
+			read++
+			cardID := cardDoc.Ref.ID
+			lastUnstudiedID = cardID
+			cardData := cardDoc.Data()
+			if !filter.Matches(cardTags(cardData)) {
+				continue
+			}
+
+			// Add every direction of the card that has not been studied yet
+			for _, direction := range utils.ReviewDirections(cardData) {
+				if !progressMap[utils.ProgressID(cardID, direction)] {
+					cards = append(cards, reviewItem(cardID, cardData, direction))
+				}
+			}
+		}
+
+		// A full query means there might be more unstudied cards left
+		if hasMoreUnstudied || read > limit {
+			return cards, "unstudied_" + lastUnstudiedID, true, nil
+		}
+	}
+
+	// We need more cards, fetch studied cards based on due date
+	remaining := limit - len(cards)
+
+	// Build the due date query
+	dueQuery := progressCollection.
+		OrderBy("due", firestore.Desc).
+		Limit(remaining + 1)
+
+	// Apply cursor if provided in previous query
+	if after, ok := strings.CutPrefix(cursor, "due_"); ok && after != "" {
+		snap, err := progressCollection.Doc(after).Get(ctx)
+		if err != nil {
+			return nil, "", false, errors.ErrInvalidId
+		}
+		dueQuery = dueQuery.StartAfter(snap)
+	}
+
+	dueIter := dueQuery.Documents(ctx)
+	defer dueIter.Stop()
+
+	var progressIDs []string
 	for {
-		doc, err := allProgressIter.Next()
+		progressDoc, err := dueIter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
 			return nil, "", false, err
 		}
-		progressMap[doc.Ref.ID] = true
+		progressIDs = append(progressIDs, progressDoc.Ref.ID)
 	}
 
-	allProgressIter.Stop()
+	// The extra document tells if there are more studied cards to paginate through
+	hasMore := len(progressIDs) > remaining
+	nextCursor := ""
+	if hasMore {
+		progressIDs = progressIDs[:remaining]
+		nextCursor = "due_"
+		if remaining > 0 {
+			nextCursor += progressIDs[remaining-1]
+		}
+	}
 
-	// Prioritize unstudied cards first
-	unstudiedQuery := r.client.
-		Collection(config.DecksCollection).Doc(deckID).
+	if len(progressIDs) == 0 {
+		return cards, nextCursor, hasMore, nil
+	}
+
+	// Batch fetch due cards to not load network multiple times
+	cardRefs := make([]*firestore.DocumentRef, len(progressIDs))
+	directions := make([]string, len(progressIDs))
+	for i, progressID := range progressIDs {
+		cardID, direction := utils.SplitProgressID(progressID)
+		cardRefs[i] = deckRef.Collection(config.CardsCollection).Doc(cardID)
+		directions[i] = direction
+	}
+
+	cardDocs, err := r.client.GetAll(ctx, cardRefs)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	for i, cardDoc := range cardDocs {
+		// Skip progress left behind by deleted cards or directions no longer reviewed
+		if !cardDoc.Exists() {
+			continue
+		}
+		cardData := cardDoc.Data()
+		if !slices.Contains(utils.ReviewDirections(cardData), directions[i]) ||
+			!filter.Matches(cardTags(cardData)) {
+			continue
+		}
+		cards = append(cards, reviewItem(cardDoc.Ref.ID, cardData, directions[i]))
+	}
+
+	return cards, nextCursor, hasMore, nil
+}
+
+// reviewItem returns a copy of the card data with its ID set.
+// Front/back cards are marked with the direction they are reviewed in.
+func reviewItem(cardID string, cardData map[string]any, direction string) map[string]any {
+	item := maps.Clone(cardData)
+	item["id"] = cardID
+	if item["type"] == utils.FRONT_BACK_CARD {
+		item["review_direction"] = direction
+	}
+	return item
+}
+
+// AddTagsToCards adds tags to the given cards, or every card in the deck if no IDs are given.
+// Returns the number of cards updated, or an error if one of the updates fails.
+func (r *FirestoreCardRepo) AddTagsToCards(
+	ctx context.Context,
+	deckID string,
+	cardIDs, tags []string,
+	revision models.CardRevision,
+) (int, error) {
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
+
+	var refs []*firestore.DocumentRef
+	if len(cardIDs) > 0 {
+		for _, id := range cardIDs {
+			refs = append(refs, cardsRef.Doc(id))
+		}
+	} else {
+		var err error
+		refs, err = cardsRef.DocumentRefs(ctx).GetAll()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	update := []firestore.Update{
+		{Path: "tags", Value: firestore.ArrayUnion(toAnySlice(tags)...)},
+	}
+
+	return r.updateCardsInBulk(ctx, refs, revision, func(*firestore.DocumentRef) []firestore.Update {
+		return update
+	})
+}
+
+// RemoveTagsFromCards removes tags from the given cards, or every card in the deck if no IDs
+// are given. Returns the number of cards updated, or an error if one of the updates fails.
+func (r *FirestoreCardRepo) RemoveTagsFromCards(
+	ctx context.Context,
+	deckID string,
+	cardIDs, tags []string,
+	revision models.CardRevision,
+) (int, error) {
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
+
+	var refs []*firestore.DocumentRef
+	if len(cardIDs) > 0 {
+		for _, id := range cardIDs {
+			refs = append(refs, cardsRef.Doc(id))
+		}
+	} else {
+		// Only the cards having one of the tags need an update
+		query := cardsRef.Query
+		if len(tags) <= maxArrayContainsAny {
+			query = query.Where("tags", "array-contains-any", tags)
+		}
+
+		docs, err := query.Select().Documents(ctx).GetAll()
+		if err != nil {
+			return 0, err
+		}
+		for _, doc := range docs {
+			refs = append(refs, doc.Ref)
+		}
+	}
+
+	update := []firestore.Update{
+		{Path: "tags", Value: firestore.ArrayRemove(toAnySlice(tags)...)},
+	}
+
+	return r.updateCardsInBulk(ctx, refs, revision, func(*firestore.DocumentRef) []firestore.Update {
+		return update
+	})
+}
+
+// RenameTag replaces a tag with another one on every card in the deck having it.
+// Returns the number of cards updated, or an error if one of the updates fails.
+func (r *FirestoreCardRepo) RenameTag(
+	ctx context.Context,
+	deckID, from, to string,
+	revision models.CardRevision,
+) (int, error) {
+	docs, err := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
 		Collection(config.CardsCollection).
-		OrderBy(firestore.DocumentID, firestore.Asc).
-		Limit(limit + 1)
+		Where("tags", "array-contains", from).
+		Select("tags").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return 0, err
+	}
 
-	// Apply a cursor if provided
-	if cursor != "" && len(cursor) > 10 && cursor[:10] == "unstudied_" {
-		unstudiedQuery = unstudiedQuery.StartAfter(cursor[10:])
+	// Compute the new tags of each card, keeping the order and avoiding duplicates
+	newTags := make(map[string][]string, len(docs))
+	refs := make([]*firestore.DocumentRef, 0, len(docs))
+	for _, doc := range docs {
+		var tags []string
+		for _, tag := range cardTags(doc.Data()) {
+			if tag == from {
+				tag = to
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		newTags[doc.Ref.ID] = tags
+		refs = append(refs, doc.Ref)
 	}
 
-	unstudiedIter := unstudiedQuery.Documents(ctx)
-	defer unstudiedIter.Stop()
+	return r.updateCardsInBulk(ctx, refs, revision, func(ref *firestore.DocumentRef) []firestore.Update {
+		return []firestore.Update{{Path: "tags", Value: newTags[ref.ID]}}
+	})
+}
 
-	lastUnstudiedID := ""
-	skipped := 0
+// GetTagCounts counts how many cards in a deck have each tag.
+// Returns the counts, or an error if the cards could not be read.
+func (r *FirestoreCardRepo) GetTagCounts(
+	ctx context.Context,
+	deckID string,
+) (map[string]int, error) {
+	iter := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection).
+		Select("tags").
+		Documents(ctx)
+	defer iter.Stop()
 
-	// Iterate through unstudied cards
+	counts := make(map[string]int)
 	for {
-		cardDoc, err := unstudiedIter.Next()
+		doc, err := iter.Next()
 		if err == iterator.Done {
 			break
 		}
 		if err != nil {
-			return nil, "", false, err
+			return nil, err
 		}
 
-		cardID := cardDoc.Ref.ID
-		lastUnstudiedID = cardID
-
-		// If the card is unstudied, add it to the results
-		if !progressMap[cardID] {
-			cardData := cardDoc.Data()
-			cardData["id"] = cardID
-			cards = append(cards, cardData)
-		} else {
-			// Skip studied cards used to decide if it has more unstudied
-			skipped++
+		for _, tag := range cardTags(doc.Data()) {
+			counts[tag]++
 		}
 	}
 
-	// Check if we have enough unstudied cards
-	hasMoreUnstudied := len(cards)+skipped > limit
-	if hasMoreUnstudied {
-		// Get the new pagination cursor and return
-		nextCursor = "unstudied_" + lastUnstudiedID
-		if len(cards) > limit {
-			return cards[:limit], nextCursor, true, nil
+	return counts, nil
+}
+
+// CountCards counts the cards of a deck with an aggregation query, without reading them.
+// Returns the number of cards, or an error if the query fails.
+func (r *FirestoreCardRepo) CountCards(ctx context.Context, deckID string) (int, error) {
+	result, err := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection).
+		NewAggregationQuery().
+		WithCount("count").
+		Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count, ok := result["count"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count result %v", result["count"])
+	}
+	return int(count.GetIntegerValue()), nil
+}
+
+// GetCardSources fetches the source of every card of a deck imported from the given document.
+// Returns the sources keyed by card ID, or an error if the cards could not be read.
+func (r *FirestoreCardRepo) GetCardSources(
+	ctx context.Context,
+	deckID, document string,
+) (map[string]models.CardSource, error) {
+	docs, err := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection).
+		Where("source.document", "==", document).
+		Select("source").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]models.CardSource, len(docs))
+	for _, doc := range docs {
+		var card struct {
+			Source models.CardSource `firestore:"source"`
 		}
-		return cards, nextCursor, true, nil
+		if err := doc.DataTo(&card); err != nil {
+			return nil, err
+		}
+		sources[doc.Ref.ID] = card.Source
 	}
 
-	// We need more cards, fetch studied cards based on due date
-	if len(cards) < limit {
-		remaining := limit - len(cards)
+	return sources, nil
+}
 
-		// Build the due date query
-		dueQuery := r.client.
-			Collection(config.DecksCollection).Doc(deckID).
-			Collection(config.UsersCollection).Doc(userID).
-			Collection(config.ProgressCollection).
-			OrderBy("due", firestore.Desc).
-			Limit(remaining + 1)
+// UpdateCards applies the updates of every card using a BulkWriter.
+// Returns the number of cards updated, or an error if one of the cards could not be updated.
+func (r *FirestoreCardRepo) UpdateCards(
+	ctx context.Context,
+	deckID string,
+	updates map[string][]firestore.Update,
+	revision models.CardRevision,
+) (int, error) {
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
 
-		// Apply cursor if provided in previous query
-		if len(cursor) >= 4 && cursor[:4] == "due_" {
-			dueQuery = dueQuery.StartAfter(cursor[4:])
+	refs := make([]*firestore.DocumentRef, 0, len(updates))
+	for id := range updates {
+		refs = append(refs, cardsRef.Doc(id))
+	}
+
+	return r.updateCardsInBulk(ctx, refs, revision, func(ref *firestore.DocumentRef) []firestore.Update {
+		return updates[ref.ID]
+	})
+}
+
+// ReplaceCards overwrites the given cards of a deck using a BulkWriter,
+// keeping the cards that existed before in revisions.
+// Returns an error if one of the cards could not be written.
+func (r *FirestoreCardRepo) ReplaceCards(
+	ctx context.Context,
+	deckID string,
+	cards map[string]any,
+	revision models.CardRevision,
+) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
+
+	refs := make([]*firestore.DocumentRef, 0, len(cards))
+	for id := range cards {
+		refs = append(refs, cardsRef.Doc(id))
+	}
+
+	bulkWriter := r.client.BulkWriter(ctx)
+
+	jobs, err := r.writeRevisions(ctx, bulkWriter, refs, revision)
+	if err != nil {
+		bulkWriter.End()
+		return err
+	}
+	for _, ref := range refs {
+		job, err := bulkWriter.Set(ref, cards[ref.ID])
+		if err != nil {
+			bulkWriter.End()
+			return err
 		}
+		jobs = append(jobs, job)
+	}
 
-		dueIter := dueQuery.Documents(ctx)
+	// Wait for all operations to complete
+	bulkWriter.End()
 
-		defer dueIter.Stop()
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
 
-		lastDueID := ""
+	return nil
+}
 
-		cardRefs := []*firestore.DocumentRef{}
+// DeleteCards moves the given cards of a deck to its trash, in transactions of
+// up to maxTrashedPerTransaction cards. Cards that do not exist are left out.
+// Returns an error if one of the cards could not be moved.
+func (r *FirestoreCardRepo) DeleteCards(
+	ctx context.Context,
+	deckID string,
+	cardIDs []string,
+	deletedBy string,
+	now time.Time,
+) error {
+	cardsRef := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection)
 
-		// Batch fetch due cards to not load network multiple times
-		for {
-			progressDoc, err := dueIter.Next()
-			if err == iterator.Done {
-				break
-			}
+	for ids := range slices.Chunk(cardIDs, maxTrashedPerTransaction) {
+		refs := make([]*firestore.DocumentRef, len(ids))
+		for i, id := range ids {
+			refs[i] = cardsRef.Doc(id)
+		}
+
+		err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			docs, err := tx.GetAll(refs)
 			if err != nil {
-				return nil, "", false, err
+				return err
+			}
+			for _, doc := range docs {
+				if !doc.Exists() {
+					continue
+				}
+				if err := trashCard(tx, doc, deletedBy, now); err != nil {
+					return err
+				}
 			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-			cardID := progressDoc.Ref.ID
-			lastDueID = cardID
+	return nil
+}
 
-			cardRef := r.client.
-				Collection(config.DecksCollection).Doc(deckID).
-				Collection(config.CardsCollection).Doc(cardID)
+// trashCard moves a fetched card to the trash of its deck within a transaction.
+// The trash entry is set, so a card deleted again after a restore replaces its old entry.
+// Returns an error if one of the writes fails.
+func trashCard(
+	tx *firestore.Transaction,
+	doc *firestore.DocumentSnapshot,
+	deletedBy string,
+	now time.Time,
+) error {
+	trashRef := doc.Ref.Parent.Parent.Collection(config.TrashCollection).Doc(doc.Ref.ID)
+	err := tx.Set(trashRef, models.DeletedCard{
+		Card:      doc.Data(),
+		DeletedBy: deletedBy,
+		DeletedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(doc.Ref)
+}
 
-			cardRefs = append(cardRefs, cardRef)
+// revisionRefs lists the revisions of cards, to delete them along with the cards.
+// Returns the references of the revisions or an error if the operation fails.
+func revisionRefs(
+	ctx context.Context,
+	cardRefs ...*firestore.DocumentRef,
+) ([]*firestore.DocumentRef, error) {
+	var refs []*firestore.DocumentRef
+	for _, cardRef := range cardRefs {
+		docs, err := cardRef.Collection(config.RevisionsCollection).Select().Documents(ctx).GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			refs = append(refs, doc.Ref)
 		}
+	}
 
-		// If there are card references, fetch their data
-		if len(cardRefs) > 0 {
-			cardDocs, err := r.client.GetAll(ctx, cardRefs)
-			if err != nil {
-				return nil, "", false, err
-			}
+	return refs, nil
+}
 
-			for _, cardDoc := range cardDocs {
-				cardData := cardDoc.Data()
-				cardData["id"] = cardDoc.Ref.ID
-				cards = append(cards, cardData)
-			}
+// deleteDocs deletes documents using a BulkWriter.
+// Returns an error if one of the documents could not be deleted.
+func deleteDocs(
+	ctx context.Context,
+	client *firestore.Client,
+	refs []*firestore.DocumentRef,
+) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	bulkWriter := client.BulkWriter(ctx)
+
+	jobs := make([]*firestore.BulkWriterJob, 0, len(refs))
+	for _, ref := range refs {
+		job, err := bulkWriter.Delete(ref)
+		if err != nil {
+			bulkWriter.End()
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+
+	// Wait for all operations to complete
+	bulkWriter.End()
+
+	for _, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateCardsInBulk applies the updates returned by updatesFor to every card using a BulkWriter,
+// keeping the cards before in revisions.
+// Returns the number of cards updated, or an error if one of the cards could not be updated.
+func (r *FirestoreCardRepo) updateCardsInBulk(
+	ctx context.Context,
+	refs []*firestore.DocumentRef,
+	revision models.CardRevision,
+	updatesFor func(ref *firestore.DocumentRef) []firestore.Update,
+) (int, error) {
+	if len(refs) == 0 {
+		return 0, nil
+	}
+
+	bulkWriter := r.client.BulkWriter(ctx)
+
+	revisionJobs, err := r.writeRevisions(ctx, bulkWriter, refs, revision)
+	if err != nil {
+		bulkWriter.End()
+		return 0, err
+	}
+
+	jobs := make([]*firestore.BulkWriterJob, 0, len(refs))
+	for _, ref := range refs {
+		job, err := bulkWriter.Update(ref, updatesFor(ref))
+		if err != nil {
+			bulkWriter.End()
+			return 0, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	// Wait for all operations to complete
+	bulkWriter.End()
+
+	for _, job := range append(jobs, revisionJobs...) {
+		if _, err := job.Results(); err != nil {
+			return 0, errors.ErrFailedUpdatingCards
+		}
+	}
+
+	return len(jobs), nil
+}
+
+// writeRevisions reads the given cards and queues a revision with the fields of each
+// existing one on the BulkWriter, before the cards are changed.
+// Returns the queued jobs, or an error if the cards could not be read.
+func (r *FirestoreCardRepo) writeRevisions(
+	ctx context.Context,
+	bulkWriter *firestore.BulkWriter,
+	refs []*firestore.DocumentRef,
+	revision models.CardRevision,
+) ([]*firestore.BulkWriterJob, error) {
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*firestore.BulkWriterJob, 0, len(docs))
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
 		}
+		revision.Fields = doc.Data()
+		job, err := bulkWriter.Create(doc.Ref.Collection(config.RevisionsCollection).NewDoc(), revision)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
 
-		// Check if we have more studied cards for pagination
-		if len(cards) > limit {
-			nextCursor = "due_" + lastDueID
-			return cards[:limit], nextCursor, true, nil
+// cardTags reads the tags from the raw data of a card.
+func cardTags(card map[string]any) []string {
+	raw, _ := card["tags"].([]any)
+
+	tags := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if tag, ok := v.(string); ok {
+			tags = append(tags, tag)
 		}
 	}
+	return tags
+}
 
-	// No more cards to paginate through
-	return cards, "", false, nil
+// toAnySlice converts a slice of strings to a slice of any, as needed by array transforms.
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
 }
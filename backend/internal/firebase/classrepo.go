@@ -0,0 +1,414 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/errors"
+	"memora/internal/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClassRepository defines methods for classes, their students and their assignments.
+type ClassRepository interface {
+	// CreateClass adds a new class to firestore.
+	// Error on fail, returns the ID of the class on success
+	CreateClass(ctx context.Context, class models.Class) (string, error)
+
+	// GetClass fetches a class by its ID.
+	// Error on fail or if the ID is not valid
+	GetClass(ctx context.Context, id string) (models.Class, error)
+
+	// GetClassByJoinCode fetches the class with a join code.
+	// Error on fail or if no class has the code
+	GetClassByJoinCode(ctx context.Context, code string) (models.Class, error)
+
+	// GetTeacherClasses fetches the classes taught by a user.
+	// Error on fail, returns the classes on success
+	GetTeacherClasses(ctx context.Context, teacherID string) ([]models.Class, error)
+
+	// GetStudentClasses fetches the classes a user is enrolled in.
+	// Error on fail, returns the classes on success
+	GetStudentClasses(ctx context.Context, studentID string) ([]models.Class, error)
+
+	// DeleteClass deletes a class with its assignments.
+	// Error on fail, nil on success
+	DeleteClass(ctx context.Context, id string) error
+
+	// AddStudents enrolls users in a class, students enrolled before are kept as they are.
+	// The pending invites of their emails are dropped.
+	// Error on fail or if the ID is not valid, returns the number of students added on success
+	AddStudents(
+		ctx context.Context,
+		classID string,
+		students map[string]models.ClassStudent,
+	) (int, error)
+
+	// InviteEmails invites emails to a class, leaving out those invited or enrolled before.
+	// Error on fail or if the ID is not valid, returns the number of emails invited on success
+	InviteEmails(ctx context.Context, classID string, emails []string) (int, error)
+
+	// GetInvitedClasses fetches the classes an email is invited to.
+	// Error on fail, returns the classes on success
+	GetInvitedClasses(ctx context.Context, email string) ([]models.Class, error)
+
+	// RemoveStudent removes a student from a class.
+	// Error on fail, or if the user is not a student, nil on success
+	RemoveStudent(ctx context.Context, classID, studentID string) error
+
+	// AddAssignment assigns a deck to a class.
+	// Error on fail, returns the ID of the assignment on success
+	AddAssignment(
+		ctx context.Context,
+		classID string,
+		assignment models.Assignment,
+	) (string, error)
+
+	// GetAssignments fetches the assignments of a class, the earliest due first.
+	// Error on fail, returns the assignments on success
+	GetAssignments(ctx context.Context, classID string) ([]models.Assignment, error)
+
+	// DeleteAssignment deletes an assignment of a class.
+	// Error on fail, or if the ID is not valid, nil on success
+	DeleteAssignment(ctx context.Context, classID, id string) error
+}
+
+// FirestoreClassRepo implements the ClassRepository interface using Firestore.
+type FirestoreClassRepo struct {
+	client *firestore.Client
+}
+
+// NewFirestoreClassRepo creates and returns a pointer to the FirestoreClassRepo.
+func NewFirestoreClassRepo(client *firestore.Client) *FirestoreClassRepo {
+	return &FirestoreClassRepo{client: client}
+}
+
+// CreateClass adds a new class to firestore.
+// Returns the ID of the class or an error if the operation fails.
+func (r *FirestoreClassRepo) CreateClass(
+	ctx context.Context,
+	class models.Class,
+) (string, error) {
+	ref, _, err := r.client.Collection(config.ClassesCollection).Add(ctx, class)
+	if err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// GetClass fetches a class by its ID.
+// Returns the class or an error if it does not exist or the operation fails.
+func (r *FirestoreClassRepo) GetClass(
+	ctx context.Context,
+	id string,
+) (models.Class, error) {
+	doc, err := r.client.Collection(config.ClassesCollection).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return models.Class{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.Class{}, err
+	}
+
+	return classFromDoc(doc)
+}
+
+// GetClassByJoinCode fetches the class with a join code.
+// Returns the class or an error if no class has the code or the operation fails.
+func (r *FirestoreClassRepo) GetClassByJoinCode(
+	ctx context.Context,
+	code string,
+) (models.Class, error) {
+	doc, err := r.client.Collection(config.ClassesCollection).
+		Where("join_code", "==", code).
+		Limit(1).
+		Documents(ctx).
+		Next()
+	if err == iterator.Done {
+		return models.Class{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.Class{}, err
+	}
+
+	return classFromDoc(doc)
+}
+
+// GetTeacherClasses fetches every class taught by a user.
+// Returns the classes or an error if the operation fails.
+func (r *FirestoreClassRepo) GetTeacherClasses(
+	ctx context.Context,
+	teacherID string,
+) ([]models.Class, error) {
+	return r.queryClasses(ctx, r.client.Collection(config.ClassesCollection).
+		Where("teacher_id", "==", teacherID))
+}
+
+// GetStudentClasses fetches every class a user is enrolled in.
+// Returns the classes or an error if the operation fails.
+func (r *FirestoreClassRepo) GetStudentClasses(
+	ctx context.Context,
+	studentID string,
+) ([]models.Class, error) {
+	return r.queryClasses(ctx, r.client.Collection(config.ClassesCollection).
+		Where("student_ids", "array-contains", studentID))
+}
+
+// DeleteClass deletes a class and its assignments in a transaction.
+// Returns an error if the operation fails.
+func (r *FirestoreClassRepo) DeleteClass(ctx context.Context, id string) error {
+	classRef := r.client.Collection(config.ClassesCollection).Doc(id)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		assignments, err := tx.Documents(classRef.Collection(config.AssignmentsCollection)).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range assignments {
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(classRef)
+	})
+}
+
+// AddStudents enrolls users in a class in a transaction,
+// so students enrolled at the same time are all kept.
+// Returns the number of students added, or an error if the class does not exist
+// or the operation fails.
+func (r *FirestoreClassRepo) AddStudents(
+	ctx context.Context,
+	classID string,
+	students map[string]models.ClassStudent,
+) (int, error) {
+	classRef := r.client.Collection(config.ClassesCollection).Doc(classID)
+
+	added := 0
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		added = 0
+
+		doc, err := tx.Get(classRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		class, err := classFromDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		var ids, emails []any
+		var updates []firestore.Update
+		for id, student := range students {
+			if _, ok := class.Students[id]; ok {
+				continue
+			}
+			ids = append(ids, id)
+			emails = append(emails, student.Email)
+			updates = append(updates, firestore.Update{
+				FieldPath: firestore.FieldPath{"students", id},
+				Value:     student,
+			})
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		added = len(ids)
+
+		updates = append(updates,
+			firestore.Update{Path: "student_ids", Value: firestore.ArrayUnion(ids...)},
+			firestore.Update{Path: "invited_emails", Value: firestore.ArrayRemove(emails...)},
+		)
+		return tx.Update(classRef, updates)
+	})
+
+	return added, err
+}
+
+// InviteEmails invites emails to a class in a transaction, leaving out the emails
+// invited before and those of the students.
+// Returns the number of emails invited, or an error if the class does not exist
+// or the operation fails.
+func (r *FirestoreClassRepo) InviteEmails(
+	ctx context.Context,
+	classID string,
+	emails []string,
+) (int, error) {
+	classRef := r.client.Collection(config.ClassesCollection).Doc(classID)
+
+	invited := 0
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		invited = 0
+
+		doc, err := tx.Get(classRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		class, err := classFromDoc(doc)
+		if err != nil {
+			return err
+		}
+
+		skipped := make(map[string]bool, len(class.InvitedEmails)+len(class.Students))
+		for _, email := range class.InvitedEmails {
+			skipped[email] = true
+		}
+		for _, student := range class.Students {
+			skipped[student.Email] = true
+		}
+
+		var added []any
+		for _, email := range emails {
+			if skipped[email] {
+				continue
+			}
+			skipped[email] = true
+			added = append(added, email)
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		invited = len(added)
+
+		return tx.Update(classRef, []firestore.Update{
+			{Path: "invited_emails", Value: firestore.ArrayUnion(added...)},
+		})
+	})
+
+	return invited, err
+}
+
+// GetInvitedClasses fetches every class an email is invited to.
+// Returns the classes or an error if the operation fails.
+func (r *FirestoreClassRepo) GetInvitedClasses(
+	ctx context.Context,
+	email string,
+) ([]models.Class, error) {
+	return r.queryClasses(ctx, r.client.Collection(config.ClassesCollection).
+		Where("invited_emails", "array-contains", email))
+}
+
+// RemoveStudent removes a student from a class in a transaction.
+// Returns an error if the user is not a student or the operation fails.
+func (r *FirestoreClassRepo) RemoveStudent(
+	ctx context.Context,
+	classID, studentID string,
+) error {
+	classRef := r.client.Collection(config.ClassesCollection).Doc(classID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(classRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		class, err := classFromDoc(doc)
+		if err != nil {
+			return err
+		}
+		if _, ok := class.Students[studentID]; !ok {
+			return errors.ErrNotStudent
+		}
+
+		return tx.Update(classRef, []firestore.Update{
+			{FieldPath: firestore.FieldPath{"students", studentID}, Value: firestore.Delete},
+			{Path: "student_ids", Value: firestore.ArrayRemove(studentID)},
+		})
+	})
+}
+
+// AddAssignment adds an assignment to a class.
+// Returns the ID of the assignment or an error if the operation fails.
+func (r *FirestoreClassRepo) AddAssignment(
+	ctx context.Context,
+	classID string,
+	assignment models.Assignment,
+) (string, error) {
+	ref, _, err := r.client.Collection(config.ClassesCollection).Doc(classID).
+		Collection(config.AssignmentsCollection).
+		Add(ctx, assignment)
+	if err != nil {
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// GetAssignments fetches every assignment of a class, the earliest due first.
+// Returns the assignments or an error if the operation fails.
+func (r *FirestoreClassRepo) GetAssignments(
+	ctx context.Context,
+	classID string,
+) ([]models.Assignment, error) {
+	docs, err := r.client.Collection(config.ClassesCollection).Doc(classID).
+		Collection(config.AssignmentsCollection).
+		OrderBy("due_at", firestore.Asc).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make([]models.Assignment, 0, len(docs))
+	for _, doc := range docs {
+		var assignment models.Assignment
+		if err := doc.DataTo(&assignment); err != nil {
+			return nil, err
+		}
+		assignment.ID = doc.Ref.ID
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, nil
+}
+
+// DeleteAssignment deletes an assignment of a class.
+// Returns an error if the assignment does not exist or the operation fails.
+func (r *FirestoreClassRepo) DeleteAssignment(
+	ctx context.Context,
+	classID, id string,
+) error {
+	ref := r.client.Collection(config.ClassesCollection).Doc(classID).
+		Collection(config.AssignmentsCollection).Doc(id)
+
+	// Delete with an exists precondition, so unknown IDs are reported
+	_, err := ref.Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return errors.ErrNotFound
+	}
+	return err
+}
+
+// queryClasses reads the classes matching a query.
+func (r *FirestoreClassRepo) queryClasses(
+	ctx context.Context,
+	query firestore.Query,
+) ([]models.Class, error) {
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]models.Class, 0, len(docs))
+	for _, doc := range docs {
+		class, err := classFromDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, nil
+}
+
+// classFromDoc reads a class from its document.
+func classFromDoc(doc *firestore.DocumentSnapshot) (models.Class, error) {
+	var class models.Class
+	if err := doc.DataTo(&class); err != nil {
+		return models.Class{}, err
+	}
+	class.ID = doc.Ref.ID
+	return class, nil
+}
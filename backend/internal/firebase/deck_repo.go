@@ -6,9 +6,13 @@ import (
 	"memora/internal/errors"
 	"memora/internal/models"
 	"memora/internal/utils"
+	"slices"
+	"strings"
+	"time"
 
 	"cloud.google.com/go/firestore"
-	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // DeckRepository methods used for storing, updating and deleting data
@@ -21,21 +25,99 @@ type DeckRepository interface {
 	// Error on fail or if the ID is invalid, returns deck on success
 	GetOneDeck(ctx context.Context, id string, fields []string) (models.Deck, error)
 
+	// ExistingDecks checks which of the given decks exist, so decks in the trash
+	// or purged are left out.
+	// Error on failure, returns the existing deck IDs on success
+	ExistingDecks(ctx context.Context, ids []string) (map[string]bool, error)
+
 	// UpdateDeck updates everything except emails and cards in a given deck.
 	// Error on failure, or if ID is invalid, nil on success
 	UpdateDeck(ctx context.Context, firestoreUpdates []firestore.Update, id string) error
 
-	// RemoveEmailsFromShared removes given emails from the decks shared emails.
-	// Error on failure in transaction, nil on success
-	RemoveEmailsFromShared(ctx context.Context, deckID string, emails []string) error
+	// RemoveMembers removes given users from the members of a deck, with their shared emails.
+	// Error on failure in transaction, or if a user is not a member, nil on success
+	RemoveMembers(ctx context.Context, deckID string, userIDs []string) error
+
+	// AddEmailsToShared makes the users of given emails members of a deck with the given role,
+	// and invites the emails without a registered user.
+	// Error on failure in transaction, returns the invited emails on success
+	AddEmailsToShared(
+		ctx context.Context,
+		deckID string,
+		emails []string,
+		role, invitedBy string,
+	) ([]string, error)
+
+	// GrantAccess makes users members of a deck with the given memberships,
+	// leaving the owner and the members as they are.
+	// Error on failure, or if the deck does not exist, nil on success
+	GrantAccess(ctx context.Context, deckID string, members map[string]models.Membership) error
 
-	// AddEmailsToShared adds given email into the decks shared emails.
-	// Error on failure in transaction, nil on success
-	AddEmailsToShared(ctx context.Context, deckID string, emails []string) error
+	// SetMemberRole changes the role of a member of a deck.
+	// Error on failure, or if the user is not a member, nil on success
+	SetMemberRole(ctx context.Context, deckID, userID, role string) error
 
-	// DeleteDeck deletes a given deck from firestore.
+	// UpdateMemberEmail changes the email shown for a user on every deck the user is a member of.
+	// Error on failure, returns the IDs of the decks updated, also those updated before a failure
+	UpdateMemberEmail(ctx context.Context, userID, email string) ([]string, error)
+
+	// DeleteDeck moves a given deck to the trash of its owner.
 	// Error on failure, or if ID is invalid, nil on success
-	DeleteDeck(ctx context.Context, id string) error
+	DeleteDeck(ctx context.Context, id, deletedBy string, now time.Time) error
+
+	// GetDeletedDecks fetches the decks in the trash of an owner, the latest deleted first.
+	// Error on fail, returns the deleted decks on success
+	GetDeletedDecks(ctx context.Context, ownerID string) ([]models.DeletedDeck, error)
+
+	// RestoreDeck moves a deck out of the trash of its owner.
+	// Error on failure, or if the deck is not in the trash of the owner, returns the restored deck on success
+	RestoreDeck(ctx context.Context, id, ownerID string) (models.Deck, error)
+
+	// PurgeDeletedDecks permanently deletes every deck deleted before a time, with its cards, notes,
+	// audit log, invites and share links.
+	// Error on failure, returns the number of decks purged on success
+	PurgeDeletedDecks(ctx context.Context, before time.Time) (int, error)
+
+	// DiscardDeck permanently deletes a deck with everything stored under it, without the trash.
+	// Used to undo a deck whose creation failed halfway.
+	// Error on failure, nil on success
+	DiscardDeck(ctx context.Context, id string) error
+
+	// RequestTransfer asks a member of a deck to take it over, replacing any pending transfer.
+	// Error on failure, or if the user is not a member, nil on success
+	RequestTransfer(ctx context.Context, deckID, userID string, now time.Time) error
+
+	// AcceptTransfer makes the user a deck was offered to its owner,
+	// and the former owner a co-owner.
+	// Error on failure, or if no transfer to the user is pending, nil on success
+	AcceptTransfer(ctx context.Context, deckID, userID string) error
+
+	// GetTransferOffers fetches the decks a user was asked to take over.
+	// Error on fail, returns the offers on success
+	GetTransferOffers(ctx context.Context, userID string) ([]models.TransferOffer, error)
+
+	// PublishDeck makes a deck public with the given listing, keeping its learners
+	// and first publication date when published before.
+	// Error on failure, or if ID is invalid, nil on success
+	PublishDeck(ctx context.Context, deckID string, listing models.PublishDeck) error
+
+	// ListPublicDecks fetches a page of the public decks matching the query.
+	// cursor is the ID of the last deck of the previous page (empty string for first page)
+	// Error on fail, returns the decks and the cursor of the next page on success,
+	// empty when there are no more decks
+	ListPublicDecks(
+		ctx context.Context,
+		query models.LibraryQuery,
+		limit int,
+	) ([]models.PublicDeck, string, error)
+
+	// AddToStudyList adds a deck to the study list of a user, counting the user as a learner.
+	// Error on fail, returns false if the deck was in the list already
+	AddToStudyList(ctx context.Context, deckID, userID string) (bool, error)
+
+	// RemoveFromStudyList removes a deck from the study list of a user.
+	// Error on fail, returns false if the deck was not in the list
+	RemoveFromStudyList(ctx context.Context, deckID, userID string) (bool, error)
 }
 
 // FirestoreDeckRepo holds the database connection needed for fetching
@@ -49,6 +131,7 @@ func NewFirestoreDeckRepo(client *firestore.Client) *FirestoreDeckRepo {
 }
 
 // AddDeck checks if the owned ID is valid, and then adds the decks data into firestore.
+// The users of the shared emails become editors of the deck.
 // Error on failure, or if parameters SharedEmails and OwnerID is invalid.
 // Returns decks ID on success
 func (r *FirestoreDeckRepo) AddDeck(
@@ -61,16 +144,25 @@ func (r *FirestoreDeckRepo) AddDeck(
 		return "", err
 	}
 
-	// Loop over email and check if they exist.
+	// Loop over email and find their users.
 	// If it fails on one email it returns error
-	for _, email := range deck.SharedEmails {
-		exists, err := utils.UserExistsByEmail(r.client, ctx, email)
+	deck.MemberIDs = []string{}
+	deck.Memberships = make(map[string]models.Membership, len(deck.SharedEmails))
+	for i, email := range deck.SharedEmails {
+		email = utils.NormalizeEmail(email)
+		deck.SharedEmails[i] = email
+
+		userID, err := utils.UserIDByEmail(r.client, ctx, email)
 		if err != nil {
 			return "", err
 		}
-		if !exists {
+		if userID == "" {
 			return "", errors.ErrInvalidEmailNotPresent
 		}
+		if _, ok := deck.Memberships[userID]; !ok {
+			deck.MemberIDs = append(deck.MemberIDs, userID)
+		}
+		deck.Memberships[userID] = models.Membership{Email: email, Role: utils.ROLE_EDITOR}
 	}
 
 	// Safely add the deck to firestore
@@ -88,80 +180,306 @@ func (r *FirestoreDeckRepo) GetOneDeck(
 	return utils.FetchByID[models.Deck](r.client, ctx, config.DecksCollection, id, fields)
 }
 
-// AddEmailsToShared adds emails to a deck to gain permissions on the deck.
-// Error on failure, or if email does not exist.
-// Returns nil on success
+// ExistingDecks checks which of the given decks exist in firestore.
+// Returns the existing deck IDs, or an error if the operation fails.
+func (r *FirestoreDeckRepo) ExistingDecks(
+	ctx context.Context,
+	ids []string,
+) (map[string]bool, error) {
+	return existingDecks(ctx, r.client, ids)
+}
+
+// existingDecks checks which of the given decks exist, in a single read.
+// Returns the existing deck IDs, or an error if the operation fails.
+func existingDecks(
+	ctx context.Context,
+	client *firestore.Client,
+	ids []string,
+) (map[string]bool, error) {
+	existing := make(map[string]bool, len(ids))
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, client.Collection(config.DecksCollection).Doc(id))
+	}
+	docs, err := client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if doc.Exists() {
+			existing[doc.Ref.ID] = true
+		}
+	}
+	return existing, nil
+}
+
+// AddEmailsToShared makes the users of emails members of a deck with a role.
+// Members added before are given the new role. Emails without a registered user
+// are invited instead, in the same transaction.
+// Error on failure, or if the deck does not exist.
+// Returns the invited emails on success
 func (r *FirestoreDeckRepo) AddEmailsToShared(
 	ctx context.Context,
 	deckID string,
 	emails []string,
-) error {
-	// Check if the deck exists
+	role, invitedBy string,
+) ([]string, error) {
 	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
 
+	var invited []string
 	// Run update transactions
-	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		invited = nil
+
+		// Check if the deck exists, its title is shown with the invites
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		members := make(map[string]models.Membership)
 		for _, email := range emails {
-			exists, err := utils.UserExistsByEmail(r.client, ctx, email)
+			email = utils.NormalizeEmail(email)
+			userID, err := utils.UserIDByEmail(r.client, ctx, email)
 			// Unforseen error
 			if err != nil {
 				return errors.ErrFailedUpdatingEmail
 			}
-			// Does not exist
-			if !exists {
-				return errors.ErrInvalidEmailNotPresent
+			if userID == "" {
+				invited = append(invited, email)
+				continue
 			}
+			members[userID] = models.Membership{Email: email, Role: role}
 		}
 
-		emailsIface := make([]any, len(emails))
-		for i, v := range emails {
-			emailsIface[i] = v
+		now := time.Now().UTC()
+		for _, email := range invited {
+			err := tx.Set(inviteRef(r.client, deckID, email), models.Invite{
+				DeckID:    deckID,
+				DeckTitle: deck.Title,
+				Email:     email,
+				Role:      role,
+				InvitedBy: invitedBy,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return err
+			}
 		}
 
-		// Update the shared emails in firestore
-		return tx.Update(deckRef, []firestore.Update{
-			{Path: "shared_emails", Value: firestore.ArrayUnion(emailsIface...)},
-		})
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.Update(deckRef, membershipUpdates(members))
 	})
+
+	return invited, err
 }
 
-// RemoveEmailsFromShared removes emails from a decks shared emails.
-// Error on failure, or if email does not exist.
+// GrantAccess makes users members of a deck in a transaction, so the role of
+// the owner or of a member is never lowered by it.
+// Error on failure, or if the deck does not exist.
 // Returns nil on success
-func (r *FirestoreDeckRepo) RemoveEmailsFromShared(
+func (r *FirestoreDeckRepo) GrantAccess(
 	ctx context.Context,
 	deckID string,
-	emails []string,
+	members map[string]models.Membership,
 ) error {
-	// Check if the deck exists
 	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
 
-	// Run update transaction
 	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		for _, email := range emails {
-			exists, err := utils.UserExistsByEmail(r.client, ctx, email)
-			// Unforseen error
-			if err != nil {
-				return errors.ErrFailedUpdatingEmail
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		added := make(map[string]models.Membership, len(members))
+		for userID, membership := range members {
+			if _, ok := deck.Memberships[userID]; ok || userID == deck.OwnerID {
+				continue
 			}
-			// Does not exist
-			if !exists {
-				return errors.ErrInvalidEmailNotPresent
+			added[userID] = membership
+		}
+		if len(added) == 0 {
+			return nil
+		}
+
+		return tx.Update(deckRef, membershipUpdates(added))
+	})
+}
+
+// RemoveMembers removes users from the members of a deck, along with their shared emails.
+// Error on failure, or if a user is not a member.
+// Returns nil on success
+func (r *FirestoreDeckRepo) RemoveMembers(
+	ctx context.Context,
+	deckID string,
+	userIDs []string,
+) error {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	// Run update transaction
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		ids := make([]any, len(userIDs))
+		emails := make([]any, len(userIDs))
+		updates := make([]firestore.Update, 0, len(userIDs)+2)
+		for i, userID := range userIDs {
+			membership, ok := deck.Memberships[userID]
+			if !ok {
+				return errors.ErrNotMember
 			}
+			ids[i], emails[i] = userID, membership.Email
+			updates = append(updates, firestore.Update{
+				FieldPath: firestore.FieldPath{"memberships", userID},
+				Value:     firestore.Delete,
+			})
+		}
+
+		// Update the members and their shared emails in firestore
+		updates = append(updates,
+			firestore.Update{Path: "member_ids", Value: firestore.ArrayRemove(ids...)},
+			firestore.Update{Path: "shared_emails", Value: firestore.ArrayRemove(emails...)},
+		)
+		return tx.Update(deckRef, updates)
+	})
+}
+
+// SetMemberRole changes the role of a member in a transaction,
+// so a member removed at the same time is not given a role again.
+// Error on failure, or if the user is not a member.
+// Returns nil on success
+func (r *FirestoreDeckRepo) SetMemberRole(
+	ctx context.Context,
+	deckID, userID, role string,
+) error {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
 		}
 
-		emailsIface := make([]any, len(emails))
-		for i, v := range emails {
-			emailsIface[i] = v
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+		if _, ok := deck.Memberships[userID]; !ok {
+			return errors.ErrNotMember
 		}
 
-		// Update the shared emails in firestore
 		return tx.Update(deckRef, []firestore.Update{
-			{Path: "shared_emails", Value: firestore.ArrayRemove(emailsIface...)},
+			{FieldPath: firestore.FieldPath{"memberships", userID, "role"}, Value: role},
 		})
 	})
 }
 
+// UpdateMemberEmail replaces the email of a user in the memberships and shared emails
+// of every deck the user is a member of, each deck in its own transaction,
+// so users of many decks do not hit the write limit of a transaction.
+// Error on failure.
+// Returns the IDs of the decks updated, also those updated before a failure
+func (r *FirestoreDeckRepo) UpdateMemberEmail(
+	ctx context.Context,
+	userID, email string,
+) ([]string, error) {
+	email = utils.NormalizeEmail(email)
+	docs, err := r.client.Collection(config.DecksCollection).
+		Where("member_ids", "array-contains", userID).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+	for _, doc := range docs {
+		changed := false
+		err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			changed = false
+
+			snap, err := tx.Get(doc.Ref)
+			if err != nil {
+				return err
+			}
+			var deck models.Deck
+			if err := snap.DataTo(&deck); err != nil {
+				return err
+			}
+
+			membership, ok := deck.Memberships[userID]
+			if !ok || membership.Email == email {
+				return nil
+			}
+			changed = true
+
+			// Removing and adding to the same array is not allowed in one write
+			old := membership.Email
+			shared := slices.DeleteFunc(deck.SharedEmails, func(e string) bool { return e == old })
+			if !slices.Contains(shared, email) {
+				shared = append(shared, email)
+			}
+
+			return tx.Update(doc.Ref, []firestore.Update{
+				{FieldPath: firestore.FieldPath{"memberships", userID, "email"}, Value: email},
+				{Path: "shared_emails", Value: shared},
+			})
+		})
+		if err != nil {
+			return updated, err
+		}
+		if changed {
+			updated = append(updated, doc.Ref.ID)
+		}
+	}
+
+	return updated, nil
+}
+
+// membershipUpdates returns the updates making users members of a deck,
+// keeping the member IDs and shared emails in step with the memberships.
+func membershipUpdates(members map[string]models.Membership) []firestore.Update {
+	ids := make([]any, 0, len(members))
+	emails := make([]any, 0, len(members))
+	updates := make([]firestore.Update, 0, len(members)+2)
+	for userID, membership := range members {
+		ids = append(ids, userID)
+		emails = append(emails, membership.Email)
+		// Set as a whole, so the email of a member added again is updated too
+		updates = append(updates, firestore.Update{
+			FieldPath: firestore.FieldPath{"memberships", userID},
+			Value:     membership,
+		})
+	}
+
+	return append(updates,
+		firestore.Update{Path: "member_ids", Value: firestore.ArrayUnion(ids...)},
+		firestore.Update{Path: "shared_emails", Value: firestore.ArrayUnion(emails...)},
+	)
+}
+
 // DeleteDeck deletes a deck from firestore based on its ID.
 // Error on failure, or if ID is invalid.
 // Returns nil on success
@@ -179,33 +497,596 @@ func (r *FirestoreDeckRepo) UpdateDeck(
 	)
 }
 
-// DeleteDeck deletes a deck from firestore based on its ID.
+// DeleteDeck moves a deck to the trash of its owner in a transaction,
+// so it is no longer found nor listed. Its cards and notes stay under the deck ID
+// and are found again when it is restored.
 // Error on failure, or if ID is invalid.
 // Returns nil on success
 func (r *FirestoreDeckRepo) DeleteDeck(
 	ctx context.Context,
-	id string,
+	id, deletedBy string,
+	now time.Time,
 ) error {
-	docRef := r.client.Collection(config.DecksCollection).Doc(id)
-	cardRef := docRef.Collection(config.CardsCollection)
+	deckRef := r.client.Collection(config.DecksCollection).Doc(id)
+	trashRef := r.client.Collection(config.DeletedDecksCollection).Doc(id)
 
 	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		// Delete all documents in the subcollection
-		iter := cardRef.Documents(ctx)
-		for {
-			doc, err := iter.Next()
-			if err == iterator.Done {
-				break
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+
+		// The deck is kept as it was, with when and by whom it was deleted
+		data := doc.Data()
+		data["deleted_by"] = deletedBy
+		data["deleted_at"] = now
+		if err := tx.Set(trashRef, data); err != nil {
+			return err
+		}
+
+		return tx.Delete(deckRef)
+	})
+}
+
+// GetDeletedDecks fetches the decks in the trash of an owner, the latest deleted first.
+// Returns the deleted decks or an error if the operation fails.
+func (r *FirestoreDeckRepo) GetDeletedDecks(
+	ctx context.Context,
+	ownerID string,
+) ([]models.DeletedDeck, error) {
+	docs, err := r.client.Collection(config.DeletedDecksCollection).
+		Where("owner_id", "==", ownerID).
+		Select("title", "owner_id", "deleted_by", "deleted_at").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	decks := make([]models.DeletedDeck, 0, len(docs))
+	for _, doc := range docs {
+		var deck models.DeletedDeck
+		if err := doc.DataTo(&deck); err != nil {
+			return nil, err
+		}
+		deck.ID = doc.Ref.ID
+		decks = append(decks, deck)
+	}
+
+	// Sorted here, as ordering the query would need a composite index
+	slices.SortFunc(decks, func(a, b models.DeletedDeck) int {
+		return b.DeletedAt.Compare(a.DeletedAt)
+	})
+
+	return decks, nil
+}
+
+// RestoreDeck moves a deck out of the trash of its owner in a transaction,
+// back under the same ID so its cards, notes and progress are kept.
+// Decks in the trash of other users are not revealed.
+// Returns the restored deck, or an error if it is not in the trash or the operation fails.
+func (r *FirestoreDeckRepo) RestoreDeck(
+	ctx context.Context,
+	id, ownerID string,
+) (models.Deck, error) {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(id)
+	trashRef := r.client.Collection(config.DeletedDecksCollection).Doc(id)
+
+	var deck models.Deck
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(trashRef)
+		if status.Code(err) == codes.NotFound {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		data := doc.Data()
+		if data["owner_id"] != ownerID {
+			return errors.ErrNotFound
+		}
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		delete(data, "deleted_by")
+		delete(data, "deleted_at")
+		if err := tx.Create(deckRef, data); err != nil {
+			return err
+		}
+		return tx.Delete(trashRef)
+	})
+	if err != nil {
+		return models.Deck{}, err
+	}
+
+	return deck, nil
+}
+
+// PurgeDeletedDecks permanently deletes every deck deleted before a time,
+// along with its cards, the cards in its trash, their revisions, its notes,
+// the progress of its learners and its audit log, and the invites and share links to it.
+// Returns the number of decks purged, or an error if the operation fails.
+func (r *FirestoreDeckRepo) PurgeDeletedDecks(
+	ctx context.Context,
+	before time.Time,
+) (int, error) {
+	docs, err := r.client.Collection(config.DeletedDecksCollection).
+		Where("deleted_at", "<", before).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, doc := range docs {
+		if err := purgeDeckContents(ctx, r.client, doc.Ref.ID); err != nil {
+			return 0, err
+		}
+
+		// The trashed deck goes last, so a failed purge is retried on the next run
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(docs), nil
+}
+
+// purgeDeckContents permanently deletes the cards of a deck, the cards in its trash,
+// their revisions, its notes, the progress of its learners and its audit log,
+// and the invites and share links to it.
+// Returns an error if the operation fails.
+func purgeDeckContents(ctx context.Context, client *firestore.Client, deckID string) error {
+	deckRef := client.Collection(config.DecksCollection).Doc(deckID)
+
+	var refs, cardRefs []*firestore.DocumentRef
+	for _, collection := range []string{
+		config.CardsCollection,
+		config.TrashCollection,
+		config.NotesCollection,
+	} {
+		subDocs, err := deckRef.Collection(collection).Select().Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, subDoc := range subDocs {
+			refs = append(refs, subDoc.Ref)
+			// Revisions are kept under the card ID, also for the cards in the trash
+			if collection != config.NotesCollection {
+				cardRefs = append(cardRefs, deckRef.Collection(config.CardsCollection).Doc(subDoc.Ref.ID))
 			}
-			if err != nil {
-				return err
+		}
+	}
+
+	revisions, err := revisionRefs(ctx, cardRefs...)
+	if err != nil {
+		return err
+	}
+	refs = append(refs, revisions...)
+
+	// Progress is kept under a document per learner, which may not exist itself
+	learners, err := deckRef.Collection(config.UsersCollection).DocumentRefs(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	for _, learner := range learners {
+		progress, err := learner.Collection(config.ProgressCollection).Select().Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range progress {
+			refs = append(refs, doc.Ref)
+		}
+		refs = append(refs, learner)
+	}
+
+	// The audit log, invites and share links have no use without the deck
+	for _, query := range []firestore.Query{
+		deckRef.Collection(config.AuditCollection).Query,
+		client.Collection(config.InvitesCollection).Where("deck_id", "==", deckID),
+		client.Collection(config.ShareLinksCollection).Where("deck_id", "==", deckID),
+	} {
+		linked, err := query.Select().Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, linkedDoc := range linked {
+			refs = append(refs, linkedDoc.Ref)
+		}
+	}
+
+	return deleteDocs(ctx, client, refs)
+}
+
+// DiscardDeck permanently deletes a deck along with everything stored under it,
+// without going through the trash.
+// Returns an error if the operation fails.
+func (r *FirestoreDeckRepo) DiscardDeck(ctx context.Context, id string) error {
+	return discardDeck(ctx, r.client, id)
+}
+
+// discardDeck permanently deletes a deck after everything stored under it,
+// so a failed deletion can be retried.
+// Returns an error if the operation fails.
+func discardDeck(ctx context.Context, client *firestore.Client, id string) error {
+	if err := purgeDeckContents(ctx, client, id); err != nil {
+		return err
+	}
+
+	_, err := client.Collection(config.DecksCollection).Doc(id).Delete(ctx)
+	return err
+}
+
+// RequestTransfer asks a member to take a deck over in a transaction,
+// so a member removed at the same time is not offered the deck.
+// Error on failure, or if the user is not a member.
+// Returns nil on success
+func (r *FirestoreDeckRepo) RequestTransfer(
+	ctx context.Context,
+	deckID, userID string,
+	now time.Time,
+) error {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+		membership, ok := deck.Memberships[userID]
+		if !ok {
+			return errors.ErrNotMember
+		}
+
+		return tx.Update(deckRef, []firestore.Update{
+			{Path: "transfer", Value: models.OwnershipTransfer{
+				ToID:        userID,
+				ToEmail:     membership.Email,
+				RequestedAt: now,
+			}},
+		})
+	})
+}
+
+// AcceptTransfer hands a deck over to the user it was offered to in a transaction.
+// The former owner stays on the deck as a co-owner.
+// Error on failure, or if no transfer to the user is pending.
+// Returns nil on success
+func (r *FirestoreDeckRepo) AcceptTransfer(
+	ctx context.Context,
+	deckID, userID string,
+) error {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(deckRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+		if deck.Transfer == nil || deck.Transfer.ToID != userID {
+			return errors.ErrNoTransfer
+		}
+		// Members removed since the transfer was requested can no longer accept it
+		if _, ok := deck.Memberships[userID]; !ok {
+			return errors.ErrNoTransfer
+		}
+
+		ownerDoc, err := tx.Get(r.client.Collection(config.UsersCollection).Doc(deck.OwnerID))
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		formerOwner := models.Membership{Role: utils.ROLE_CO_OWNER}
+		if ownerDoc != nil && ownerDoc.Exists() {
+			formerOwner.Email, _ = ownerDoc.Data()["email"].(string)
+		}
+
+		return tx.Update(deckRef, ownershipUpdates(deck, userID, &formerOwner))
+	})
+}
+
+// GetTransferOffers fetches the decks with a pending transfer to a user.
+// Returns the offers or an error if the operation fails.
+func (r *FirestoreDeckRepo) GetTransferOffers(
+	ctx context.Context,
+	userID string,
+) ([]models.TransferOffer, error) {
+	docs, err := r.client.Collection(config.DecksCollection).
+		Where("transfer.to_id", "==", userID).
+		Select("title", "owner_id", "transfer").
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	offers := make([]models.TransferOffer, 0, len(docs))
+	for _, doc := range docs {
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return nil, err
+		}
+		offers = append(offers, models.TransferOffer{
+			DeckID:      doc.Ref.ID,
+			DeckTitle:   deck.Title,
+			OwnerID:     deck.OwnerID,
+			RequestedAt: deck.Transfer.RequestedAt,
+		})
+	}
+
+	return offers, nil
+}
+
+// ownershipUpdates returns the updates making a member the owner of a deck,
+// and clearing any pending transfer. The former owner becomes a member
+// with the given membership, or leaves the deck when it is nil.
+func ownershipUpdates(
+	deck models.Deck,
+	toID string,
+	formerOwner *models.Membership,
+) []firestore.Update {
+	newOwner := deck.Memberships[toID]
+
+	// The member lists are set as a whole, as one write can not both add to and remove from them
+	memberIDs := slices.DeleteFunc(append([]string{}, deck.MemberIDs...), func(id string) bool {
+		return id == toID
+	})
+	shared := slices.DeleteFunc(append([]string{}, deck.SharedEmails...), func(e string) bool {
+		return e == newOwner.Email
+	})
+
+	updates := []firestore.Update{
+		{Path: "owner_id", Value: toID},
+		{FieldPath: firestore.FieldPath{"memberships", toID}, Value: firestore.Delete},
+		{Path: "transfer", Value: firestore.Delete},
+	}
+	if formerOwner != nil {
+		memberIDs = append(memberIDs, deck.OwnerID)
+		if formerOwner.Email != "" {
+			shared = append(shared, formerOwner.Email)
+		}
+		updates = append(updates, firestore.Update{
+			FieldPath: firestore.FieldPath{"memberships", deck.OwnerID},
+			Value:     *formerOwner,
+		})
+	}
+
+	return append(updates,
+		firestore.Update{Path: "member_ids", Value: memberIDs},
+		firestore.Update{Path: "shared_emails", Value: shared},
+	)
+}
+
+// PublishDeck makes a deck public with the given listing in a transaction.
+// The learners and first publication date are kept when the deck was published before.
+// Error on failure, or if ID is invalid.
+// Returns nil on success
+func (r *FirestoreDeckRepo) PublishDeck(
+	ctx context.Context,
+	deckID string,
+	listing models.PublishDeck,
+) error {
+	docRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	return r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(docRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+
+		var deck models.Deck
+		if err := doc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		publication := models.Publication{
+			Description: listing.Description,
+			Language:    listing.Language,
+			Subjects:    listing.Subjects,
+			PublishedAt: now,
+			UpdatedAt:   now,
+		}
+		if deck.Publication != nil {
+			publication.Learners = deck.Publication.Learners
+			publication.PublishedAt = deck.Publication.PublishedAt
+		}
+
+		return tx.Update(docRef, []firestore.Update{
+			{Path: "public", Value: true},
+			{Path: "publication", Value: publication},
+		})
+	})
+}
+
+// ListPublicDecks fetches a page of public decks, the most studied or the last published first.
+// cursor is the ID of the last deck of the previous page (empty for first page)
+// Decks not matching the search are skipped, and more decks are fetched to fill the page,
+// reading at most MAX_LIBRARY_SCAN decks, so a page may be short while there are more.
+// Returns the decks and the cursor of the next page, empty when there are no more,
+// or an error if the operation fails.
+func (r *FirestoreDeckRepo) ListPublicDecks(
+	ctx context.Context,
+	libraryQuery models.LibraryQuery,
+	limit int,
+) ([]models.PublicDeck, string, error) {
+	decksRef := r.client.Collection(config.DecksCollection)
+	query := decksRef.Where("public", "==", true)
+
+	if libraryQuery.Language != "" {
+		query = query.Where("publication.language", "==", libraryQuery.Language)
+	}
+	if libraryQuery.Subject != "" {
+		query = query.Where("publication.subjects", "array-contains", libraryQuery.Subject)
+	}
+
+	if libraryQuery.Sort == utils.LIBRARY_SORT_RECENT {
+		query = query.OrderBy("publication.published_at", firestore.Desc)
+	} else {
+		query = query.OrderBy("publication.learners", firestore.Desc).
+			OrderBy("publication.published_at", firestore.Desc)
+	}
+	query = query.Select("title", "owner_id", "publication")
+
+	// Ordering by publication fields needs the document to start after
+	var cursor *firestore.DocumentSnapshot
+	if libraryQuery.Cursor != "" {
+		snap, err := decksRef.Doc(libraryQuery.Cursor).Get(ctx)
+		if err != nil {
+			return nil, "", errors.ErrInvalidId
+		}
+		cursor = snap
+	}
+
+	words := strings.Fields(strings.ToLower(libraryQuery.Search))
+
+	var result []models.PublicDeck
+	scanned := 0
+	for {
+		// Fetch one extra to check for more pages
+		pageQuery := query.Limit(limit + 1)
+		if cursor != nil {
+			pageQuery = pageQuery.StartAfter(cursor)
+		}
+
+		docs, err := pageQuery.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i, doc := range docs {
+			cursor = doc
+			scanned++
+
+			var deck models.PublicDeck
+			if err := doc.DataTo(&deck); err != nil {
+				return nil, "", err
 			}
-			if err := tx.Delete(doc.Ref); err != nil {
-				return err
+			if matchesSearch(deck, words) {
+				deck.ID = doc.Ref.ID
+				result = append(result, deck)
+			}
+
+			// The extra deck shows there is a next page, starting after the last one kept
+			if len(result) > limit {
+				return result[:limit], result[limit-1].ID, nil
+			}
+
+			// A search matching few decks stops early, the next page starting after
+			// the last deck read
+			if scanned >= utils.MAX_LIBRARY_SCAN {
+				if i < len(docs)-1 || len(docs) > limit {
+					return result, doc.Ref.ID, nil
+				}
+				return result, "", nil
 			}
 		}
 
-		// Delete the deck document itself
-		return tx.Delete(docRef)
+		if len(docs) <= limit {
+			return result, "", nil
+		}
+	}
+}
+
+// AddToStudyList adds a deck to the study list of a user in a transaction,
+// and counts the user as a learner of the deck.
+// Returns false if the deck was in the list already, or an error if the operation fails.
+func (r *FirestoreDeckRepo) AddToStudyList(
+	ctx context.Context,
+	deckID, userID string,
+) (bool, error) {
+	return r.updateStudyList(ctx, deckID, userID, true)
+}
+
+// RemoveFromStudyList removes a deck from the study list of a user in a transaction,
+// and no longer counts the user as a learner of the deck.
+// Returns false if the deck was not in the list, or an error if the operation fails.
+func (r *FirestoreDeckRepo) RemoveFromStudyList(
+	ctx context.Context,
+	deckID, userID string,
+) (bool, error) {
+	return r.updateStudyList(ctx, deckID, userID, false)
+}
+
+// updateStudyList adds or removes a deck from the study list of a user,
+// keeping the learners of the deck in step.
+// Returns false if nothing changed, or an error if the operation fails.
+func (r *FirestoreDeckRepo) updateStudyList(
+	ctx context.Context,
+	deckID, userID string,
+	add bool,
+) (bool, error) {
+	userRef := r.client.Collection(config.UsersCollection).Doc(userID)
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+
+	changed := false
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		changed = false
+
+		userDoc, err := tx.Get(userRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		var user struct {
+			StudyDecks []string `firestore:"study_decks"`
+		}
+		if err := userDoc.DataTo(&user); err != nil {
+			return err
+		}
+
+		if slices.Contains(user.StudyDecks, deckID) == add {
+			return nil
+		}
+
+		// A deck deleted since it was added only has to leave the list
+		deckDoc, err := tx.Get(deckRef)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+
+		var studyDecks any = firestore.ArrayUnion(deckID)
+		learners := 1
+		if !add {
+			studyDecks, learners = firestore.ArrayRemove(deckID), -1
+		}
+
+		if err := tx.Update(userRef, []firestore.Update{
+			{Path: "study_decks", Value: studyDecks},
+		}); err != nil {
+			return err
+		}
+		changed = true
+
+		if deckDoc == nil || !deckDoc.Exists() {
+			return nil
+		}
+		return tx.Update(deckRef, []firestore.Update{
+			{Path: "publication.learners", Value: firestore.Increment(learners)},
+		})
 	})
+
+	return changed, err
+}
+
+// matchesSearch checks if every word of a search is in the title or description of a deck.
+func matchesSearch(deck models.PublicDeck, words []string) bool {
+	text := strings.ToLower(deck.Title + " " + deck.Publication.Description)
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
 }
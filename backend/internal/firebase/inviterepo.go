@@ -0,0 +1,172 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InviteRepository defines methods for the pending invites to decks.
+// Invites are created along with the memberships in DeckRepository.AddEmailsToShared.
+type InviteRepository interface {
+	// GetDeckInvites fetches the pending invites to a deck.
+	// Error on fail, returns the invites on success
+	GetDeckInvites(ctx context.Context, deckID string) ([]models.Invite, error)
+
+	// GetEmailInvites fetches the pending invites of an email.
+	// Error on fail, returns the invites on success
+	GetEmailInvites(ctx context.Context, email string) ([]models.Invite, error)
+
+	// GetInvite fetches an invite by its ID.
+	// Error on fail or if the ID is not valid
+	GetInvite(ctx context.Context, id string) (models.Invite, error)
+
+	// DeleteInvite deletes an invite by its ID.
+	// Error on fail, nil on success
+	DeleteInvite(ctx context.Context, id string) error
+
+	// AcceptInvites turns invites into memberships of a user on their decks, and deletes them.
+	// Invites to decks deleted since are only deleted.
+	// Error on fail, returns the IDs of the decks joined on success
+	AcceptInvites(ctx context.Context, userID string, invites []models.Invite) ([]string, error)
+}
+
+// FirestoreInviteRepo implements the InviteRepository interface using Firestore.
+type FirestoreInviteRepo struct {
+	client *firestore.Client
+}
+
+// NewFirestoreInviteRepo creates and returns a pointer to the FirestoreInviteRepo.
+func NewFirestoreInviteRepo(client *firestore.Client) *FirestoreInviteRepo {
+	return &FirestoreInviteRepo{client: client}
+}
+
+// inviteRef returns the reference of the invite of an email to a deck,
+// so inviting an email again replaces its invite.
+func inviteRef(client *firestore.Client, deckID, email string) *firestore.DocumentRef {
+	return client.Collection(config.InvitesCollection).Doc(deckID + "_" + email)
+}
+
+// GetDeckInvites fetches every pending invite to a deck.
+// Returns the invites or an error if the operation fails.
+func (r *FirestoreInviteRepo) GetDeckInvites(
+	ctx context.Context,
+	deckID string,
+) ([]models.Invite, error) {
+	return r.queryInvites(ctx, r.client.Collection(config.InvitesCollection).Where("deck_id", "==", deckID))
+}
+
+// GetEmailInvites fetches every pending invite of an email.
+// Returns the invites or an error if the operation fails.
+func (r *FirestoreInviteRepo) GetEmailInvites(
+	ctx context.Context,
+	email string,
+) ([]models.Invite, error) {
+	return r.queryInvites(ctx, r.client.Collection(config.InvitesCollection).
+		Where("email", "==", utils.NormalizeEmail(email)))
+}
+
+// GetInvite fetches an invite by its ID.
+// Returns the invite or an error if it does not exist or the operation fails.
+func (r *FirestoreInviteRepo) GetInvite(
+	ctx context.Context,
+	id string,
+) (models.Invite, error) {
+	doc, err := r.client.Collection(config.InvitesCollection).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return models.Invite{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.Invite{}, err
+	}
+
+	var invite models.Invite
+	if err := doc.DataTo(&invite); err != nil {
+		return models.Invite{}, err
+	}
+	invite.ID = doc.Ref.ID
+
+	return invite, nil
+}
+
+// DeleteInvite deletes an invite by its ID.
+// Returns an error if the operation fails.
+func (r *FirestoreInviteRepo) DeleteInvite(ctx context.Context, id string) error {
+	_, err := r.client.Collection(config.InvitesCollection).Doc(id).Delete(ctx)
+	return err
+}
+
+// AcceptInvites makes a user a member of the decks of the invites with the invited role,
+// and deletes the invites in the same transaction.
+// Returns the IDs of the decks joined, or an error if the operation fails.
+func (r *FirestoreInviteRepo) AcceptInvites(
+	ctx context.Context,
+	userID string,
+	invites []models.Invite,
+) ([]string, error) {
+	var joined []string
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		joined = nil
+
+		// Every read of a transaction must happen before its writes
+		exists := make([]bool, len(invites))
+		for i, invite := range invites {
+			deckRef := r.client.Collection(config.DecksCollection).Doc(invite.DeckID)
+			_, err := tx.Get(deckRef)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			exists[i] = err == nil
+		}
+
+		for i, invite := range invites {
+			if exists[i] {
+				deckRef := r.client.Collection(config.DecksCollection).Doc(invite.DeckID)
+				err := tx.Update(deckRef, membershipUpdates(map[string]models.Membership{
+					userID: {Email: invite.Email, Role: invite.Role},
+				}))
+				if err != nil {
+					return err
+				}
+				joined = append(joined, invite.DeckID)
+			}
+
+			if err := tx.Delete(r.client.Collection(config.InvitesCollection).Doc(invite.ID)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return joined, err
+}
+
+// queryInvites reads the invites matching a query.
+func (r *FirestoreInviteRepo) queryInvites(
+	ctx context.Context,
+	query firestore.Query,
+) ([]models.Invite, error) {
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]models.Invite, 0, len(docs))
+	for _, doc := range docs {
+		var invite models.Invite
+		if err := doc.DataTo(&invite); err != nil {
+			return nil, err
+		}
+		invite.ID = doc.Ref.ID
+		invites = append(invites, invite)
+	}
+
+	return invites, nil
+}
@@ -0,0 +1,159 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// MembersMigration counts what MigrateMembers changed.
+type MembersMigration struct {
+	Users   int
+	Decks   int
+	Invites int
+}
+
+// MigrateMembers moves decks shared by email to memberships keyed on user IDs.
+// The emails of users are lowercased first, so decks shared with another case find them.
+// Shared emails with a registered user become members with their role, editor when they
+// had none, and the others are invited with it. Decks migrated before are skipped,
+// so the migration can be run again.
+// Returns what was migrated, or an error if the operation fails.
+func MigrateMembers(ctx context.Context, client *firestore.Client) (MembersMigration, error) {
+	var result MembersMigration
+
+	bulkWriter := client.BulkWriter(ctx)
+	defer bulkWriter.End()
+
+	users := client.Collection(config.UsersCollection).Select("email").Documents(ctx)
+	defer users.Stop()
+	for {
+		doc, err := users.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		email, _ := doc.Data()["email"].(string)
+		if normalized := utils.NormalizeEmail(email); normalized != email {
+			_, err := bulkWriter.Update(doc.Ref, []firestore.Update{
+				{Path: "email", Value: normalized},
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Users++
+		}
+	}
+
+	// The emails are looked up by the decks below
+	bulkWriter.Flush()
+
+	decks := client.Collection(config.DecksCollection).Documents(ctx)
+	defer decks.Stop()
+	for {
+		doc, err := decks.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		if _, ok := doc.Data()["member_ids"]; ok {
+			continue
+		}
+
+		invites, err := migrateDeckMembers(ctx, client, bulkWriter, doc)
+		if err != nil {
+			return result, err
+		}
+		result.Decks++
+		result.Invites += invites
+	}
+
+	bulkWriter.Flush()
+
+	return result, nil
+}
+
+// migrateDeckMembers schedules the writes moving the shared emails of a deck to memberships,
+// and removes the roles it kept by email.
+// Returns the number of emails invited, or an error if the operation fails.
+func migrateDeckMembers(
+	ctx context.Context,
+	client *firestore.Client,
+	bulkWriter *firestore.BulkWriter,
+	doc *firestore.DocumentSnapshot,
+) (int, error) {
+	var deck struct {
+		Title        string            `firestore:"title"`
+		OwnerID      string            `firestore:"owner_id"`
+		SharedEmails []string          `firestore:"shared_emails"`
+		Members      map[string]string `firestore:"members"`
+	}
+	if err := doc.DataTo(&deck); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	memberIDs := []string{}
+	memberships := make(map[string]models.Membership)
+	sharedEmails := []string{}
+	invited := 0
+	for _, shared := range deck.SharedEmails {
+		email := utils.NormalizeEmail(shared)
+
+		// Decks shared before roles existed gave full access to cards
+		role, ok := deck.Members[shared]
+		if !ok {
+			role = utils.ROLE_EDITOR
+		}
+
+		userID, err := utils.UserIDByEmail(client, ctx, email)
+		if err != nil {
+			return 0, err
+		}
+		if userID == "" {
+			_, err := bulkWriter.Set(inviteRef(client, doc.Ref.ID, email), models.Invite{
+				DeckID:    doc.Ref.ID,
+				DeckTitle: deck.Title,
+				Email:     email,
+				Role:      role,
+				InvitedBy: deck.OwnerID,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return 0, err
+			}
+			invited++
+			continue
+		}
+
+		// The same user may be shared with emails differing in case
+		if _, ok := memberships[userID]; ok {
+			continue
+		}
+		memberIDs = append(memberIDs, userID)
+		sharedEmails = append(sharedEmails, email)
+		memberships[userID] = models.Membership{Email: email, Role: role}
+	}
+
+	_, err := bulkWriter.Update(doc.Ref, []firestore.Update{
+		{Path: "member_ids", Value: memberIDs},
+		{Path: "memberships", Value: memberships},
+		{Path: "shared_emails", Value: sharedEmails},
+		{Path: "members", Value: firestore.Delete},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return invited, nil
+}
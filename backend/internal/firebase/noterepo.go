@@ -0,0 +1,547 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NoteRepository defines methods for storing note types, and the notes generating cards.
+type NoteRepository interface {
+	// CreateNoteType adds a new note type into firestore.
+	// Error on fail, returns the ID if successful
+	CreateNoteType(ctx context.Context, noteType models.NoteType) (string, error)
+
+	// GetNoteType fetches a note type by its ID.
+	// Error on fail or if the ID is not valid
+	GetNoteType(ctx context.Context, id string) (models.NoteType, error)
+
+	// GetNoteTypesByOwner fetches every note type owned by a user.
+	// Error on fail, returns the note types on success
+	GetNoteTypesByOwner(ctx context.Context, ownerID string) ([]models.NoteType, error)
+
+	// UpdateNoteType replaces an existing note type.
+	// Error on fail or if the ID is not valid, nil on success
+	UpdateNoteType(ctx context.Context, noteType models.NoteType) error
+
+	// DeleteNoteType deletes a note type that is not used by any note.
+	// Error on fail, if the ID is not valid or if notes still use it, nil on success
+	DeleteNoteType(ctx context.Context, id string) error
+
+	// NewNoteID returns an unused ID for a note in the given deck.
+	NewNoteID(deckID string) string
+
+	// SaveNote stores a note along with the cards generated from it, keyed by card ID,
+	// changed by userID at now.
+	// Cards previously generated from the note and no longer present are moved to the trash.
+	// Error on fail, returns the cards generated from the note before, keyed by ID, on success
+	SaveNote(
+		ctx context.Context,
+		deckID string,
+		note models.Note,
+		cards map[string]models.NoteCard,
+		userID string,
+		now time.Time,
+	) (map[string]map[string]any, error)
+
+	// GetNote fetches a note in a deck.
+	// Error on fail or if the ID is not valid
+	GetNote(ctx context.Context, deckID, noteID string) (models.Note, error)
+
+	// GetNotesInDeck fetches the notes in a deck with cursor-based pagination.
+	// Error on fail, returns the notes and whether there are more on success
+	GetNotesInDeck(
+		ctx context.Context,
+		deckID string,
+		limit int,
+		cursor string,
+	) ([]models.Note, bool, error)
+
+	// GetNotesByType fetches every note using a note type, across all decks not in the trash.
+	// Error on fail, returns the notes with their deck ID set on success
+	GetNotesByType(ctx context.Context, noteTypeID string) ([]models.Note, error)
+
+	// NoteTypeUsedInDeck checks if notes of a deck use a note type.
+	// Error on fail, returns true if they do on success
+	NoteTypeUsedInDeck(ctx context.Context, deckID, noteTypeID string) (bool, error)
+
+	// GetNoteCards fetches the raw data of the cards generated from a note.
+	// Error on fail, returns the cards with their ID set on success
+	GetNoteCards(ctx context.Context, deckID, noteID string) ([]map[string]any, error)
+
+	// DeleteNote moves a note to the trash of its deck along with the cards generated from it.
+	// Error on fail or if the ID is not valid, returns the deleted cards keyed by ID on success
+	DeleteNote(
+		ctx context.Context,
+		deckID, noteID, deletedBy string,
+		now time.Time,
+	) (map[string]map[string]any, error)
+
+	// RestoreNote moves a note out of the trash of its deck along with its cards.
+	// Error on fail or if the note is not in the trash, returns the note and its restored cards on success
+	RestoreNote(ctx context.Context, deckID, noteID string) (models.Note, map[string]map[string]any, error)
+}
+
+// FirestoreNoteRepo holds the connection to the database
+type FirestoreNoteRepo struct {
+	client *firestore.Client
+}
+
+// NewFirestoreNoteRepo creates and returns a pointer to the repository
+func NewFirestoreNoteRepo(client *firestore.Client) *FirestoreNoteRepo {
+	return &FirestoreNoteRepo{client: client}
+}
+
+// CreateNoteType adds a note type to firestore.
+// Returns the ID of the note type, or an error if the operation fails.
+func (r *FirestoreNoteRepo) CreateNoteType(
+	ctx context.Context,
+	noteType models.NoteType,
+) (string, error) {
+	return utils.AddToDB(r.client, ctx, config.NoteTypesCollection, noteType)
+}
+
+// GetNoteType fetches a note type by its ID.
+// Returns the note type, or an error if it does not exist.
+func (r *FirestoreNoteRepo) GetNoteType(
+	ctx context.Context,
+	id string,
+) (models.NoteType, error) {
+	doc, err := utils.GetDocumentIfExists(r.client, ctx, config.NoteTypesCollection, id)
+	if err != nil {
+		return models.NoteType{}, errors.ErrInvalidId
+	}
+
+	var noteType models.NoteType
+	if err := doc.DataTo(&noteType); err != nil {
+		return models.NoteType{}, err
+	}
+	noteType.ID = doc.Ref.ID
+
+	return noteType, nil
+}
+
+// GetNoteTypesByOwner fetches every note type owned by a user, ordered by name.
+// Returns the note types, or an error if the operation fails.
+func (r *FirestoreNoteRepo) GetNoteTypesByOwner(
+	ctx context.Context,
+	ownerID string,
+) ([]models.NoteType, error) {
+	docs, err := r.client.Collection(config.NoteTypesCollection).
+		Where("owner_id", "==", ownerID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	noteTypes := make([]models.NoteType, 0, len(docs))
+	for _, doc := range docs {
+		var noteType models.NoteType
+		if err := doc.DataTo(&noteType); err != nil {
+			return nil, err
+		}
+		noteType.ID = doc.Ref.ID
+		noteTypes = append(noteTypes, noteType)
+	}
+
+	return noteTypes, nil
+}
+
+// UpdateNoteType replaces the stored note type with the given one.
+// Returns an error if the note type does not exist or the operation fails.
+func (r *FirestoreNoteRepo) UpdateNoteType(
+	ctx context.Context,
+	noteType models.NoteType,
+) error {
+	docRef := r.client.Collection(config.NoteTypesCollection).Doc(noteType.ID)
+	if _, err := docRef.Get(ctx); err != nil {
+		return errors.ErrInvalidId
+	}
+
+	_, err := docRef.Set(ctx, noteType)
+	return err
+}
+
+// DeleteNoteType deletes a note type, as long as no note in any deck uses it,
+// including the notes in the trash which can still be restored.
+// Returns an error if the note type is in use, does not exist or the operation fails.
+func (r *FirestoreNoteRepo) DeleteNoteType(
+	ctx context.Context,
+	id string,
+) error {
+	for _, query := range []firestore.Query{
+		r.client.CollectionGroup(config.NotesCollection).Where("note_type_id", "==", id),
+		r.client.CollectionGroup(config.TrashCollection).Where("note.note_type_id", "==", id),
+	} {
+		docs, err := query.Limit(1).Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(docs) > 0 {
+			return errors.ErrNoteTypeInUse
+		}
+	}
+
+	return utils.DeleteDocumentInDB(r.client, ctx, config.NoteTypesCollection, id)
+}
+
+// NewNoteID returns a new document ID in the notes of a deck, without creating the note.
+func (r *FirestoreNoteRepo) NewNoteID(deckID string) string {
+	return r.notesRef(deckID).NewDoc().ID
+}
+
+// SaveNote stores a note and its generated cards in a single transaction.
+// Cards generated from the note earlier keep their fields before in a revision, or are moved
+// to the trash when they are not in cards, which happens when a template is removed
+// or no longer renders a front.
+// Returns the cards generated from the note before keyed by ID, or an error if the operation fails.
+func (r *FirestoreNoteRepo) SaveNote(
+	ctx context.Context,
+	deckID string,
+	note models.Note,
+	cards map[string]models.NoteCard,
+	userID string,
+	now time.Time,
+) (map[string]map[string]any, error) {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+	cardsRef := deckRef.Collection(config.CardsCollection)
+	oldCards := cardsRef.Where("note_id", "==", note.ID)
+
+	var before map[string]map[string]any
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// Every read has to happen before the writes of the transaction
+		oldDocs, err := tx.Documents(oldCards).GetAll()
+		if err != nil {
+			return err
+		}
+		before = make(map[string]map[string]any, len(oldDocs))
+		for _, doc := range oldDocs {
+			before[doc.Ref.ID] = doc.Data()
+		}
+
+		if err := tx.Set(r.notesRef(deckID).Doc(note.ID), note); err != nil {
+			return err
+		}
+
+		for id, card := range cards {
+			if err := tx.Set(cardsRef.Doc(id), card); err != nil {
+				return err
+			}
+		}
+
+		for _, doc := range oldDocs {
+			// Regenerated cards keep their fields before in a revision
+			if _, ok := cards[doc.Ref.ID]; ok {
+				revision := models.CardRevision{Fields: doc.Data(), AuthorID: userID, CreatedAt: now}
+				if err := tx.Create(doc.Ref.Collection(config.RevisionsCollection).NewDoc(), revision); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := trashCard(tx, doc, userID, now); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return before, nil
+}
+
+// GetNote fetches a note in a deck by its ID.
+// Returns the note, or an error if it does not exist.
+func (r *FirestoreNoteRepo) GetNote(
+	ctx context.Context,
+	deckID, noteID string,
+) (models.Note, error) {
+	doc, err := r.notesRef(deckID).Doc(noteID).Get(ctx)
+	if err != nil {
+		return models.Note{}, errors.ErrInvalidId
+	}
+
+	var note models.Note
+	if err := doc.DataTo(&note); err != nil {
+		return models.Note{}, err
+	}
+	note.ID = doc.Ref.ID
+
+	return note, nil
+}
+
+// GetNotesInDeck fetches the notes in a deck ordered by ID.
+// cursor is the ID of the last note from the previous page (empty for first page)
+// Returns the notes and whether there are more, or an error if the operation fails.
+func (r *FirestoreNoteRepo) GetNotesInDeck(
+	ctx context.Context,
+	deckID string,
+	limit int,
+	cursor string,
+) ([]models.Note, bool, error) {
+	// Fetch one extra to check for more pages
+	query := r.notesRef(deckID).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(limit + 1)
+
+	if cursor != "" {
+		query = query.StartAfter(cursor)
+	}
+
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, false, err
+	}
+
+	notes := make([]models.Note, 0, len(docs))
+	for _, doc := range docs {
+		var note models.Note
+		if err := doc.DataTo(&note); err != nil {
+			return nil, false, err
+		}
+		note.ID = doc.Ref.ID
+		notes = append(notes, note)
+	}
+
+	hasMore := false
+	if len(notes) > limit {
+		hasMore = true
+		notes = notes[:limit]
+	}
+
+	return notes, hasMore, nil
+}
+
+// NoteTypeUsedInDeck checks if notes of a deck use a note type.
+// Returns true if they do, or an error if the operation fails.
+func (r *FirestoreNoteRepo) NoteTypeUsedInDeck(
+	ctx context.Context,
+	deckID, noteTypeID string,
+) (bool, error) {
+	docs, err := r.client.Collection(config.DecksCollection).Doc(deckID).
+		Collection(config.NotesCollection).
+		Where("note_type_id", "==", noteTypeID).
+		Select().
+		Limit(1).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return false, err
+	}
+
+	return len(docs) > 0, nil
+}
+
+// GetNotesByType fetches every note using a note type in all decks, leaving out decks in the trash.
+// Returns the notes with their deck ID set, or an error if the operation fails.
+func (r *FirestoreNoteRepo) GetNotesByType(
+	ctx context.Context,
+	noteTypeID string,
+) ([]models.Note, error) {
+	iter := r.client.CollectionGroup(config.NotesCollection).
+		Where("note_type_id", "==", noteTypeID).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var notes []models.Note
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var note models.Note
+		if err := doc.DataTo(&note); err != nil {
+			return nil, err
+		}
+		note.ID = doc.Ref.ID
+		note.DeckID = doc.Ref.Parent.Parent.ID
+		notes = append(notes, note)
+	}
+
+	// The notes of decks in the trash stay under the deck until it is purged
+	deckIDs := make([]string, 0, len(notes))
+	for _, note := range notes {
+		if !slices.Contains(deckIDs, note.DeckID) {
+			deckIDs = append(deckIDs, note.DeckID)
+		}
+	}
+	existing, err := existingDecks(ctx, r.client, deckIDs)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(notes, func(note models.Note) bool {
+		return !existing[note.DeckID]
+	}), nil
+}
+
+// GetNoteCards fetches the raw data of every card generated from a note.
+// Returns the cards with their ID set, or an error if the operation fails.
+func (r *FirestoreNoteRepo) GetNoteCards(
+	ctx context.Context,
+	deckID, noteID string,
+) ([]map[string]any, error) {
+	docs, err := r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.CardsCollection).
+		Where("note_id", "==", noteID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make([]map[string]any, 0, len(docs))
+	for _, doc := range docs {
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		cards = append(cards, data)
+	}
+
+	return cards, nil
+}
+
+// DeleteNote moves a note and every card generated from it to the trash of its deck
+// in a single transaction. The note is kept under its own ID, next to its cards.
+// Returns the deleted cards keyed by ID, or an error if the note does not exist.
+func (r *FirestoreNoteRepo) DeleteNote(
+	ctx context.Context,
+	deckID, noteID, deletedBy string,
+	now time.Time,
+) (map[string]map[string]any, error) {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+	noteRef := r.notesRef(deckID).Doc(noteID)
+	cards := deckRef.Collection(config.CardsCollection).Where("note_id", "==", noteID)
+
+	var deleted map[string]map[string]any
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		deleted = make(map[string]map[string]any)
+
+		noteDoc, err := tx.Get(noteRef)
+		if err != nil {
+			return errors.ErrInvalidId
+		}
+		var note models.Note
+		if err := noteDoc.DataTo(&note); err != nil {
+			return err
+		}
+
+		cardDocs, err := tx.Documents(cards).GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, doc := range cardDocs {
+			if err := trashCard(tx, doc, deletedBy, now); err != nil {
+				return err
+			}
+			deleted[doc.Ref.ID] = doc.Data()
+		}
+
+		err = tx.Set(deckRef.Collection(config.TrashCollection).Doc(noteID), models.DeletedCard{
+			Note:      &note,
+			DeletedBy: deletedBy,
+			DeletedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Delete(noteRef)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// RestoreNote moves a note out of the trash of its deck in a single transaction,
+// along with the cards deleted with it.
+// Returns the note and its restored cards keyed by ID, or an error if the note is not in the trash.
+func (r *FirestoreNoteRepo) RestoreNote(
+	ctx context.Context,
+	deckID, noteID string,
+) (models.Note, map[string]map[string]any, error) {
+	deckRef := r.client.Collection(config.DecksCollection).Doc(deckID)
+	trashRef := deckRef.Collection(config.TrashCollection)
+	trashedCards := trashRef.Where("card.note_id", "==", noteID)
+
+	var note models.Note
+	var restored map[string]map[string]any
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		restored = make(map[string]map[string]any)
+
+		noteDoc, err := tx.Get(trashRef.Doc(noteID))
+		if status.Code(err) == codes.NotFound {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var deleted models.DeletedCard
+		if err := noteDoc.DataTo(&deleted); err != nil {
+			return err
+		}
+		if deleted.Note == nil {
+			return errors.ErrNotFound
+		}
+		note = *deleted.Note
+		note.ID = noteID
+
+		cardDocs, err := tx.Documents(trashedCards).GetAll()
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Create(r.notesRef(deckID).Doc(noteID), note); err != nil {
+			return err
+		}
+		for _, doc := range cardDocs {
+			var card models.DeletedCard
+			if err := doc.DataTo(&card); err != nil {
+				return err
+			}
+			// Cards of templates dropped before the note was deleted stay in the trash
+			if !card.DeletedAt.Equal(deleted.DeletedAt) {
+				continue
+			}
+			cardRef := deckRef.Collection(config.CardsCollection).Doc(doc.Ref.ID)
+			if err := tx.Create(cardRef, card.Card); err != nil {
+				return err
+			}
+			if err := tx.Delete(doc.Ref); err != nil {
+				return err
+			}
+			restored[doc.Ref.ID] = card.Card
+		}
+
+		return tx.Delete(noteDoc.Ref)
+	})
+	if err != nil {
+		return models.Note{}, nil, err
+	}
+
+	return note, restored, nil
+}
+
+// notesRef returns the collection holding the notes of a deck.
+func (r *FirestoreNoteRepo) notesRef(deckID string) *firestore.CollectionRef {
+	return r.client.Collection(config.DecksCollection).
+		Doc(deckID).
+		Collection(config.NotesCollection)
+}
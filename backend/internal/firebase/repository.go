@@ -6,10 +6,15 @@ import (
 
 // Repositories groups all Firestore repositories.
 type Repositories struct {
-	User *FirestoreUserRepo
-	Card *FirestoreCardRepo
-	Deck *FirestoreDeckRepo
-	Auth *FirebaseAuthRepo
+	User   *FirestoreUserRepo
+	Card   *FirestoreCardRepo
+	Deck   *FirestoreDeckRepo
+	Note   *FirestoreNoteRepo
+	Invite *FirestoreInviteRepo
+	Link   *FirestoreShareLinkRepo
+	Class  *FirestoreClassRepo
+	Audit  *FirestoreAuditRepo
+	Auth   *FirebaseAuthRepo
 }
 
 // NewRepositories creates a new Repositories struct with the provided Firestore client.
@@ -18,9 +23,14 @@ func NewRepositories(
 	auth *FirebaseAuthRepo,
 ) *Repositories {
 	return &Repositories{
-		User: NewFirestoreUserRepo(client),
-		Card: NewFirestoreCardRepo(client),
-		Deck: NewFirestoreDeckRepo(client),
-		Auth: auth,
+		User:   NewFirestoreUserRepo(client),
+		Card:   NewFirestoreCardRepo(client),
+		Deck:   NewFirestoreDeckRepo(client),
+		Note:   NewFirestoreNoteRepo(client),
+		Invite: NewFirestoreInviteRepo(client),
+		Link:   NewFirestoreShareLinkRepo(client),
+		Class:  NewFirestoreClassRepo(client),
+		Audit:  NewFirestoreAuditRepo(client),
+		Auth:   auth,
 	}
 }
@@ -0,0 +1,193 @@
+package firebase
+
+import (
+	"context"
+	"memora/internal/config"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ShareLinkRepository defines methods for the links sharing a deck with anyone holding them.
+type ShareLinkRepository interface {
+	// CreateShareLink stores a new share link under the given ID.
+	// Error on fail, nil on success
+	CreateShareLink(ctx context.Context, id string, link models.ShareLink) error
+
+	// GetDeckShareLinks fetches the share links of a deck.
+	// Error on fail, returns the links on success
+	GetDeckShareLinks(ctx context.Context, deckID string) ([]models.ShareLink, error)
+
+	// GetShareLink fetches a share link by its ID.
+	// Error on fail or if the ID is not valid
+	GetShareLink(ctx context.Context, id string) (models.ShareLink, error)
+
+	// DeleteShareLink deletes a share link by its ID.
+	// Error on fail, nil on success
+	DeleteShareLink(ctx context.Context, id string) error
+
+	// RedeemShareLink makes a user a member of the deck of a share link, counting a use of the link.
+	// Owners and members keep their role without using the link.
+	// Error on fail, if the ID is not valid, or if the link expired or is used up
+	RedeemShareLink(
+		ctx context.Context,
+		id, userID, email string,
+		now time.Time,
+	) (models.ShareLinkRedemption, error)
+}
+
+// FirestoreShareLinkRepo implements the ShareLinkRepository interface using Firestore.
+type FirestoreShareLinkRepo struct {
+	client *firestore.Client
+}
+
+// NewFirestoreShareLinkRepo creates and returns a pointer to the FirestoreShareLinkRepo.
+func NewFirestoreShareLinkRepo(client *firestore.Client) *FirestoreShareLinkRepo {
+	return &FirestoreShareLinkRepo{client: client}
+}
+
+// CreateShareLink stores a new share link under the given ID.
+// Returns an error if the operation fails.
+func (r *FirestoreShareLinkRepo) CreateShareLink(
+	ctx context.Context,
+	id string,
+	link models.ShareLink,
+) error {
+	_, err := r.client.Collection(config.ShareLinksCollection).Doc(id).Create(ctx, link)
+	return err
+}
+
+// GetDeckShareLinks fetches every share link of a deck.
+// Returns the links or an error if the operation fails.
+func (r *FirestoreShareLinkRepo) GetDeckShareLinks(
+	ctx context.Context,
+	deckID string,
+) ([]models.ShareLink, error) {
+	docs, err := r.client.Collection(config.ShareLinksCollection).
+		Where("deck_id", "==", deckID).
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]models.ShareLink, 0, len(docs))
+	for _, doc := range docs {
+		var link models.ShareLink
+		if err := doc.DataTo(&link); err != nil {
+			return nil, err
+		}
+		link.ID = doc.Ref.ID
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// GetShareLink fetches a share link by its ID.
+// Returns the link or an error if it does not exist or the operation fails.
+func (r *FirestoreShareLinkRepo) GetShareLink(
+	ctx context.Context,
+	id string,
+) (models.ShareLink, error) {
+	doc, err := r.client.Collection(config.ShareLinksCollection).Doc(id).Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return models.ShareLink{}, errors.ErrNotFound
+	}
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+
+	var link models.ShareLink
+	if err := doc.DataTo(&link); err != nil {
+		return models.ShareLink{}, err
+	}
+	link.ID = doc.Ref.ID
+
+	return link, nil
+}
+
+// DeleteShareLink deletes a share link by its ID.
+// Returns an error if the operation fails.
+func (r *FirestoreShareLinkRepo) DeleteShareLink(ctx context.Context, id string) error {
+	_, err := r.client.Collection(config.ShareLinksCollection).Doc(id).Delete(ctx)
+	return err
+}
+
+// RedeemShareLink makes a user a member of the deck of a share link with its role,
+// and counts the use in the same transaction, so a link is never used more than allowed.
+// Returns the deck and the role of the user on it, or an error if the link is not valid,
+// expired or used up, or the operation fails.
+func (r *FirestoreShareLinkRepo) RedeemShareLink(
+	ctx context.Context,
+	id, userID, email string,
+	now time.Time,
+) (models.ShareLinkRedemption, error) {
+	linkRef := r.client.Collection(config.ShareLinksCollection).Doc(id)
+
+	var redemption models.ShareLinkRedemption
+	err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		linkDoc, err := tx.Get(linkRef)
+		if status.Code(err) == codes.NotFound {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var link models.ShareLink
+		if err := linkDoc.DataTo(&link); err != nil {
+			return err
+		}
+
+		deckRef := r.client.Collection(config.DecksCollection).Doc(link.DeckID)
+		deckDoc, err := tx.Get(deckRef)
+		if status.Code(err) == codes.NotFound {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var deck models.Deck
+		if err := deckDoc.DataTo(&deck); err != nil {
+			return err
+		}
+
+		redemption = models.ShareLinkRedemption{DeckID: link.DeckID}
+
+		// Users with access already keep their role, even a higher one than the link grants
+		if deck.OwnerID == userID {
+			redemption.Role = utils.ROLE_OWNER
+			return nil
+		}
+		if membership, ok := deck.Memberships[userID]; ok {
+			redemption.Role = membership.Role
+			return nil
+		}
+
+		if link.ExpiresAt != nil && !now.Before(*link.ExpiresAt) {
+			return errors.ErrShareLinkExpired
+		}
+		if link.MaxUses > 0 && link.Uses >= link.MaxUses {
+			return errors.ErrShareLinkExpired
+		}
+
+		redemption.Role = link.Role
+		err = tx.Update(deckRef, membershipUpdates(map[string]models.Membership{
+			userID: {Email: utils.NormalizeEmail(email), Role: link.Role},
+		}))
+		if err != nil {
+			return err
+		}
+
+		return tx.Update(linkRef, []firestore.Update{
+			{Path: "uses", Value: firestore.Increment(1)},
+		})
+	})
+
+	return redemption, err
+}
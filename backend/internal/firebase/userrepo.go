@@ -32,10 +32,13 @@ type UserRepository interface {
 	// Returns nil on success.
 	UpdateUser(ctx context.Context, firestoreUpdates []firestore.Update, id string) error
 
-	// DeleteUser deletes a user from Firestore by ID.
+	// DeleteUser deletes a user from Firestore by ID, with the decks the user owns
+	// and everything stored under them, and the classes the user teaches,
+	// and removes the user from their other classes.
+	// Decks the chosen member belongs to are handed over to the member instead.
 	// Error on failure or if the ID is invalid.
-	// Returns nil on success.
-	DeleteUser(ctx context.Context, id string) error
+	// Returns the IDs of the decks handed over on success.
+	DeleteUser(ctx context.Context, id, transferTo string) ([]string, error)
 }
 
 // FirestoreUserRepo implements the UserRepository interface using Firestore as the backend.
@@ -76,12 +79,14 @@ func (r *FirestoreUserRepo) GetDecks(
 ) (models.UserDecks, error) {
 
 	// Get the user by ID. After middleware is introduced, this can be omitted.
-	user, err := utils.FetchByID[models.User](
+	user, err := utils.FetchByID[struct {
+		StudyDecks []string `firestore:"study_decks"`
+	}](
 		r.client,
 		ctx,
 		config.UsersCollection,
 		id,
-		[]string{"email"},
+		[]string{"study_decks"},
 	)
 	if err != nil {
 		return models.UserDecks{}, err
@@ -106,9 +111,9 @@ func (r *FirestoreUserRepo) GetDecks(
 	}()
 
 	go func() {
-		// Create iterator where shared_emails array contains the user's email.
+		// Create iterator where member_ids array contains the user's ID.
 		iter := r.client.Collection(config.DecksCollection).
-			Where("shared_emails", "array-contains", user.Email).
+			Where("member_ids", "array-contains", id).
 			Select(fields...).
 			Documents(ctx)
 		decksShared, err := readDataFromIterator(iter)
@@ -125,9 +130,15 @@ func (r *FirestoreUserRepo) GetDecks(
 		return models.UserDecks{}, sharedRes.err
 	}
 
+	studyDecks, err := r.getStudyDecks(ctx, user.StudyDecks)
+	if err != nil {
+		return models.UserDecks{}, err
+	}
+
 	decks := models.UserDecks{
 		OwnedDecks:  ownedRes.decks,
 		SharedDecks: sharedRes.decks,
+		StudyDecks:  studyDecks,
 	}
 
 	return decks, nil
@@ -157,6 +168,7 @@ func (r *FirestoreUserRepo) AddUser(
 			Title:        "Default Deck",
 			OwnerID:      id,
 			SharedEmails: []string{},
+			MemberIDs:    []string{},
 		}
 		if err := tx.Set(deckRef, mockDeck); err != nil {
 			return err
@@ -192,64 +204,262 @@ func (r *FirestoreUserRepo) UpdateUser(
 	)
 }
 
-// DeleteUser deletes a user from Firestore by ID.
+// DeleteUser deletes a user from Firestore by ID, with the decks the user owns, also those
+// in their trash, purged like the trash is, the classes the user teaches
+// and their enrolment in other classes.
+// When a member to transfer to is given, by user ID or email, the decks the member
+// belongs to are handed over first, each in a transaction, and are not deleted.
 // Error on failure or if the ID is invalid.
-// Returns nil on success
-// TODO refactor this to support batching
+// Returns the IDs of the decks handed over on success
 func (r *FirestoreUserRepo) DeleteUser(
 	ctx context.Context,
-	id string,
-) error {
-	decksIter := r.client.Collection(config.DecksCollection).
+	id, transferTo string,
+) ([]string, error) {
+	var transferred []string
+	if transferTo != "" {
+		var err error
+		transferred, err = r.transferDecks(ctx, id, transferTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Decks go with everything stored under them, also those in the trash of the user
+	owned, err := r.client.Collection(config.DecksCollection).
+		Where("owner_id", "==", id).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+	trashed, err := r.client.Collection(config.DeletedDecksCollection).
 		Where("owner_id", "==", id).
-		Documents(ctx)
-	defer decksIter.Stop()
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
 
-	bulkWriter := r.client.BulkWriter(ctx)
+	deletedDecks := make(map[string]bool, len(owned)+len(trashed))
+	for _, doc := range owned {
+		deletedDecks[doc.Ref.ID] = true
+		if err := discardDeck(ctx, r.client, doc.Ref.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, doc := range trashed {
+		deletedDecks[doc.Ref.ID] = true
+		if err := purgeDeckContents(ctx, r.client, doc.Ref.ID); err != nil {
+			return nil, err
+		}
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	for {
-		deckDoc, err := decksIter.Next()
-		if err == iterator.Done {
-			break
+	// Delete the note types of the user, except those still used by notes in decks
+	// of other users, which could no longer be updated without them
+	noteTypes, err := r.client.Collection(config.NoteTypesCollection).
+		Where("owner_id", "==", id).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var refs []*firestore.DocumentRef
+	for _, doc := range noteTypes {
+		inUse, err := r.noteTypeUsedOutside(ctx, doc.Ref.ID, deletedDecks)
+		if err != nil {
+			return nil, err
+		}
+		if !inUse {
+			refs = append(refs, doc.Ref)
 		}
+	}
+
+	classRefs, err := r.deleteClasses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, classRefs...)
+
+	if err := deleteDocs(ctx, r.client, refs); err != nil {
+		return nil, err
+	}
+
+	// The user goes last, so a failed deletion can be retried
+	if _, err := r.client.Collection(config.UsersCollection).Doc(id).Delete(ctx); err != nil {
+		return nil, err
+	}
+
+	return transferred, nil
+}
+
+// deleteClasses removes a user from the classes they are enrolled in, and lists the classes
+// the user teaches with their assignments, to delete them with the user.
+// Returns the references of the classes and assignments, or an error if the operation fails.
+func (r *FirestoreUserRepo) deleteClasses(
+	ctx context.Context,
+	userID string,
+) ([]*firestore.DocumentRef, error) {
+	classes := r.client.Collection(config.ClassesCollection)
+
+	enrolled, err := classes.Where("student_ids", "array-contains", userID).Select().Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range enrolled {
+		_, err := doc.Ref.Update(ctx, []firestore.Update{
+			{FieldPath: firestore.FieldPath{"students", userID}, Value: firestore.Delete},
+			{Path: "student_ids", Value: firestore.ArrayRemove(userID)},
+		})
 		if err != nil {
-			return err
+			return nil, err
 		}
-		// Delete all cards in this deck
-		cardsIter := deckDoc.Ref.Collection("cards").Documents(ctx)
+	}
 
-		for {
-			cardDoc, err := cardsIter.Next()
-			if err == iterator.Done {
-				break
+	taught, err := classes.Where("teacher_id", "==", userID).Select().Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var refs []*firestore.DocumentRef
+	for _, classDoc := range taught {
+		assignments, err := classDoc.Ref.Collection(config.AssignmentsCollection).
+			Select().
+			Documents(ctx).
+			GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range assignments {
+			refs = append(refs, doc.Ref)
+		}
+
+		// Enrolments are kept on the class, so they go with it
+		refs = append(refs, classDoc.Ref)
+	}
+
+	return refs, nil
+}
+
+// noteTypeUsedOutside checks if a note type is used by notes in decks other than the given ones,
+// including the notes in the trash which can still be restored.
+// Returns true if it is, or an error if the operation fails.
+func (r *FirestoreUserRepo) noteTypeUsedOutside(
+	ctx context.Context,
+	noteTypeID string,
+	decks map[string]bool,
+) (bool, error) {
+	for _, query := range []firestore.Query{
+		r.client.CollectionGroup(config.NotesCollection).Where("note_type_id", "==", noteTypeID),
+		r.client.CollectionGroup(config.TrashCollection).Where("note.note_type_id", "==", noteTypeID),
+	} {
+		docs, err := query.Select().Documents(ctx).GetAll()
+		if err != nil {
+			return false, err
+		}
+
+		for _, doc := range docs {
+			if !decks[doc.Ref.Parent.Parent.ID] {
+				return true, nil
 			}
+		}
+	}
+	return false, nil
+}
+
+// transferDecks hands the decks a user owns over to a member of each deck,
+// found by their user ID or email. Decks the member does not belong to are left alone.
+// Returns the IDs of the decks handed over, or an error if the operation fails.
+func (r *FirestoreUserRepo) transferDecks(
+	ctx context.Context,
+	ownerID, member string,
+) ([]string, error) {
+	docs, err := r.client.Collection(config.DecksCollection).
+		Where("owner_id", "==", ownerID).
+		Select().
+		Documents(ctx).
+		GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var transferred []string
+	for _, doc := range docs {
+		handedOver := false
+		err := r.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			handedOver = false
+
+			snap, err := tx.Get(doc.Ref)
 			if err != nil {
 				return err
 			}
-
-			// Schedule delete using BulkWriter
-			if _, err := bulkWriter.Delete(cardDoc.Ref); err != nil {
+			var deck models.Deck
+			if err := snap.DataTo(&deck); err != nil {
 				return err
 			}
-		}
 
-		// Delete the deck itself
-		if _, err := bulkWriter.Delete(deckDoc.Ref); err != nil {
-			return err
+			memberID, ok := deck.MemberID(member)
+			if !ok {
+				return nil
+			}
+			handedOver = true
+
+			// The owner is leaving, so they do not stay on the deck
+			return tx.Update(doc.Ref, ownershipUpdates(deck, memberID, nil))
+		})
+		if err != nil {
+			return nil, err
+		}
+		if handedOver {
+			transferred = append(transferred, doc.Ref.ID)
 		}
 	}
 
-	userRef := r.client.Collection(config.UsersCollection).Doc(id)
+	return transferred, nil
+}
 
-	if _, err := bulkWriter.Delete(userRef); err != nil {
-		return err
+// getStudyDecks reads the decks of a study list.
+// Decks deleted or no longer public since they were added are left out.
+func (r *FirestoreUserRepo) getStudyDecks(
+	ctx context.Context,
+	deckIDs []string,
+) ([]models.DisplayDeck, error) {
+	if len(deckIDs) == 0 {
+		return nil, nil
+	}
+
+	refs := make([]*firestore.DocumentRef, len(deckIDs))
+	for i, id := range deckIDs {
+		refs[i] = r.client.Collection(config.DecksCollection).Doc(id)
 	}
 
-	// Wait for all operations to complete
-	bulkWriter.Flush()
-	bulkWriter.End()
+	docs, err := r.client.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	var results []models.DisplayDeck
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		if public, _ := doc.Data()["public"].(bool); !public {
+			continue
+		}
+
+		var item models.DisplayDeck
+		if err := doc.DataTo(&item); err != nil {
+			return nil, err
+		}
+		item.ID = doc.Ref.ID
+		results = append(results, item)
+	}
+
+	return results, nil
 }
 
 // readDataFromIterator reads documents from a Firestore DocumentIterator
@@ -0,0 +1,307 @@
+package classes
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get the user's classes
+// @Description Lists the classes taught by the user
+// @Tags Classes
+// @Produce json
+// @Success 200 {array} models.Class
+// @Router /api/v1/classes [get]
+func GetClasses(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		classes, err := classRepo.GetClasses(c.Request.Context(), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, classes)
+	}
+}
+
+// @Summary Create a class
+// @Description Creates a class taught by the user, with a join code students can join it with
+// @Tags Classes
+// @Accept json
+// @Produce json
+// @Param class body models.CreateClass true "Class info"
+// @Success 201 {object} models.Class
+// @Router /api/v1/classes [post]
+func CreateClass(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body models.CreateClass
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		class, err := classRepo.CreateClass(c.Request.Context(), uid, body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, class)
+	}
+}
+
+// @Summary Get a class
+// @Description Retrieves a class taught by the user, with its students
+// @Tags Classes
+// @Produce json
+// @Param classID path string true "Class ID"
+// @Success 200 {object} models.Class
+// @Router /api/v1/classes/{classID} [get]
+func GetClass(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class, err := classRepo.GetClass(c.Request.Context(), c.Param("classID"), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, class)
+	}
+}
+
+// @Summary Delete a class
+// @Description Deletes a class taught by the user with its assignments, students keep access to the assigned decks
+// @Tags Classes
+// @Param classID path string true "Class ID"
+// @Success 204
+// @Router /api/v1/classes/{classID} [delete]
+func DeleteClass(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := classRepo.DeleteClass(c.Request.Context(), c.Param("classID"), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Join a class
+// @Description Enrolls the user in the class with the join code, and gives them access to its assigned decks
+// @Tags Classes
+// @Produce json
+// @Param code path string true "Join code of the class"
+// @Success 200 {object} models.ReturnID
+// @Router /api/v1/classes/join/{code} [post]
+func JoinClass(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := classRepo.JoinClass(
+			c.Request.Context(),
+			c.Param("code"), c.GetString("uid"), c.GetString("email"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ReturnID{ID: id})
+	}
+}
+
+// @Summary Invite students to a class
+// @Description Invites the emails to a class taught by the user. Their users are enrolled and given access to its assigned decks once they accept. Whether an email has a registered user is not told
+// @Tags Classes
+// @Accept json
+// @Produce json
+// @Param classID path string true "Class ID"
+// @Param students body models.EnrollStudents true "Emails of the students"
+// @Success 200 {object} models.Enrollment
+// @Router /api/v1/classes/{classID}/students [post]
+func InviteStudents(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body models.EnrollStudents
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		enrollment, err := classRepo.InviteStudents(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"),
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+// @Summary Get the class invites of the user
+// @Description Lists the classes the email of the user is invited to
+// @Tags Classes
+// @Produce json
+// @Success 200 {array} models.ClassInvite
+// @Router /api/v1/classes/invites [get]
+func GetClassInvites(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invites, err := classRepo.GetClassInvites(c.Request.Context(), c.GetString("email"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, invites)
+	}
+}
+
+// @Summary Accept an invite to a class
+// @Description Enrolls the user in a class their email is invited to, and gives them access to its assigned decks. The email of the account must be verified
+// @Tags Classes
+// @Param classID path string true "Class ID"
+// @Success 204
+// @Router /api/v1/classes/{classID}/accept [post]
+func AcceptClassInvite(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := classRepo.AcceptClassInvite(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"), c.GetString("email"),
+			utils.EmailVerified(c),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Remove a student from a class
+// @Description Removes a student from a class taught by the user, the student keeps access to the assigned decks
+// @Tags Classes
+// @Param classID path string true "Class ID"
+// @Param studentID path string true "User ID of the student"
+// @Success 204
+// @Router /api/v1/classes/{classID}/students/{studentID} [delete]
+func RemoveStudent(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := classRepo.RemoveStudent(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"), c.Param("studentID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Assign a deck to a class
+// @Description Assigns a deck to a class with a due date, the students become viewers of the deck unless they have access already. Only owners and co-owners of the deck can assign it
+// @Tags Classes
+// @Accept json
+// @Produce json
+// @Param classID path string true "Class ID"
+// @Param assignment body models.CreateAssignment true "Deck and due date"
+// @Success 201 {object} models.Assignment
+// @Router /api/v1/classes/{classID}/assignments [post]
+func AssignDeck(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body models.CreateAssignment
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		assignment, err := classRepo.AssignDeck(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"),
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, assignment)
+	}
+}
+
+// @Summary List the assignments of a class
+// @Description Lists the decks assigned to a class taught by the user, the earliest due first. Decks in the trash are left out until they are restored
+// @Tags Classes
+// @Produce json
+// @Param classID path string true "Class ID"
+// @Success 200 {array} models.Assignment
+// @Router /api/v1/classes/{classID}/assignments [get]
+func GetAssignments(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assignments, err := classRepo.GetAssignments(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, assignments)
+	}
+}
+
+// @Summary Delete an assignment
+// @Description Deletes an assignment of a class taught by the user, the students keep access to the deck
+// @Tags Classes
+// @Param classID path string true "Class ID"
+// @Param assignmentID path string true "Assignment ID"
+// @Success 204
+// @Router /api/v1/classes/{classID}/assignments/{assignmentID} [delete]
+func DeleteAssignment(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := classRepo.DeleteAssignment(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"), c.Param("assignmentID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Get the progress of a class
+// @Description Sums up the progress of every student on every assignment: cards studied, reviews, retention and cards overdue for review. Assignments of decks in the trash are left out
+// @Tags Classes
+// @Produce json
+// @Param classID path string true "Class ID"
+// @Success 200 {object} models.ClassProgress
+// @Router /api/v1/classes/{classID}/progress [get]
+func GetClassProgress(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		progress, err := classRepo.GetClassProgress(
+			c.Request.Context(),
+			c.Param("classID"), c.GetString("uid"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
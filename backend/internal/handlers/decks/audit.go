@@ -0,0 +1,34 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get the audit log of a deck
+// @Description Lists the changes made to the deck and its cards, the latest first, with who made them, the request they were made in and the fields they changed. Only the owner can read the log
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param limit query string false "Number of events to retrieve" default(20)
+// @Param cursor query string false "Cursor for pagination"
+// @Success 200 {object} models.AuditLogResponse
+// @Router /api/v1/decks/{deckID}/audit [get]
+func GetAuditLog(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log, err := deckRepo.GetAuditLog(
+			c.Request.Context(),
+			c.Param("deckID"),
+			c.DefaultQuery("limit", "20"),
+			c.DefaultQuery("cursor", ""),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, log)
+	}
+}
@@ -1,26 +1,29 @@
 package decks
 
 import (
-	"context"
-	"log/slog"
 	"memora/internal/errors"
 	"memora/internal/models"
 	"memora/internal/services"
 	"memora/internal/utils"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Get cards in a deck
-// @Description Retrieves cards from a specified deck in Firestore
+// @Description Retrieves cards from a specified deck in Firestore.
+// @Description Cards are returned as stored to editors, and as presented to the other members, so matching cards do not give away their pairs.
+// @Description Only tags_any with at most 30 tags narrows the cards read, the other tag filters are applied while reading the deck.
+// @Description Reading stops after skipping 1000 cards, returning a short page with has_more, or a 400 when no card matched.
 // @Tags Decks
 // @Accept json
 // @Produce json
 // @Param deckID path string true "Deck ID"
 // @Param limit query string false "Number of cards to retrieve" default(20)
 // @Param cursor query string false "Cursor for pagination"
+// @Param tags_any query string false "Comma separated tags, cards must have at least one of them"
+// @Param tags_all query string false "Comma separated tags, cards must have all of them"
+// @Param tags_not query string false "Comma separated tags, cards must have none of them"
 // @Success 200 {object} models.CardsResponse
 // @Router /api/v1/decks/{deckID}/cards [get]
 func GetCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
@@ -34,19 +37,31 @@ func GetCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 			deckID,
 			limit,
 			cursor,
+			tagFilterFromQuery(c),
 		)
 		if errors.HandleError(c, err) {
 			return
 		}
+
+		// Only editors need the stored cards, to change them
+		var shown []any
+		if utils.RoleAllows(c.GetString("deckRole"), utils.ROLE_EDITOR) {
+			for _, card := range cards {
+				shown = append(shown, card)
+			}
+		} else {
+			shown = models.PresentCards(cards)
+		}
+
 		c.JSON(http.StatusOK, models.CardsResponse{
-			Cards:   cards,
+			Cards:   shown,
 			HasMore: hasMore,
 		})
 	}
 }
 
 // @Summary Get a deck
-// @Description Retrieves deck information from Firestore
+// @Description Retrieves deck information from Firestore. Public decks can be read by anyone, without the emails they are shared with and their roles
 // @Tags Decks
 // @Accept json
 // @Produce json
@@ -56,26 +71,20 @@ func GetCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 func GetDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
-		filter := c.DefaultQuery("filter", "title,owner_id,shared_emails")
-
-		uid := c.GetString("uid")
-		email := c.GetString("email")
-
-		canAccess, err := deckRepo.CheckIfUserCanAccessDeck(
-			c.Request.Context(),
-			deckID, uid, email,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
+		filter := c.DefaultQuery("filter", "title,owner_id,shared_emails,memberships,forked_from,forked_at,upstream,public,publication,transfer")
 
 		deck, err := deckRepo.GetOneDeck(c.Request.Context(), deckID, filter)
 		if errors.HandleError(c, err) {
 			return
 		}
 
+		// Readers of a public deck do not see who it is shared with
+		if c.GetString("deckRole") == utils.ROLE_READER {
+			deck.SharedEmails = nil
+			deck.Memberships = nil
+			deck.Transfer = nil
+		}
+
 		c.JSON(http.StatusOK, deck)
 	}
 }
@@ -93,20 +102,81 @@ func GetCardInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
 		cardID := c.Param("cardID")
-		uid := c.GetString("uid")
-		email := c.GetString("email")
 
-		canAccess, err := deckRepo.CheckIfUserCanAccessDeck(
+		card, err := deckRepo.GetCardInDeck(c.Request.Context(), deckID, cardID)
+		if errors.HandleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusOK, card)
+	}
+}
+
+// @Summary Get a card in a deck as HTML
+// @Description Retrieves a card with every text field rendered to sanitized HTML.
+// @Description Markdown is rendered, plain text is escaped, and math delimiters are kept for the client.
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Success 200 {object} models.AnyCard
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/html [get]
+func GetCardHTMLInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		cardID := c.Param("cardID")
+
+		card, err := deckRepo.GetCardHTMLInDeck(c.Request.Context(), deckID, cardID)
+		if errors.HandleError(c, err) {
+			return
+		}
+		c.JSON(http.StatusOK, card)
+	}
+}
+
+// @Summary Get the highlighted code of a code card
+// @Description Renders the code of a code card to HTML with inline styles and line numbers,
+// @Description so every client shows it the same way. The highlighted line range of the card is marked.
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Param style query string false "Highlighting style" default(github)
+// @Success 200 {object} models.CodeHighlight
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/highlight [get]
+func GetCodeHighlightInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		cardID := c.Param("cardID")
+		style := c.DefaultQuery("style", utils.DefaultCodeStyle)
+
+		highlight, err := deckRepo.GetCodeHighlightInDeck(
 			c.Request.Context(),
-			deckID, uid, email,
+			deckID, cardID,
+			style,
 		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
+		if errors.HandleError(c, err) {
 			return
 		}
+		c.JSON(http.StatusOK, highlight)
+	}
+}
 
-		card, err := deckRepo.GetCardInDeck(c.Request.Context(), deckID, cardID)
+// @Summary Get a card in a deck to present it
+// @Description Retrieves a card as it should be shown to the learner.
+// @Description Matching cards have both sides shuffled separately, other cards are returned as stored.
+// @Description The due cards are not shuffled, so this is how a matching card in the review queue is shown.
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Success 200 {object} models.MatchingPresentation
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/presentation [get]
+func PresentCardInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		cardID := c.Param("cardID")
+
+		card, err := deckRepo.PresentCardInDeck(c.Request.Context(), deckID, cardID)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -140,12 +210,7 @@ func CreateDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 			return
 		}
 
-		ownerEmail, err := utils.GetEmail(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		id, err := deckRepo.RegisterNewDeck(c.Request.Context(), content, ownerEmail)
+		id, err := deckRepo.RegisterNewDeck(c.Request.Context(), content)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -161,24 +226,12 @@ func CreateDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param deckID path string true "Deck ID"
-// @Param card body object true "Card info (can be MultipleChoiceCard, FrontBackCard, OrderedCard, or BlanksCard)"
+// @Param card body object true "Card info (can be MultipleChoiceCard, FrontBackCard, OrderedCard, BlanksCard, NumericCard, MatchingCard or CodeCard), note cards are created through notes"
 // @Success 201 {object} models.AnyCard
 // @Router /api/v1/decks/{deckID}/cards [post]
 func CreateCardInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
-		uid := c.GetString("uid")
-		email := c.GetString("email")
-
-		canAccess, err := deckRepo.CheckIfUserCanAccessDeck(
-			c.Request.Context(),
-			deckID, uid, email,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
 
 		rawData, err := c.GetRawData()
 		if errors.HandleError(c, err) {
@@ -206,21 +259,6 @@ func PatchDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var body models.UpdateDeck
 		deckID := c.Param("deckID")
-		uid, err := utils.GetUID(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		canAccess, err := deckRepo.UserOwnsDeck(
-			c.Request.Context(),
-			deckID, uid,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
-
 		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "invalid body",
@@ -228,12 +266,7 @@ func PatchDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 			return
 		}
 
-		email, err := utils.GetEmail(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		deck, err := deckRepo.UpdateDeck(c.Request.Context(), deckID, email, body)
+		deck, err := deckRepo.UpdateDeck(c.Request.Context(), deckID, body)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -243,7 +276,7 @@ func PatchDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 }
 
 // @Summary Update a decks' emails
-// @Description Updates a decks shared emails in Firestore by ID
+// @Description Updates a decks shared emails in Firestore by ID, added emails get the given role (viewer, editor or co_owner), editor by default. Emails without a registered user are invited, and become members when they register. Only co-owners and the owner can share a deck
 // @Tags Decks
 // @Accept json
 // @Produce json
@@ -253,27 +286,7 @@ func PatchDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 func UpdateEmails(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
-		uid, err := utils.GetUID(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		canAccess, err := deckRepo.UserOwnsDeck(
-			c.Request.Context(),
-			deckID, uid,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
-
 		var body models.UpdateDeckEmails
-		ownerEmail, err := utils.GetEmail(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
 		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error": "invalid body",
@@ -281,7 +294,11 @@ func UpdateEmails(deckRepo *services.DeckService) gin.HandlerFunc {
 			return
 		}
 
-		deck, err := deckRepo.UpdateEmailsInDeck(c.Request.Context(), deckID, ownerEmail, body)
+		deck, err := deckRepo.UpdateEmailsInDeck(
+			c.Request.Context(),
+			deckID, c.GetString("uid"),
+			body,
+		)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -304,18 +321,6 @@ func UpdateCard(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
 		cardID := c.Param("cardID")
-		uid := c.GetString("uid")
-		email := c.GetString("email")
-
-		canAccess, err := deckRepo.CheckIfUserCanAccessDeck(
-			c.Request.Context(),
-			deckID, uid, email,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
 
 		rawData, err := c.GetRawData()
 		if errors.HandleError(c, err) {
@@ -337,7 +342,7 @@ func UpdateCard(deckRepo *services.DeckService) gin.HandlerFunc {
 }
 
 // @Summary Delete a deck along with its cards
-// @Description Deletes a deck from Firestore by its ID
+// @Description Moves a deck to the trash of its owner by its ID, it can be restored until it is purged after the retention period
 // @Tags Decks
 // @Accept json
 // @Produce json
@@ -347,27 +352,8 @@ func UpdateCard(deckRepo *services.DeckService) gin.HandlerFunc {
 func DeleteDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
-		uid, err := utils.GetUID(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		canAccess, err := deckRepo.UserOwnsDeck(
-			c.Request.Context(),
-			deckID, uid,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
 
-		ownerEmail, err := utils.GetEmail(c)
-		if errors.HandleError(c, err) {
-			return
-		}
-
-		err = deckRepo.DeleteDeck(c.Request.Context(), deckID, ownerEmail)
+		err := deckRepo.DeleteDeck(c.Request.Context(), deckID)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -377,7 +363,7 @@ func DeleteDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 }
 
 // @Summary Delete a card in a deck
-// @Description Deletes a card from a specified deck by its ID
+// @Description Moves a card to the trash of its deck by its ID, it can be restored until it is purged after the retention period
 // @Tags Decks
 // @Accept json
 // @Produce json
@@ -389,20 +375,8 @@ func DeleteCardInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
 		cardID := c.Param("cardID")
-		uid := c.GetString("uid")
-		email := c.GetString("email")
-
-		canAccess, err := deckRepo.CheckIfUserCanAccessDeck(
-			c.Request.Context(),
-			deckID, uid, email,
-		)
-
-		if !canAccess || err != nil {
-			errors.HandleError(c, errors.ErrUnauthorized)
-			return
-		}
 
-		err = deckRepo.DeleteCardInDeck(c.Request.Context(), deckID, cardID)
+		err := deckRepo.DeleteCardInDeck(c.Request.Context(), deckID, cardID)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -411,13 +385,18 @@ func DeleteCardInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 }
 
 // @Summary Get due cards in a deck for a user
-// @Description Retrieves due cards from a specified deck for a user in Firestore, prioritizing unstudied cards
+// @Description Retrieves due cards from a specified deck for a user in Firestore, prioritizing unstudied cards.
+// @Description Front/back cards reviewed in both directions are returned once per direction, marked by review_direction.
+// @Description Cards are returned as presented, so matching cards come with both sides shuffled and without their pairs.
 // @Tags Decks
 // @Accept json
 // @Produce json
 // @Param deckID path string true "Deck ID"
 // @Param limit query string false "Number of cards to retrieve" default(20)
 // @Param cursor query string false "Cursor for pagination"
+// @Param tags_any query string false "Comma separated tags, cards must have at least one of them"
+// @Param tags_all query string false "Comma separated tags, cards must have all of them"
+// @Param tags_not query string false "Comma separated tags, cards must have none of them"
 // @Success 200 {object} models.AnyCardWithPaging
 // @Router /api/v1/decks/{deckID}/cards/due [get]
 func GetDueCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
@@ -437,6 +416,7 @@ func GetDueCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 			userID,
 			limit,
 			cursor,
+			tagFilterFromQuery(c),
 		)
 		if errors.HandleError(c, err) {
 			return
@@ -456,18 +436,24 @@ func GetDueCardsInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
 // @Produce json
 // @Param deckID path string true "Deck ID"
 // @Param cardID path string true "Card ID"
+// @Param direction query string false "Direction the card is reviewed in (forward or reverse), the first one of the card when left out"
 // @Success 200 {object} models.CardProgress
 // @Router /api/v1/decks/{deckID}/cards/{cardID}/progress [get]
 func GetProgress(deckRepo *services.DeckService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deckID := c.Param("deckID")
 		cardID := c.Param("cardID")
+		direction := c.Query("direction")
 
 		userID, err := utils.GetUID(c)
 		if errors.HandleError(c, err) {
 			return
 		}
-		progress, err := deckRepo.GetCardProgress(c.Request.Context(), deckID, cardID, userID)
+		progress, err := deckRepo.GetCardProgress(
+			c.Request.Context(),
+			deckID, cardID, userID,
+			direction,
+		)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -477,7 +463,7 @@ func GetProgress(deckRepo *services.DeckService) gin.HandlerFunc {
 }
 
 // @Summary Update progress of a card for a user
-// @Description Updates progress information of a card for a user in Firestore
+// @Description Updates progress information of a card for a user in Firestore. The direction must be one the card is reviewed in, the first one when left out
 // @Tags Decks
 // @Accept json
 // @Produce json
@@ -505,17 +491,46 @@ func UpdateProgress(deckRepo *services.DeckService) gin.HandlerFunc {
 			return
 		}
 
-		// Update progress asynchronously as it does not need to wait for completion
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		// The rating is checked before answering, while the progress is written in the background
+		if err := deckRepo.UpdateCardProgress(c.Request.Context(), deckID, cardID, userID, body); errors.HandleError(c, err) {
+			return
+		}
 
-			defer cancel()
+		c.Status(http.StatusAccepted)
+	}
+}
 
-			if err := deckRepo.UpdateCardProgress(ctx, deckID, cardID, userID, body); err != nil {
-				slog.Any("Got error ", err)
-			}
-		}()
+// @Summary Grade an answer to a card
+// @Description Checks an answer to a card that can be graded.
+// @Description Numeric cards accept a number with an optional unit, such as "9.81 m/s²", converting
+// @Description equivalent units like km and m before comparing within the tolerance of the card.
+// @Description Matching cards accept matches from left to right items, giving credit for each correct match.
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Param answer body models.GradeAnswer true "Answer to the card"
+// @Success 200 {object} models.GradeResult
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/grade [post]
+func GradeCard(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		cardID := c.Param("cardID")
 
-		c.Status(http.StatusAccepted)
+		var body models.GradeAnswer
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		result, err := deckRepo.GradeCardInDeck(c.Request.Context(), deckID, cardID, body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
 	}
 }
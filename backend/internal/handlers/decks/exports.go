@@ -0,0 +1,118 @@
+package decks
+
+import (
+	"log"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Export a deck
+// @Description Exports a deck with every card, the notes generating cards and their note types, and the library description of the deck. The JSON format is a versioned document that can be imported again, while Markdown and plain text are streamed questions and answers meant for reading and printing
+// @Tags Decks
+// @Produce json,text/markdown,plain
+// @Param deckID path string true "Deck ID"
+// @Param format query string false "Export format" Enums(json, markdown, text) default(json)
+// @Param include_progress query bool false "Include the progress of the caller, only in JSON"
+// @Success 200 {object} models.DeckExport
+// @Router /api/v1/decks/{deckID}/export [get]
+func ExportDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		format := c.DefaultQuery("format", utils.EXPORT_FORMAT_JSON)
+		switch format {
+		case utils.EXPORT_FORMAT_JSON:
+		case utils.EXPORT_FORMAT_MARKDOWN, utils.EXPORT_FORMAT_TEXT:
+			streamDeckExport(c, deckRepo, deckID, format)
+			return
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid format",
+			})
+			return
+		}
+
+		export, err := deckRepo.ExportDeck(
+			c.Request.Context(),
+			deckID, uid,
+			c.Query("include_progress") == "true",
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Header("Content-Disposition", `attachment; filename="deck-`+deckID+`.json"`)
+		c.JSON(http.StatusOK, export)
+	}
+}
+
+// streamDeckExport writes a deck as Markdown or plain text while its cards are read.
+func streamDeckExport(c *gin.Context, deckRepo *services.DeckService, deckID, format string) {
+	contentType, extension := "text/plain; charset=utf-8", ".txt"
+	if format == utils.EXPORT_FORMAT_MARKDOWN {
+		contentType, extension = "text/markdown; charset=utf-8", ".md"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="deck-`+deckID+extension+`"`)
+
+	err := deckRepo.WriteDeckExport(c.Request.Context(), deckID, format, c.Writer)
+	if err == nil {
+		return
+	}
+
+	// The status can only be changed as long as nothing was sent
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		errors.HandleError(c, err)
+		return
+	}
+	log.Println("Failed streaming deck export: ", err)
+}
+
+// @Summary Import an exported deck
+// @Description Recreates an exported deck owned by the caller, with new IDs for the deck, its cards, its notes and their note types, which the caller owns as well. Exports with an unknown schema version, or with progress out of the bounds of the scheduler, are rejected and nothing is created
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param export body models.DeckExport true "Exported deck"
+// @Param include_progress query bool false "Restore the exported progress for the caller"
+// @Success 201 {object} models.ImportSummary
+// @Router /api/v1/decks/import [post]
+func ImportDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MAX_IMPORT_SIZE)
+
+		var export models.DeckExport
+		if err := c.ShouldBindBodyWithJSON(&export); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		summary, err := deckRepo.ImportDeck(
+			c.Request.Context(),
+			uid,
+			export,
+			c.Query("include_progress") == "true",
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, summary)
+	}
+}
@@ -0,0 +1,44 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Fork a deck
+// @Description Copies a deck the caller can access and all its cards into a new deck owned by the caller. The copy records the source deck and when it was forked. Note cards become front/back cards, and the progress of the caller can be carried over onto the new cards
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param options body models.ForkDeck false "Fork options"
+// @Success 201 {object} models.ImportSummary
+// @Router /api/v1/decks/{deckID}/fork [post]
+func ForkDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		// The options are optional, so an empty body forks with the defaults
+		var options models.ForkDeck
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindBodyWithJSON(&options); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "invalid body",
+				})
+				return
+			}
+		}
+
+		summary, err := deckRepo.ForkDeck(c.Request.Context(), deckID, uid, options)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, summary)
+	}
+}
@@ -0,0 +1,190 @@
+package decks
+
+import (
+	"encoding/json"
+	"io"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Import an Anki deck
+// @Description Creates a deck from an Anki .apkg package. Basic notes become front/back cards, reversed notes are reviewed in both directions and every cloze number becomes a blanks card. Media is not imported, the summary counts what was skipped by reason. A failed import leaves no deck behind
+// @Tags Decks
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Anki package (.apkg)"
+// @Param title formData string false "Title of the deck, defaults to the name of the Anki deck"
+// @Param include_progress formData bool false "Import the review history as the progress of the caller"
+// @Success 201 {object} models.ImportSummary
+// @Router /api/v1/decks/import/anki [post]
+func ImportAnkiDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MAX_IMPORT_SIZE)
+
+		var options models.AnkiImportOptions
+		if err := c.ShouldBind(&options); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		file, err := header.Open()
+		if errors.HandleError(c, err) {
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		summary, err := deckRepo.ImportAnki(
+			c.Request.Context(),
+			uid,
+			file, header.Size,
+			options,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, summary)
+	}
+}
+
+// @Summary Import cards from a CSV file
+// @Description Creates cards in a deck from the rows of a CSV or TSV file. The options map the columns to the fields of each card type, and every row is validated like a created card. Nothing is created when a row has an error or on a dry run, and the report lists the errors of every row
+// @Tags Decks
+// @Accept multipart/form-data
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param file formData file true "CSV or TSV file"
+// @Param options formData string true "Import options as JSON, see models.CSVImportOptions"
+// @Success 200 {object} models.CSVImportReport "Dry run without errors"
+// @Success 201 {object} models.CSVImportReport
+// @Failure 422 {object} models.CSVImportReport "Rows with errors"
+// @Router /api/v1/decks/{deckID}/import/csv [post]
+func ImportCSV(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MAX_IMPORT_SIZE)
+
+		var options models.CSVImportOptions
+		if err := json.Unmarshal([]byte(c.PostForm("options")), &options); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		file, err := header.Open()
+		if errors.HandleError(c, err) {
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		report, err := deckRepo.ImportCSV(c.Request.Context(), deckID, file, options)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		switch {
+		case len(report.Errors) > 0:
+			c.JSON(http.StatusUnprocessableEntity, report)
+		case report.DryRun:
+			c.JSON(http.StatusOK, report)
+		default:
+			c.JSON(http.StatusCreated, report)
+		}
+	}
+}
+
+// @Summary Import cards from Markdown notes
+// @Description Creates cards in a deck from a Markdown document: "Q:" lines followed by "A:" lines and "term :: definition" lines become front/back cards, lines with {{cloze}} spans become blanks cards. Every block is remembered by the id of an "<!-- id: name -->" comment on the line before it, or else by its question, term or cloze text, so importing the same source again updates the changed cards instead of duplicating them. Editing the text of a block without an id creates a new card, so blocks without an id are reported as warnings when missing cards are removed. Nothing is changed when a block has an error
+// @Tags Decks
+// @Accept multipart/form-data
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param file formData file true "Markdown document"
+// @Param source formData string false "Name of the document, defaults to the file name"
+// @Param remove_missing formData bool false "Delete the cards of blocks no longer in the document"
+// @Success 200 {object} models.MarkdownImportReport
+// @Failure 422 {object} models.MarkdownImportReport "Blocks with errors"
+// @Router /api/v1/decks/{deckID}/import/markdown [post]
+func ImportMarkdown(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, utils.MAX_IMPORT_SIZE)
+
+		var options models.MarkdownImportOptions
+		if err := c.ShouldBind(&options); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+		if options.Source == "" {
+			options.Source = header.Filename
+		}
+
+		file, err := header.Open()
+		if errors.HandleError(c, err) {
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		text, err := io.ReadAll(file)
+		if errors.HandleError(c, err) {
+			return
+		}
+		if !utf8.Valid(text) {
+			errors.HandleError(c, errors.ErrInvalidImport)
+			return
+		}
+
+		report, err := deckRepo.ImportMarkdown(c.Request.Context(), deckID, string(text), options)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		if len(report.Errors) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, report)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
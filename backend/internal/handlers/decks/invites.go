@@ -0,0 +1,45 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List the pending invites to a deck
+// @Description Lists the emails without a registered user the deck was shared with, they become members when they register
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {array} models.Invite
+// @Router /api/v1/decks/{deckID}/invites [get]
+func GetDeckInvites(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		invites, err := deckRepo.GetDeckInvites(c.Request.Context(), c.Param("deckID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, invites)
+	}
+}
+
+// @Summary Revoke an invite to a deck
+// @Description Deletes a pending invite, so the email does not become a member when it registers
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Param inviteID path string true "Invite ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/invites/{inviteID} [delete]
+func RevokeInvite(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := deckRepo.RevokeInvite(c.Request.Context(), c.Param("deckID"), c.Param("inviteID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
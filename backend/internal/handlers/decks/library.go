@@ -0,0 +1,127 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Browse the public library
+// @Description Lists the public decks with their listing and card count, the most studied first unless sorted by recency. Decks can be filtered by language and subject, and searched by the words of their title and description. A search reads a bounded number of decks per request, so a page may hold fewer decks than the limit while has_more is true
+// @Tags Decks
+// @Produce json
+// @Param q query string false "Words the title or description must contain"
+// @Param language query string false "Language of the cards, such as en"
+// @Param subject query string false "Subject the deck is tagged with"
+// @Param sort query string false "popular or recent" default(popular)
+// @Param limit query string false "Number of decks to retrieve, at most 100" default(20)
+// @Param cursor query string false "Cursor for pagination"
+// @Success 200 {object} models.PublicDecksResponse
+// @Router /api/v1/decks/library [get]
+func ListPublicDecks(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query models.LibraryQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid query",
+			})
+			return
+		}
+
+		decks, err := deckRepo.ListPublicDecks(c.Request.Context(), query)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, decks)
+	}
+}
+
+// @Summary Publish a deck
+// @Description Lists a deck in the public library with a description, language and subjects, making it readable by anyone. Publishing again updates the listing
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param listing body models.PublishDeck true "Listing of the deck"
+// @Success 200 {object} models.Deck
+// @Router /api/v1/decks/{deckID}/publish [put]
+func PublishDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		var listing models.PublishDeck
+		if err := c.ShouldBindBodyWithJSON(&listing); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		deck, err := deckRepo.PublishDeck(c.Request.Context(), deckID, listing)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, deck)
+	}
+}
+
+// @Summary Unpublish a deck
+// @Description Removes a deck from the public library, so only its owner and shared users can read it again
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/publish [delete]
+func UnpublishDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		if errors.HandleError(c, deckRepo.UnpublishDeck(c.Request.Context(), deckID)) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Add a deck to the study list
+// @Description Adds a public deck to the study list of the caller, listed with their decks
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/study [post]
+func AddToStudyList(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		err := deckRepo.AddToStudyList(c.Request.Context(), deckID, uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Remove a deck from the study list
+// @Description Removes a deck from the study list of the caller
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/study [delete]
+func RemoveFromStudyList(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		err := deckRepo.RemoveFromStudyList(c.Request.Context(), deckID, uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
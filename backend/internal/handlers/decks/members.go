@@ -0,0 +1,88 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List the members of a deck
+// @Description Lists the users a deck is shared with and their role: viewers can study the deck, editors can change its cards and co-owners can manage its members
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {object} models.DeckMembers
+// @Router /api/v1/decks/{deckID}/members [get]
+func GetDeckMembers(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		members, err := deckRepo.GetDeckMembers(c.Request.Context(), deckID)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, members)
+	}
+}
+
+// @Summary Change the role of a member
+// @Description Changes the role of a user the deck is shared with, only co-owners and the owner can manage members
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param member path string true "User ID or email of the member"
+// @Param role body models.UpdateMemberRole true "New role"
+// @Success 200 {object} models.DeckMember
+// @Router /api/v1/decks/{deckID}/members/{member} [put]
+func UpdateDeckMember(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		var body models.UpdateMemberRole
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		member, err := deckRepo.SetMemberRole(
+			c.Request.Context(),
+			deckID, c.Param("member"),
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, member)
+	}
+}
+
+// @Summary Remove a member from a deck
+// @Description Stops sharing a deck with a user, only co-owners and the owner can manage members
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Param member path string true "User ID or email of the member"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/members/{member} [delete]
+func RemoveDeckMember(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		err := deckRepo.RemoveMember(
+			c.Request.Context(),
+			deckID, c.Param("member"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
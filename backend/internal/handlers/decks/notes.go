@@ -0,0 +1,180 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get notes in a deck
+// @Description Retrieves the notes in a deck with cursor-based pagination
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param limit query string false "Number of notes to retrieve" default(20)
+// @Param cursor query string false "Cursor for pagination"
+// @Success 200 {object} models.NotesResponse
+// @Router /api/v1/decks/{deckID}/notes [get]
+func GetNotesInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		notes, hasMore, err := deckRepo.Notes.GetNotesInDeck(
+			c.Request.Context(),
+			deckID,
+			c.DefaultQuery("limit", "20"),
+			c.DefaultQuery("cursor", ""),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, models.NotesResponse{
+			Notes:   notes,
+			HasMore: hasMore,
+		})
+	}
+}
+
+// @Summary Get a note in a deck
+// @Description Retrieves a note along with the cards generated from it
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param noteID path string true "Note ID"
+// @Success 200 {object} models.NoteWithCards
+// @Router /api/v1/decks/{deckID}/notes/{noteID} [get]
+func GetNoteInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		note, err := deckRepo.Notes.GetNote(c.Request.Context(), deckID, c.Param("noteID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, note)
+	}
+}
+
+// @Summary Create a note in a deck
+// @Description Creates a note from a note type of the user or of the deck owner, or one already used in the deck,
+// @Description and generates a card for every template rendering a non-empty front.
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param note body models.CreateNote true "Note info"
+// @Success 201 {object} models.NoteWithCards
+// @Router /api/v1/decks/{deckID}/notes [post]
+func CreateNoteInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		var body models.CreateNote
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		note, err := deckRepo.Notes.CreateNote(c.Request.Context(), deckID, uid, body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, note)
+	}
+}
+
+// @Summary Update a note in a deck
+// @Description Replaces the fields and tags of a note, and generates its cards again.
+// @Description Cards keep their progress as long as their template still exists.
+// @Description The note type is checked as when creating a note.
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param noteID path string true "Note ID"
+// @Param note body models.UpdateNote true "Note info"
+// @Success 200 {object} models.NoteWithCards
+// @Router /api/v1/decks/{deckID}/notes/{noteID} [put]
+func UpdateNoteInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		var body models.UpdateNote
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		note, err := deckRepo.Notes.UpdateNote(
+			c.Request.Context(),
+			deckID, c.Param("noteID"), uid,
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, note)
+	}
+}
+
+// @Summary Delete a note in a deck
+// @Description Moves a note to the trash of its deck along with the cards generated from it, they can be restored until they are purged
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Param noteID path string true "Note ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/notes/{noteID} [delete]
+func DeleteNoteInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		err := deckRepo.Notes.DeleteNote(c.Request.Context(), deckID, c.Param("noteID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Restore a deleted note
+// @Description Moves a note out of the trash of its deck, along with the cards deleted with it
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param noteID path string true "Note ID"
+// @Success 200 {object} models.Note
+// @Router /api/v1/decks/{deckID}/notes/{noteID}/restore [post]
+func RestoreNoteInDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		note, err := deckRepo.Notes.RestoreNote(
+			c.Request.Context(),
+			c.Param("deckID"), c.Param("noteID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, note)
+	}
+}
@@ -0,0 +1,87 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List the revisions of a card
+// @Description Lists the fields a card had before each of its updates, the latest first, with who updated it and when. Bulk changes such as tag updates, imports, upstream pulls and note edits are listed too
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Success 200 {array} models.CardRevision
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/revisions [get]
+func GetCardRevisions(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revisions, err := deckRepo.Cards.GetCardRevisions(
+			c.Request.Context(),
+			c.Param("deckID"), c.Param("cardID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+// @Summary Compare two revisions of a card
+// @Description Lists the fields that differ between two revisions of a card, or between a revision and the card as it is now
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Param from query string true "Revision ID to compare from"
+// @Param to query string false "Revision ID to compare to, the current card by default" default(current)
+// @Success 200 {object} models.RevisionDiff
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/revisions/diff [get]
+func DiffCardRevisions(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from := c.Query("from")
+		if from == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "missing revision to compare from",
+			})
+			return
+		}
+
+		diff, err := deckRepo.Cards.DiffCardRevisions(
+			c.Request.Context(),
+			c.Param("deckID"), c.Param("cardID"),
+			from, c.Query("to"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, diff)
+	}
+}
+
+// @Summary Restore a revision of a card
+// @Description Updates a card back to the fields of one of its revisions, clearing the fields the revision did not have. The restore is checked like any update, and the fields it replaces are kept as a new revision
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Param revisionID path string true "Revision ID"
+// @Success 200 {object} models.AnyCard
+// @Router /api/v1/decks/{deckID}/cards/{cardID}/revisions/{revisionID}/restore [post]
+func RestoreCardRevision(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		card, err := deckRepo.RestoreCardRevisionInDeck(
+			c.Request.Context(),
+			c.Param("deckID"), c.Param("cardID"), c.Param("revisionID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, card)
+	}
+}
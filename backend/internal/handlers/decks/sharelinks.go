@@ -0,0 +1,99 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create a share link of a deck
+// @Description Creates a link making anyone who redeems its token a viewer or editor of the deck, until it expires or reaches its maximum number of uses. The token is only returned once
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param link body models.CreateShareLink true "Role, expiry and maximum number of uses of the link"
+// @Success 201 {object} models.CreatedShareLink
+// @Router /api/v1/decks/{deckID}/links [post]
+func CreateShareLink(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body models.CreateShareLink
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		link, err := deckRepo.CreateShareLink(
+			c.Request.Context(),
+			c.Param("deckID"), c.GetString("uid"),
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, link)
+	}
+}
+
+// @Summary List the share links of a deck
+// @Description Lists the share links of a deck with their uses, without their tokens
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {array} models.ShareLink
+// @Router /api/v1/decks/{deckID}/links [get]
+func GetShareLinks(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		links, err := deckRepo.GetShareLinks(c.Request.Context(), c.Param("deckID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, links)
+	}
+}
+
+// @Summary Revoke a share link of a deck
+// @Description Deletes a share link, users who joined through it stay members
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Param linkID path string true "Share link ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/links/{linkID} [delete]
+func RevokeShareLink(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := deckRepo.RevokeShareLink(c.Request.Context(), c.Param("deckID"), c.Param("linkID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Join a deck through a share link
+// @Description Makes the caller a member of the deck of a share link with the role of the link. Owners and members keep their role
+// @Tags Decks
+// @Produce json
+// @Param token path string true "Token of the share link"
+// @Success 200 {object} models.ShareLinkRedemption
+// @Router /api/v1/decks/join/{token} [post]
+func RedeemShareLink(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		redemption, err := deckRepo.RedeemShareLink(
+			c.Request.Context(),
+			c.Param("token"), c.GetString("uid"), c.GetString("email"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, redemption)
+	}
+}
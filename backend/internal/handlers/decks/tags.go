@@ -0,0 +1,73 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get tags in a deck
+// @Description Lists the tags used by the cards in a deck, with the number of cards having each tag
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {array} models.TagCount
+// @Router /api/v1/decks/{deckID}/tags [get]
+func GetTags(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		tags, err := deckRepo.GetTagsInDeck(c.Request.Context(), deckID)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, tags)
+	}
+}
+
+// @Summary Update tags of cards in a deck
+// @Description Adds or removes tags on the given cards, or every card in the deck if no card IDs are given.
+// @Description Rename replaces the tag "from" with "to" on every card in the deck.
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param tags body models.UpdateTags true "Tag operation"
+// @Success 200 {object} models.UpdateTagsResponse
+// @Router /api/v1/decks/{deckID}/tags [patch]
+func UpdateTags(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+
+		var body models.UpdateTags
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		updated, err := deckRepo.UpdateTagsInDeck(c.Request.Context(), deckID, body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, models.UpdateTagsResponse{
+			Updated: updated,
+		})
+	}
+}
+
+// tagFilterFromQuery reads the tag filter of a card listing from the query parameters
+func tagFilterFromQuery(c *gin.Context) models.TagFilter {
+	return models.TagFilter{
+		Any: utils.ParseTags(c.Query("tags_any")),
+		All: utils.ParseTags(c.Query("tags_all")),
+		Not: utils.ParseTags(c.Query("tags_not")),
+	}
+}
@@ -0,0 +1,72 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Offer a deck to a member
+// @Description Asks a member of the deck to take it over, replacing any pending transfer. The deck changes owner once the member accepts. Only the owner can transfer a deck
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param transfer body models.RequestTransfer true "User ID or email of the member"
+// @Success 201 {object} models.OwnershipTransfer
+// @Router /api/v1/decks/{deckID}/transfer [post]
+func RequestTransfer(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body models.RequestTransfer
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		transfer, err := deckRepo.RequestTransfer(c.Request.Context(), c.Param("deckID"), body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, transfer)
+	}
+}
+
+// @Summary Cancel or decline the transfer of a deck
+// @Description Withdraws the pending transfer of a deck, the owner can cancel it and the member it was offered to can decline it
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/transfer [delete]
+func CancelTransfer(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := deckRepo.CancelTransfer(c.Request.Context(), c.Param("deckID"), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Accept the transfer of a deck
+// @Description Makes the user the owner of a deck offered to them, the former owner stays on the deck as a co-owner
+// @Tags Decks
+// @Param deckID path string true "Deck ID"
+// @Success 204
+// @Router /api/v1/decks/{deckID}/transfer/accept [post]
+func AcceptTransfer(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := deckRepo.AcceptTransfer(c.Request.Context(), c.Param("deckID"), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,95 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary List the user's deleted decks
+// @Description Lists the decks in the trash of the user, the latest deleted first, with when each will be purged
+// @Tags Decks
+// @Produce json
+// @Success 200 {array} models.DeletedDeck
+// @Router /api/v1/decks/trash [get]
+func GetDeletedDecks(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		decks, err := deckRepo.GetDeletedDecks(c.Request.Context(), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, decks)
+	}
+}
+
+// @Summary Restore a deleted deck
+// @Description Moves a deck out of the trash of the user, with its members, cards and notes
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {object} models.Deck
+// @Router /api/v1/decks/trash/{deckID}/restore [post]
+func RestoreDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		deck, err := deckRepo.RestoreDeck(c.Request.Context(), c.Param("deckID"), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, deck)
+	}
+}
+
+// @Summary List the deleted cards of a deck
+// @Description Lists the cards and notes in the trash of a deck, the latest deleted first, with when each will be purged
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Success 200 {array} models.DeletedCard
+// @Router /api/v1/decks/{deckID}/trash [get]
+func GetDeletedCards(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cards, err := deckRepo.Cards.GetDeletedCards(c.Request.Context(), c.Param("deckID"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, cards)
+	}
+}
+
+// @Summary Restore a deleted card
+// @Description Moves a card out of the trash of a deck, with its revisions. Cards deleted along with their note come back by restoring the note
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param cardID path string true "Card ID"
+// @Success 200 {object} models.AnyCard
+// @Router /api/v1/decks/{deckID}/trash/{cardID}/restore [post]
+func RestoreCard(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		card, err := deckRepo.Cards.RestoreCard(
+			c.Request.Context(),
+			c.Param("deckID"), c.Param("cardID"),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, card)
+	}
+}
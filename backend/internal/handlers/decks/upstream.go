@@ -0,0 +1,96 @@
+package decks
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Subscribe to a deck
+// @Description Creates a linked copy of a deck the caller can access, owned by the caller. The copy records its source deck and sync point, so later changes of the source can be reviewed and pulled in
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID"
+// @Param options body models.ForkDeck false "Copy options"
+// @Success 201 {object} models.ImportSummary
+// @Router /api/v1/decks/{deckID}/subscribe [post]
+func SubscribeToDeck(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		// The options are optional, so an empty body subscribes with the defaults
+		var options models.ForkDeck
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindBodyWithJSON(&options); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "invalid body",
+				})
+				return
+			}
+		}
+
+		summary, err := deckRepo.SubscribeToDeck(c.Request.Context(), deckID, uid, options)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, summary)
+	}
+}
+
+// @Summary Get upstream changes
+// @Description Lists the cards added, modified and removed in the source deck of a linked copy since the last sync. Changes to cards also edited in the copy are flagged as conflicts, and cards deleted from the copy are offered again
+// @Tags Decks
+// @Produce json
+// @Param deckID path string true "Deck ID of the linked copy"
+// @Success 200 {object} models.UpstreamChanges
+// @Router /api/v1/decks/{deckID}/upstream/changes [get]
+func GetUpstreamChanges(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		changes, err := deckRepo.GetUpstreamChanges(c.Request.Context(), deckID, uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, changes)
+	}
+}
+
+// @Summary Pull upstream changes
+// @Description Applies the selected changes of the source deck to a linked copy, keeping the tags of the local cards. Conflicting changes are left out unless overwrite is set, which replaces the local edits
+// @Tags Decks
+// @Accept json
+// @Produce json
+// @Param deckID path string true "Deck ID of the linked copy"
+// @Param pull body models.UpstreamPull true "Changes to pull"
+// @Success 200 {object} models.UpstreamPullResult
+// @Router /api/v1/decks/{deckID}/upstream/pull [post]
+func PullUpstreamChanges(deckRepo *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deckID := c.Param("deckID")
+		uid := c.GetString("uid")
+
+		var pull models.UpstreamPull
+		if err := c.ShouldBindBodyWithJSON(&pull); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		result, err := deckRepo.PullUpstreamChanges(c.Request.Context(), deckID, uid, pull)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,188 @@
+package notetypes
+
+import (
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get the user's note types
+// @Description Lists the note types owned by the user, ordered by name
+// @Tags Note types
+// @Produce json
+// @Success 200 {array} models.NoteType
+// @Router /api/v1/note-types [get]
+func GetNoteTypes(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		noteTypes, err := noteRepo.GetNoteTypes(c.Request.Context(), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, noteTypes)
+	}
+}
+
+// @Summary Get a note type
+// @Description Retrieves a note type owned by the user
+// @Tags Note types
+// @Produce json
+// @Param noteTypeID path string true "Note type ID"
+// @Success 200 {object} models.NoteType
+// @Router /api/v1/note-types/{noteTypeID} [get]
+func GetNoteType(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		noteType, err := noteRepo.GetNoteType(c.Request.Context(), c.Param("noteTypeID"), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, noteType)
+	}
+}
+
+// @Summary Create a note type
+// @Description Creates a note type with named fields and one or more card templates.
+// @Description Templates use {{Field}} to insert a field, and the back can use {{FrontSide}}.
+// @Tags Note types
+// @Accept json
+// @Produce json
+// @Param noteType body models.CreateNoteType true "Note type info"
+// @Success 201 {object} models.ReturnID
+// @Router /api/v1/note-types [post]
+func CreateNoteType(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		var body models.CreateNoteType
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		id, err := noteRepo.CreateNoteType(c.Request.Context(), uid, body)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, models.ReturnID{
+			ID: id,
+		})
+	}
+}
+
+// @Summary Update a note type
+// @Description Replaces the name, fields and templates of a note type.
+// @Description The cards of the notes using the note type are generated again, in the decks the owner can still edit.
+// @Tags Note types
+// @Accept json
+// @Produce json
+// @Param noteTypeID path string true "Note type ID"
+// @Param noteType body models.CreateNoteType true "Note type info"
+// @Success 200 {object} models.NoteType
+// @Router /api/v1/note-types/{noteTypeID} [put]
+func UpdateNoteType(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		var body models.CreateNoteType
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		noteType, err := noteRepo.UpdateNoteType(
+			c.Request.Context(),
+			c.Param("noteTypeID"), uid,
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, noteType)
+	}
+}
+
+// @Summary Delete a note type
+// @Description Deletes a note type, only allowed when no note uses it
+// @Tags Note types
+// @Param noteTypeID path string true "Note type ID"
+// @Success 204
+// @Router /api/v1/note-types/{noteTypeID} [delete]
+func DeleteNoteType(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		err = noteRepo.DeleteNoteType(c.Request.Context(), c.Param("noteTypeID"), uid)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Preview the cards of a note
+// @Description Renders the cards a note with the given fields would generate, without saving it
+// @Tags Note types
+// @Accept json
+// @Produce json
+// @Param noteTypeID path string true "Note type ID"
+// @Param note body models.PreviewNote true "Note fields"
+// @Success 200 {array} models.NoteCard
+// @Router /api/v1/note-types/{noteTypeID}/preview [post]
+func PreviewNoteType(noteRepo *services.NoteService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := utils.GetUID(c)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		var body models.PreviewNote
+		if err := c.ShouldBindBodyWithJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid body",
+			})
+			return
+		}
+
+		cards, err := noteRepo.PreviewNoteType(
+			c.Request.Context(),
+			c.Param("noteTypeID"), uid,
+			body,
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, cards)
+	}
+}
@@ -0,0 +1,26 @@
+package users
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary GET the assignments of a user
+// @Description Return the decks assigned in the classes the user is enrolled in, the earliest due first, with the progress of the user on them. Decks in the trash are left out
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.StudentAssignment
+// @Router /api/v1/users/assignments [get]
+func GetAssignments(classRepo *services.ClassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assignments, err := classRepo.GetStudentAssignments(c.Request.Context(), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, assignments)
+	}
+}
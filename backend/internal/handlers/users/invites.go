@@ -0,0 +1,60 @@
+package users
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"memora/internal/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary GET the pending invites of a user
+// @Description Return the invites to decks sent to the email of the user
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.Invite
+// @Router /api/v1/users/invites [get]
+func GetInvites(userRepo *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, err := utils.GetEmail(c)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		invites, err := userRepo.GetInvites(c.Request.Context(), email)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, invites)
+	}
+}
+
+// @Summary Accept an invite to a deck
+// @Description Makes the user a member of the deck with the invited role. The email of the account must be verified. Invites are accepted when registering as well
+// @Tags Users
+// @Param inviteID path string true "Invite ID"
+// @Success 204
+// @Router /api/v1/users/invites/{inviteID}/accept [post]
+func AcceptInvite(userRepo *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email, err := utils.GetEmail(c)
+		if err != nil {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+
+		err = userRepo.AcceptInvite(
+			c.Request.Context(),
+			c.Param("inviteID"), c.GetString("uid"), email,
+			utils.EmailVerified(c),
+		)
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,26 @@
+package users
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary GET the decks offered to a user
+// @Description Return the decks the user was asked to take over
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.TransferOffer
+// @Router /api/v1/users/transfers [get]
+func GetTransferOffers(userRepo *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		offers, err := userRepo.GetTransferOffers(c.Request.Context(), c.GetString("uid"))
+		if errors.HandleError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusOK, offers)
+	}
+}
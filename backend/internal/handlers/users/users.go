@@ -51,13 +51,7 @@ func GetDecks(userRepo *services.UserService) gin.HandlerFunc {
 			c.Status(http.StatusUnauthorized)
 			return
 		}
-		email, err := utils.GetEmail(c)
-		if err != nil {
-			c.Status(http.StatusUnauthorized)
-			return
-		}
-
-		decks, err := userRepo.GetDecks(c.Request.Context(), id, filter, email)
+		decks, err := userRepo.GetDecks(c.Request.Context(), id, filter)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -67,7 +61,7 @@ func GetDecks(userRepo *services.UserService) gin.HandlerFunc {
 }
 
 // @Summary Create a user and return their ID
-// @Description Creates a new user
+// @Description Creates a new user. The pending invites of their email become memberships once the email is verified
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -88,7 +82,7 @@ func CreateUser(userRepo *services.UserService) gin.HandlerFunc {
 		content.Email = c.GetString("email")
 		uid := c.GetString("uid")
 
-		if err := userRepo.RegisterNewUser(c.Request.Context(), content, uid); err != nil {
+		if err := userRepo.RegisterNewUser(c.Request.Context(), content, uid, utils.EmailVerified(c)); err != nil {
 			errors.HandleError(c, err)
 			return
 		}
@@ -100,7 +94,7 @@ func CreateUser(userRepo *services.UserService) gin.HandlerFunc {
 }
 
 // @Summary Update a user in firestore
-// @Description Return updated user information
+// @Description Return updated user information. The email can only be set to the verified email of the signed-in account
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -124,7 +118,12 @@ func PatchUser(userRepo *services.UserService) gin.HandlerFunc {
 			return
 		}
 
-		user, err := userRepo.UpdateUser(c.Request.Context(), updates, id)
+		// The email can only be changed to the verified one of the signed-in account
+		user, err := userRepo.UpdateUser(
+			c.Request.Context(),
+			updates, id, c.GetString("email"),
+			utils.EmailVerified(c),
+		)
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -134,10 +133,11 @@ func PatchUser(userRepo *services.UserService) gin.HandlerFunc {
 }
 
 // @Summary Deletes a user from firestore
-// @Description Return card information
+// @Description Deletes the user with the decks they own and the classes they teach, and removes them from the classes they are enrolled in. Decks the member given in transfer_to belongs to are handed over to that member instead. Note types still used by notes in decks of other users are kept
 // @Tags Users
 // @Accept json
 // @Produce json
+// @Param transfer_to query string false "User ID or email of the member to hand decks over to"
 // @Success 204
 // @Router /api/v1/users [delete]
 func DeleteUser(userRepo *services.UserService) gin.HandlerFunc {
@@ -148,7 +148,7 @@ func DeleteUser(userRepo *services.UserService) gin.HandlerFunc {
 			return
 		}
 
-		err = userRepo.DeleteUser(c.Request.Context(), id)
+		err = userRepo.DeleteUser(c.Request.Context(), id, c.Query("transfer_to"))
 		if errors.HandleError(c, err) {
 			return
 		}
@@ -8,6 +8,7 @@ import (
 	"memora/internal/firebase"
 	"memora/internal/router"
 	"memora/internal/services"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -53,6 +54,34 @@ func PerformRequest(
 	return w
 }
 
+// PerformMultipartRequest sends the form fields and a file as multipart form data.
+func PerformMultipartRequest(
+	r *gin.Engine,
+	method, path string,
+	fields map[string]string,
+	fileName string,
+	file []byte,
+	token string,
+) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		_ = writer.WriteField(name, value)
+	}
+	part, _ := writer.CreateFormFile("file", fileName)
+	_, _ = part.Write(file)
+	_ = writer.Close()
+
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("content-type", writer.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
 func CreateTestUser1(t *testing.T) string {
 	url := "http://127.0.0.1:9099/identitytoolkit.googleapis.com/v1/accounts:signUp?key=any"
 	payload := map[string]string{
@@ -108,3 +137,31 @@ func CreateTestUser2(t *testing.T) string {
 
 	return idToken
 }
+
+// CreateTestUserWithEmail signs up an emulator account with an email it has not verified.
+func CreateTestUserWithEmail(t *testing.T, email string) string {
+	url := "http://127.0.0.1:9099/identitytoolkit.googleapis.com/v1/accounts:signUp?key=any"
+	payload := map[string]string{
+		"email":             email,
+		"password":          "verysecurepassword",
+		"returnSecureToken": "true",
+	}
+	body, _ := json.Marshal(payload)
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var result map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	idToken, ok := result["idToken"].(string)
+	if !ok || idToken == "" {
+		t.Fatal("failed to get idToken from emulator")
+	}
+
+	return idToken
+}
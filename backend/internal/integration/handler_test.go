@@ -1,9 +1,13 @@
 package integration_test
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandlers(t *testing.T) {
@@ -247,12 +251,147 @@ func TestHandlers(t *testing.T) {
 			t.Errorf("Expected status code 400, got %d", w.Code)
 		}
 		resp := w.Body.String()
-		expected := `{"error":"email not registered"}`
+		expected := `{"error":"invalid deck, missing fields"}`
 		if resp != expected {
 			t.Errorf("Expected response body %q, got %q", expected, resp)
 		}
 	})
 
+	t.Run("Invite an email without a registered user", func(t *testing.T) {
+		body := `{
+			"opp": "add",
+			"shared_emails": ["new@user.com"],
+			"role": "viewer"
+		}`
+		w := PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/emails", strings.NewReader(body), token1)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, "new@user.com") {
+			t.Errorf("Expected the email to be invited rather than shared, got %d %q", w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/invites", nil, token1)
+		var invites []struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+			Role  string `json:"role"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &invites); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(invites) != 1 || invites[0].Email != "new@user.com" || invites[0].Role != "viewer" {
+			t.Fatalf("Expected one invite of new@user.com as a viewer, got %+v", invites)
+		}
+
+		// Only the invited email can accept the invite
+		w = PerformRequest(r, "POST", "/api/v1/users/invites/"+invites[0].ID+"/accept", nil, token2)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400 for another email, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deckID+"/invites/"+invites[0].ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/invites", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || resp != "[]" {
+			t.Errorf("Expected no invites left, got %d %q", w.Code, resp)
+		}
+	})
+
+	t.Run("Claim no invite by changing to an unverified email", func(t *testing.T) {
+		body := `{
+			"opp": "add",
+			"shared_emails": ["teacher@school.com"],
+			"role": "co_owner"
+		}`
+		w := PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/emails", strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		// The email is not the one of the signed-in account, so it is rejected
+		body = `{"email": "teacher@school.com"}`
+		w = PerformRequest(r, "PATCH", "/api/v1/users/", strings.NewReader(body), token2)
+		if w.Code != 403 {
+			t.Errorf("Expected status code 403, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/users/", nil, token2)
+		expectedSubstring := `"email":"` + email + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// The invite is still pending
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/invites", nil, token1)
+		var invites []struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &invites); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(invites) != 1 || invites[0].Email != "teacher@school.com" {
+			t.Fatalf("Expected the invite of teacher@school.com to be pending, got %+v", invites)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deckID+"/invites/"+invites[0].ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Claim no invite by signing up with an unverified invited email", func(t *testing.T) {
+		body := `{
+			"opp": "add",
+			"shared_emails": ["invited@user.com"],
+			"role": "editor"
+		}`
+		w := PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/emails", strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		// The account has the invited email, but has not verified it
+		token3 := CreateTestUserWithEmail(t, "invited@user.com")
+		w = PerformRequest(r, "POST", "/api/v1/users/", strings.NewReader(`{"name": "Invited"}`), token3)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/invites", nil, token1)
+		var invites []struct {
+			ID    string `json:"id"`
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &invites); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(invites) != 1 || invites[0].Email != "invited@user.com" {
+			t.Fatalf("Expected the invite of invited@user.com to be pending, got %+v", invites)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/users/invites/"+invites[0].ID+"/accept", nil, token3)
+		if w.Code != 403 {
+			t.Errorf("Expected status code 403, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID, nil, token3)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 without access to the deck, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deckID+"/invites/"+invites[0].ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/users/", nil, token3)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
 	t.Run("Add cards to the created deck", func(t *testing.T) {
 		body := `{
 			"type": "front_back",
@@ -278,6 +417,156 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("Add card reviewed in both directions", func(t *testing.T) {
+		body := `{
+			"type": "front_back",
+			"front": "Hund",
+			"back": "Dog",
+			"direction": "both"
+		}`
+
+		w := PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/cards/",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/cards/due", nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		resp := w.Body.String()
+		for _, expectedSubstring := range []string{
+			`"review_direction":"forward"`,
+			`"review_direction":"reverse"`,
+		} {
+			if !strings.Contains(resp, expectedSubstring) {
+				t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+			}
+		}
+	})
+
+	t.Run("Rate only the directions a card is reviewed in", func(t *testing.T) {
+		body := `{"type": "front_back", "front": "Katze", "back": "Cat"}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		progressPath := "/api/v1/decks/" + deckID + "/cards/" + card.ID + "/progress/"
+
+		body = `{"rating": "good", "direction": "reverse"}`
+		w = PerformRequest(r, "PUT", progressPath, strings.NewReader(body), token1)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400 for a card only reviewed forward, got %d", w.Code)
+		}
+
+		body = `{"rating": "good"}`
+		w = PerformRequest(r, "PUT", progressPath, strings.NewReader(body), token1)
+		if w.Code != 202 {
+			t.Errorf("Expected status code 202, got %d", w.Code)
+		}
+	})
+
+	t.Run("Read the progress of the direction a card is rated in by default", func(t *testing.T) {
+		body := `{"type": "front_back", "front": "Hund", "back": "Dog", "direction": "reverse"}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		progressPath := "/api/v1/decks/" + deckID + "/cards/" + card.ID + "/progress/"
+
+		body = `{"rating": "good"}`
+		w = PerformRequest(r, "PUT", progressPath, strings.NewReader(body), token1)
+		if w.Code != 202 {
+			t.Fatalf("Expected status code 202, got %d", w.Code)
+		}
+
+		// The progress is written in the background
+		var progress struct {
+			Reps int `json:"reps"`
+		}
+		for range 20 {
+			w = PerformRequest(r, "GET", progressPath, nil, token1)
+			if w.Code == 200 {
+				if err := json.Unmarshal(w.Body.Bytes(), &progress); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if progress.Reps != 1 {
+			t.Errorf("Expected the reverse progress to be read without a direction, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Tag every card in the deck", func(t *testing.T) {
+		body := `{
+			"opp": "add",
+			"tags": ["vocab"]
+		}`
+
+		w := PerformRequest(
+			r,
+			"PATCH",
+			"/api/v1/decks/"+deckID+"/tags",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/tags", nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		resp := w.Body.String()
+		expectedSubstring := `"tag":"vocab"`
+		if !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+	})
+
+	t.Run("Add card with invalid direction", func(t *testing.T) {
+		body := `{
+			"type": "front_back",
+			"front": "Katt",
+			"back": "Cat",
+			"direction": "sideways"
+		}`
+
+		w := PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/cards/",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
 	t.Run("Add multiple choice card to the created deck", func(t *testing.T) {
 		body := `{
 			"type": "multiple_choice",
@@ -310,6 +599,1176 @@ func TestHandlers(t *testing.T) {
 		}
 	})
 
+	t.Run("Grade a numeric card answered with another prefix", func(t *testing.T) {
+		body := `{
+			"type": "numeric",
+			"question": "How far is the finish line?",
+			"answer": 1.5,
+			"unit": "km",
+			"tolerance": 0.01,
+			"tolerance_mode": "relative"
+		}`
+
+		w := PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/cards/",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		w = PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/cards/"+card.ID+"/grade",
+			strings.NewReader(`{"answer": "1505 m"}`),
+			token1,
+		)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		resp := w.Body.String()
+		expectedSubstring := `"correct":true`
+		if !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+	})
+
+	t.Run("Present a matching card with both sides shuffled", func(t *testing.T) {
+		body := `{
+			"type": "matching",
+			"question": "Match each country to its capital",
+			"pairs": [
+				{"left": "France", "right": "Paris"},
+				{"left": "Spain", "right": "Madrid"},
+				{"left": "Peru", "right": "Lima"},
+				{"left": "Japan", "right": "Tokyo"}
+			]
+		}`
+
+		w := PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/cards/",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		w = PerformRequest(
+			r,
+			"GET",
+			"/api/v1/decks/"+deckID+"/cards/"+card.ID+"/presentation",
+			nil,
+			token2,
+		)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		var presentation struct {
+			ID    string           `json:"id"`
+			Left  []string         `json:"left"`
+			Right []string         `json:"right"`
+			Pairs []map[string]any `json:"pairs"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &presentation); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if presentation.ID != card.ID {
+			t.Errorf("Expected card %q, got %q", card.ID, presentation.ID)
+		}
+		// The pairs would give away the matches
+		if presentation.Pairs != nil {
+			t.Errorf("Expected no pairs in the presentation, got %v", presentation.Pairs)
+		}
+
+		left := strings.Join(slices.Sorted(slices.Values(presentation.Left)), ",")
+		right := strings.Join(slices.Sorted(slices.Values(presentation.Right)), ",")
+		if left != "France,Japan,Peru,Spain" || right != "Lima,Madrid,Paris,Tokyo" {
+			t.Errorf("Expected every item of both sides, got %v and %v", presentation.Left, presentation.Right)
+		}
+
+		// Due cards are served presented as well
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/cards/due?limit=100", nil, token2)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		type dueCard struct {
+			ID    string           `json:"id"`
+			Left  []string         `json:"left"`
+			Pairs []map[string]any `json:"pairs"`
+		}
+		var due struct {
+			Cards []dueCard `json:"cards"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &due); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		i := slices.IndexFunc(due.Cards, func(c dueCard) bool {
+			return c.ID == card.ID
+		})
+		if i < 0 || due.Cards[i].Pairs != nil || len(due.Cards[i].Left) != 4 {
+			t.Errorf("Expected the due matching card to be presented, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Add a note generating a card per template", func(t *testing.T) {
+		body := `{
+			"name": "Vocabulary",
+			"fields": ["Word", "Reading", "Meaning"],
+			"templates": [
+				{"name": "Recognition", "front": "{{Word}}", "back": "{{Reading}}: {{Meaning}}"},
+				{"name": "Recall", "front": "{{Meaning}}", "back": "{{FrontSide}} = {{Word}}"}
+			]
+		}`
+
+		w := PerformRequest(r, "POST", "/api/v1/note-types/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		var noteType struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &noteType); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		body = `{
+			"note_type_id": "` + noteType.ID + `",
+			"fields": {"Word": "猫", "Reading": "ねこ", "Meaning": "cat"}
+		}`
+
+		w = PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/notes/",
+			strings.NewReader(body),
+			token1,
+		)
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+
+		resp := w.Body.String()
+		for _, expectedSubstring := range []string{
+			`"front":"猫","back":"ねこ: cat"`,
+			`"front":"cat","back":"cat = 猫"`,
+		} {
+			if !strings.Contains(resp, expectedSubstring) {
+				t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+			}
+		}
+
+		// Editors use the note types of the deck owner, both to create and update notes
+		w = PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/notes/",
+			strings.NewReader(`{"note_type_id": "`+noteType.ID+`", "fields": {"Word": "犬", "Meaning": "dog"}}`),
+			token2,
+		)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201 for an editor, got %d", w.Code)
+		}
+		var note struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &note); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		w = PerformRequest(
+			r,
+			"PUT",
+			"/api/v1/decks/"+deckID+"/notes/"+note.ID,
+			strings.NewReader(`{"fields": {"Word": "犬", "Reading": "いぬ", "Meaning": "dog"}}`),
+			token2,
+		)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for an editor, got %d", w.Code)
+		}
+
+		// The note types of other users are not used in the deck
+		otherBody := `{
+			"name": "Birds",
+			"fields": ["Word"],
+			"templates": [{"name": "Card", "front": "{{Word}}", "back": "{{Word}}"}]
+		}`
+		w = PerformRequest(r, "POST", "/api/v1/note-types/", strings.NewReader(otherBody), token2)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var otherType struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &otherType); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		w = PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/notes/",
+			strings.NewReader(`{"note_type_id": "`+otherType.ID+`", "fields": {"Word": "鳥"}}`),
+			token1,
+		)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for a note type of another user, got %d", w.Code)
+		}
+
+		// Note types in use can not be deleted
+		w = PerformRequest(r, "DELETE", "/api/v1/note-types/"+noteType.ID, nil, token1)
+		if w.Code != 409 {
+			t.Errorf("Expected status code 409, got %d", w.Code)
+		}
+	})
+
+	t.Run("Import cards from a CSV file", func(t *testing.T) {
+		csv := "Word;Translation;Tags\n" +
+			"perro;dog;animals|nouns\n" +
+			"gato;;animals\n"
+		options := `{
+			"delimiter": ";",
+			"has_header": true,
+			"mappings": {"front_back": {"front": "Word", "back": "Translation", "tags": "Tags"}}
+		}`
+
+		// The second row has no translation, so nothing is imported
+		w := PerformMultipartRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/import/csv",
+			map[string]string{"options": options},
+			"words.csv",
+			[]byte(csv),
+			token1,
+		)
+		if w.Code != 422 {
+			t.Errorf("Expected status code 422, got %d", w.Code)
+		}
+
+		expectedSubstring := `{"row":3,"error":"back failed on required (column Translation)"}`
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+
+		csv = strings.Replace(csv, "gato;;", "gato;cat;", 1)
+		w = PerformMultipartRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/import/csv",
+			map[string]string{"options": options},
+			"words.csv",
+			[]byte(csv),
+			token1,
+		)
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+
+		expectedSubstring = `"rows":2,"imported":2`
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+	})
+
+	t.Run("Import cards from Markdown notes", func(t *testing.T) {
+		notes := "# Animals\n\n" +
+			"Q: What does a cow say?\n" +
+			"A: Moo\n\n" +
+			"- caballo :: horse\n" +
+			"- A {{spider}} has eight legs\n"
+		fields := map[string]string{"source": "animals.md"}
+
+		w := PerformMultipartRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/import/markdown",
+			fields,
+			"notes.md",
+			[]byte(notes),
+			token1,
+		)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		expectedSubstring := `"created":3,"updated":0,"unchanged":0`
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+
+		// Importing the changed notes again updates the card instead of adding one
+		notes = strings.Replace(notes, ":: horse", ":: horse, the animal", 1)
+		w = PerformMultipartRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/import/markdown",
+			fields,
+			"notes.md",
+			[]byte(notes),
+			token1,
+		)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		expectedSubstring = `"created":0,"updated":1,"unchanged":2`
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+
+		w = PerformMultipartRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+deckID+"/import/markdown",
+			fields,
+			"notes.md",
+			[]byte("Q: A question without an answer\n"),
+			token1,
+		)
+		if w.Code != 422 {
+			t.Errorf("Expected status code 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("Export a deck and import it again", func(t *testing.T) {
+		w := PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/export", nil, token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		var export struct {
+			SchemaVersion int               `json:"schema_version"`
+			Cards         []json.RawMessage `json:"cards"`
+			NoteTypes     []json.RawMessage `json:"note_types"`
+			Notes         []json.RawMessage `json:"notes"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if export.SchemaVersion != 1 || len(export.Cards) == 0 || len(export.Notes) == 0 || len(export.NoteTypes) == 0 {
+			t.Fatalf("Unexpected export %s", w.Body.String())
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/import", bytes.NewReader(w.Body.Bytes()), token1)
+		if w.Code != 201 {
+			t.Errorf("Expected status code 201, got %d", w.Code)
+		}
+
+		expectedSubstring := fmt.Sprintf(`"cards":%d`, len(export.Cards))
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+
+		// Notes come back as notes, generating their cards
+		var summary struct {
+			DeckID string `json:"deck_id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+summary.DeckID+"/notes/", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, `"note_type_id"`) {
+			t.Errorf("Expected the imported deck to have notes, got %d %q", w.Code, resp)
+		}
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+summary.DeckID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		// Progress out of the bounds of the scheduler is rejected
+		body := `{"schema_version": 1, "deck": {"title": "Bad progress"}, "cards": [],
+			"progress": [{"card_id": "a", "direction": "forward", "ease_factor": 99999, "interval": -1, "due": "2026-01-01T00:00:00Z"}]}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/import?include_progress=true", strings.NewReader(body), token1)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/export?format=markdown", nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+		if resp := w.Body.String(); !strings.HasPrefix(resp, "# ") || !strings.Contains(resp, "**Q:** ") {
+			t.Errorf("Expected a markdown export, got %q", resp)
+		}
+
+		// Exports from a newer version are rejected
+		body = `{"schema_version": 2, "deck": {"title": "Future"}, "cards": []}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/import", strings.NewReader(body), token1)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Fork a shared deck", func(t *testing.T) {
+		body := `{"title": "My copy", "include_progress": true}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/fork", strings.NewReader(body), token2)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		var summary struct {
+			DeckID string `json:"deck_id"`
+			Cards  int    `json:"cards"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if summary.Cards == 0 {
+			t.Errorf("Expected the cards to be copied, got %s", w.Body.String())
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+summary.DeckID, nil, token2)
+		expectedSubstring := `"forked_from":"` + deckID + `"`
+		if resp := w.Body.String(); !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+summary.DeckID, nil, token2)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Subscribe to a deck and pull its changes", func(t *testing.T) {
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/subscribe", nil, token2)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		var summary struct {
+			DeckID string `json:"deck_id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		changesPath := "/api/v1/decks/" + summary.DeckID + "/upstream/changes"
+
+		w = PerformRequest(r, "GET", changesPath, nil, token2)
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, `"changes":[]`) {
+			t.Errorf("Expected no changes, got %d %q", w.Code, resp)
+		}
+
+		body := `{"type": "front_back", "front": "Added upstream", "back": "Pulled"}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", changesPath, nil, token2)
+		var changes struct {
+			Changes []struct {
+				CardID string `json:"card_id"`
+				Status string `json:"status"`
+			} `json:"changes"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &changes); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(changes.Changes) != 1 || changes.Changes[0].Status != "added" {
+			t.Fatalf("Expected one added card, got %s", w.Body.String())
+		}
+
+		body = `{"card_ids": ["` + changes.Changes[0].CardID + `"]}`
+		w = PerformRequest(
+			r,
+			"POST",
+			"/api/v1/decks/"+summary.DeckID+"/upstream/pull",
+			strings.NewReader(body),
+			token2,
+		)
+		expectedSubstring := `"added":1`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", changesPath, nil, token2)
+		if resp := w.Body.String(); !strings.Contains(resp, `"changes":[]`) {
+			t.Errorf("Expected no changes after pulling, got %q", resp)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+summary.DeckID, nil, token2)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Publish a deck to the public library", func(t *testing.T) {
+		body := `{"description": "Words for animals", "language": "es", "subjects": ["Spanish", "animals"]}`
+		w := PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/publish", strings.NewReader(body), token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for a shared user, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/publish", strings.NewReader(body), token1)
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, `"public":true`) {
+			t.Errorf("Expected a public deck, got %d %q", w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/library?language=es&q=animals", nil, token2)
+		expectedSubstring := `"id":"` + deckID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/study", nil, token2)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/users/decks", nil, token2)
+		if resp := w.Body.String(); !strings.Contains(resp, `"study_decks":[{"id":"`+deckID+`"`) {
+			t.Errorf("Expected the deck in the study list, got %q", resp)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deckID+"/study", nil, token2)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deckID+"/publish", nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Change the role of a deck member", func(t *testing.T) {
+		w := PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/members", nil, token2)
+		expectedSubstring := `"email":"` + email + `","role":"editor"}`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		body := `{"role": "viewer"}`
+		w = PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/members/"+email, strings.NewReader(body), token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for an editor, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/members/"+email, strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		// Viewers can study the deck but not change its cards
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/cards/", nil, token2)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for a viewer, got %d", w.Code)
+		}
+
+		card := `{"type": "front_back", "front": "el perro", "back": "the dog"}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(card), token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for a viewer, got %d", w.Code)
+		}
+
+		// Members are found by their email in any case
+		body = `{"role": "editor"}`
+		w = PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/members/"+strings.ToUpper(email), strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("Share a deck with an email in another case", func(t *testing.T) {
+		remove := `{"opp": "remove", "shared_emails": ["` + email + `"]}`
+		w := PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/emails", strings.NewReader(remove), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		add := `{"opp": "add", "shared_emails": ["` + strings.ToUpper(email) + `"]}`
+		w = PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/emails", strings.NewReader(add), token1)
+		expectedSubstring := `"shared_emails":["` + email + `"]`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/cards/", nil, token2)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for the member, got %d", w.Code)
+		}
+	})
+
+	t.Run("Transfer a deck to a member", func(t *testing.T) {
+		body := `{"member": "` + email + `"}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer", strings.NewReader(body), token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for a member, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/users/transfers", nil, token2)
+		expectedSubstring := `"deck_id":"` + deckID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Only the member the deck was offered to can accept it
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer/accept", nil, token1)
+		if w.Code != 404 {
+			t.Errorf("Expected status code 404 for the owner, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer/accept", nil, token2)
+		if w.Code != 204 {
+			t.Fatalf("Expected status code 204, got %d", w.Code)
+		}
+
+		// The former owner is a co-owner now, so hand the deck back
+		body = `{"member": "test@user.com"}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer", strings.NewReader(body), token2)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/transfer/accept", nil, token1)
+		if w.Code != 204 {
+			t.Fatalf("Expected status code 204, got %d", w.Code)
+		}
+
+		body = `{"role": "editor"}`
+		w = PerformRequest(r, "PUT", "/api/v1/decks/"+deckID+"/members/"+email, strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("Restore a revision of a card", func(t *testing.T) {
+		body := `{"type": "front_back", "front": "la mer", "back": "the sea"}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		cardPath := "/api/v1/decks/" + deckID + "/cards/" + card.ID
+
+		body = `{"type": "front_back", "front": "la mer", "back": "the ocean", "tags": ["ocean"]}`
+		w = PerformRequest(r, "PUT", cardPath, strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", cardPath+"/revisions", nil, token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		var revisions []struct {
+			ID     string         `json:"id"`
+			Fields map[string]any `json:"fields"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &revisions); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(revisions) != 1 || revisions[0].Fields["back"] != "the sea" {
+			t.Fatalf("Expected the revision before the update, got %v", revisions)
+		}
+
+		w = PerformRequest(r, "GET", cardPath+"/revisions/diff?from="+revisions[0].ID, nil, token1)
+		expectedSubstring := `{"field":"back","old":"the sea","new":"the ocean"}`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Fields empty in the revision are cleared by the restore
+		w = PerformRequest(r, "POST", cardPath+"/revisions/"+revisions[0].ID+"/restore", nil, token1)
+		expectedSubstring = `"back":"the sea"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) || strings.Contains(resp, "ocean") {
+			t.Errorf("Expected response body to contain %q without the tags, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// The restore is kept as a revision too
+		w = PerformRequest(r, "GET", cardPath+"/revisions", nil, token1)
+		expectedSubstring = `"restored_from":"` + revisions[0].ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Bulk changes such as tag updates are kept as revisions as well
+		body = `{"card_ids": ["` + card.ID + `"], "opp": "add", "tags": ["sea"]}`
+		w = PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID+"/tags", strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", cardPath+"/revisions", nil, token1)
+		if err := json.Unmarshal(w.Body.Bytes(), &revisions); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(revisions) != 3 || revisions[0].Fields["tags"] != nil {
+			t.Errorf("Expected the revision before the tag update, got %v", revisions)
+		}
+	})
+
+	t.Run("Read the audit log of a deck", func(t *testing.T) {
+		body := `{"title": "Audited Deck"}`
+		w := PerformRequest(r, "PATCH", "/api/v1/decks/"+deckID, strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/audit?limit=1", nil, token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		resp := w.Body.String()
+		for _, expectedSubstring := range []string{
+			`"action":"deck.update"`,
+			`"field":"title"`,
+			`"new":"Audited Deck"`,
+			`"has_more":true`,
+		} {
+			if !strings.Contains(resp, expectedSubstring) {
+				t.Errorf("Expected response body to contain %q, got %q", expectedSubstring, resp)
+			}
+		}
+
+		// Only the owner can read who changed the deck
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/audit", nil, token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for an editor, got %d", w.Code)
+		}
+	})
+
+	t.Run("Assign a deck to a class", func(t *testing.T) {
+		w := PerformRequest(r, "POST", "/api/v1/classes/", strings.NewReader(`{"name": "Spanish 101"}`), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var class struct {
+			ID       string `json:"id"`
+			JoinCode string `json:"join_code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &class); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		// Registered and unregistered emails are invited alike
+		body := `{"emails": ["` + email + `", "unregistered@user.com"]}`
+		w = PerformRequest(r, "POST", "/api/v1/classes/"+class.ID+"/students", strings.NewReader(body), token1)
+		if resp := w.Body.String(); w.Code != 200 || resp != `{"invited":2}` {
+			t.Errorf("Expected both emails to be invited, got %d %q", w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/classes/invites", nil, token2)
+		expectedSubstring := `"class_id":"` + class.ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Students are not enrolled before accepting, and only with a verified email
+		w = PerformRequest(r, "GET", "/api/v1/classes/"+class.ID+"/progress", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, email) {
+			t.Errorf("Expected no student before accepting, got %d %q", w.Code, resp)
+		}
+		w = PerformRequest(r, "POST", "/api/v1/classes/"+class.ID+"/accept", nil, token2)
+		if w.Code != 403 {
+			t.Errorf("Expected status code 403 for an unverified email, got %d", w.Code)
+		}
+
+		// Joining with the code drops the invite
+		w = PerformRequest(r, "POST", "/api/v1/classes/join/"+class.JoinCode, nil, token2)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/classes/invites", nil, token2)
+		if resp := w.Body.String(); w.Code != 200 || resp != "[]" {
+			t.Errorf("Expected no invites left, got %d %q", w.Code, resp)
+		}
+
+		dueAt := time.Now().Add(7 * 24 * time.Hour).UTC().Format(time.RFC3339)
+		body = `{"deck_id": "` + deckID + `", "due_at": "` + dueAt + `"}`
+		w = PerformRequest(r, "POST", "/api/v1/classes/"+class.ID+"/assignments", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/users/assignments", nil, token2)
+		expectedSubstring = `"deck_id":"` + deckID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/classes/"+class.ID+"/progress", nil, token1)
+		expectedSubstring = `"email":"` + email + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Students can't see the reports of the class
+		w = PerformRequest(r, "GET", "/api/v1/classes/"+class.ID+"/progress", nil, token2)
+		if w.Code != 401 {
+			t.Errorf("Expected status code 401 for a student, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/classes/"+class.ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Restore deleted cards and decks from the trash", func(t *testing.T) {
+		body := `{"type": "front_back", "front": "le soleil", "back": "the sun"}`
+		w := PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/cards/", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var card struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		cardPath := "/api/v1/decks/" + deckID + "/cards/" + card.ID
+
+		w = PerformRequest(r, "DELETE", cardPath, nil, token1)
+		if w.Code != 204 {
+			t.Fatalf("Expected status code 204, got %d", w.Code)
+		}
+
+		// The deleted card is only found in the trash
+		w = PerformRequest(r, "GET", cardPath, nil, token1)
+		if w.Code == 200 {
+			t.Errorf("Expected the deleted card to be gone, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/cards/", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, card.ID) {
+			t.Errorf("Expected the deleted card to be left out of the list, got %d %q", w.Code, resp)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/trash", nil, token1)
+		expectedSubstring := `"id":"` + card.ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/trash/"+card.ID+"/restore", nil, token1)
+		expectedSubstring = `"back":"the sun"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+		w = PerformRequest(r, "GET", cardPath, nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for the restored card, got %d", w.Code)
+		}
+
+		// Notes go to the trash with their cards, and come back with them
+		body = `{"name": "Trashed Words", "fields": ["Word"], "templates": [{"name": "Card", "front": "{{Word}}", "back": "?"}]}`
+		w = PerformRequest(r, "POST", "/api/v1/note-types/", strings.NewReader(body), token1)
+		var noteType struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &noteType); err != nil || w.Code != 201 {
+			t.Fatalf("Failed to create a note type: %d %q", w.Code, w.Body.String())
+		}
+		body = `{"note_type_id": "` + noteType.ID + `", "fields": {"Word": "la lune"}}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/notes/", strings.NewReader(body), token1)
+		var note struct {
+			ID    string `json:"id"`
+			Cards []struct {
+				ID string `json:"id"`
+			} `json:"cards"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &note); err != nil || w.Code != 201 || len(note.Cards) != 1 {
+			t.Fatalf("Failed to create a note: %d %q", w.Code, w.Body.String())
+		}
+		notePath := "/api/v1/decks/" + deckID + "/notes/" + note.ID
+		noteCardPath := "/api/v1/decks/" + deckID + "/cards/" + note.Cards[0].ID
+
+		w = PerformRequest(r, "DELETE", notePath, nil, token1)
+		if w.Code != 204 {
+			t.Fatalf("Expected status code 204, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", noteCardPath, nil, token1)
+		if w.Code == 200 {
+			t.Errorf("Expected the card of the deleted note to be gone, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deckID+"/audit?limit=1", nil, token1)
+		expectedSubstring = `"action":"card.delete","card_id":"` + note.Cards[0].ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// The card of a note in the trash only comes back with the note
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deckID+"/trash/"+note.Cards[0].ID+"/restore", nil, token1)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+		w = PerformRequest(r, "POST", notePath+"/restore", nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", noteCardPath, nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for the restored card of the note, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/", strings.NewReader(`{"title": "Trashed Deck"}`), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var deck struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &deck); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		// Assignments of a deck in the trash are hidden until it is restored
+		w = PerformRequest(r, "POST", "/api/v1/classes/", strings.NewReader(`{"name": "Trash 101"}`), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var class struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &class); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		body = `{"emails": ["` + email + `"]}`
+		w = PerformRequest(r, "POST", "/api/v1/classes/"+class.ID+"/students", strings.NewReader(body), token1)
+		if w.Code != 200 {
+			t.Fatalf("Expected status code 200, got %d", w.Code)
+		}
+		dueAt := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+		body = `{"deck_id": "` + deck.ID + `", "due_at": "` + dueAt + `"}`
+		w = PerformRequest(r, "POST", "/api/v1/classes/"+class.ID+"/assignments", strings.NewReader(body), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deck.ID, nil, token1)
+		if w.Code != 204 {
+			t.Fatalf("Expected status code 204, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deck.ID, nil, token1)
+		if w.Code == 200 {
+			t.Errorf("Expected the deleted deck to be gone, got %d", w.Code)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/users/assignments", nil, token2)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, deck.ID) {
+			t.Errorf("Expected the assignment of the deleted deck to be hidden, got %d %q", w.Code, resp)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/classes/"+class.ID+"/progress", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, deck.ID) {
+			t.Errorf("Expected the assignment of the deleted deck to be hidden, got %d %q", w.Code, resp)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/decks/trash", nil, token1)
+		expectedSubstring = `"id":"` + deck.ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		// Only the owner can restore a deck
+		w = PerformRequest(r, "POST", "/api/v1/decks/trash/"+deck.ID+"/restore", nil, token2)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400, got %d", w.Code)
+		}
+		w = PerformRequest(r, "POST", "/api/v1/decks/trash/"+deck.ID+"/restore", nil, token1)
+		expectedSubstring = `"title":"Trashed Deck"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+		w = PerformRequest(r, "GET", "/api/v1/users/assignments", nil, token2)
+		expectedSubstring = `"deck_id":"` + deck.ID + `"`
+		if resp := w.Body.String(); w.Code != 200 || !strings.Contains(resp, expectedSubstring) {
+			t.Errorf("Expected response body to contain %q, got %d %q", expectedSubstring, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/classes/"+class.ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deck.ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Deny every deck route to users without access", func(t *testing.T) {
+		w := PerformRequest(r, "POST", "/api/v1/decks/", strings.NewReader(`{"title": "Private Deck"}`), token1)
+		if w.Code != 201 {
+			t.Fatalf("Expected status code 201, got %d", w.Code)
+		}
+		var deck struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &deck); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		card := `{"type": "front_back", "front": "el gato", "back": "the cat"}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deck.ID+"/cards/", strings.NewReader(card), token1)
+		var created struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.ID == "" {
+			t.Fatalf("Failed to create a card: %d %q", w.Code, w.Body.String())
+		}
+
+		base := "/api/v1/decks/" + deck.ID
+		cardPath := base + "/cards/" + created.ID
+		routes := []struct {
+			method, path string
+		}{
+			{"GET", base},
+			{"PATCH", base},
+			{"DELETE", base},
+			{"GET", base + "/audit"},
+			{"GET", base + "/trash"},
+			{"POST", base + "/trash/" + created.ID + "/restore"},
+			{"POST", base + "/transfer"},
+			{"DELETE", base + "/transfer"},
+			{"POST", base + "/transfer/accept"},
+			{"PATCH", base + "/emails"},
+			{"GET", base + "/members"},
+			{"PUT", base + "/members/" + email},
+			{"DELETE", base + "/members/" + email},
+			{"GET", base + "/invites"},
+			{"DELETE", base + "/invites/any"},
+			{"POST", base + "/links"},
+			{"GET", base + "/links"},
+			{"DELETE", base + "/links/any"},
+			{"GET", base + "/tags"},
+			{"PATCH", base + "/tags"},
+			{"PUT", base + "/publish"},
+			{"DELETE", base + "/publish"},
+			{"POST", base + "/study"},
+			{"POST", base + "/fork"},
+			{"POST", base + "/subscribe"},
+			{"GET", base + "/upstream/changes"},
+			{"POST", base + "/upstream/pull"},
+			{"GET", base + "/export"},
+			{"POST", base + "/import/csv"},
+			{"POST", base + "/import/markdown"},
+			{"GET", base + "/notes/"},
+			{"POST", base + "/notes/"},
+			{"GET", base + "/notes/any"},
+			{"PUT", base + "/notes/any"},
+			{"DELETE", base + "/notes/any"},
+			{"POST", base + "/notes/any/restore"},
+			{"GET", base + "/cards/"},
+			{"POST", base + "/cards/"},
+			{"GET", base + "/cards/due"},
+			{"GET", cardPath},
+			{"PUT", cardPath},
+			{"DELETE", cardPath},
+			{"GET", cardPath + "/html"},
+			{"GET", cardPath + "/highlight"},
+			{"GET", cardPath + "/presentation"},
+			{"POST", cardPath + "/grade"},
+			{"GET", cardPath + "/revisions"},
+			{"GET", cardPath + "/revisions/diff?from=any"},
+			{"POST", cardPath + "/revisions/any/restore"},
+			{"GET", cardPath + "/progress/"},
+			{"PUT", cardPath + "/progress/"},
+		}
+
+		for _, route := range routes {
+			w := PerformRequest(r, route.method, route.path, strings.NewReader(`{}`), token2)
+			if w.Code != 401 {
+				t.Errorf("Expected status code 401 for %s %s, got %d", route.method, route.path, w.Code)
+			}
+		}
+
+		// The deck and its card are left untouched
+		w = PerformRequest(r, "GET", cardPath, nil, token1)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for the owner, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", base, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
+	t.Run("Join a deck through a share link", func(t *testing.T) {
+		w := PerformRequest(r, "POST", "/api/v1/decks/", strings.NewReader(`{"title": "Class Deck"}`), token1)
+		var deck struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &deck); err != nil || deck.ID == "" {
+			t.Fatalf("Failed to create a deck: %d %q", w.Code, w.Body.String())
+		}
+
+		body := `{"role": "viewer", "max_uses": 1}`
+		w = PerformRequest(r, "POST", "/api/v1/decks/"+deck.ID+"/links", strings.NewReader(body), token1)
+		var link struct {
+			ID    string `json:"id"`
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &link); err != nil || w.Code != 201 || link.Token == "" {
+			t.Fatalf("Failed to create a share link: %d %q", w.Code, w.Body.String())
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/join/"+link.Token, nil, token2)
+		expected := `{"deck_id":"` + deck.ID + `","role":"viewer"}`
+		if resp := w.Body.String(); w.Code != 200 || resp != expected {
+			t.Errorf("Expected response body %q, got %d %q", expected, w.Code, resp)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deck.ID, nil, token2)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200 for a member, got %d", w.Code)
+		}
+
+		// Members redeeming again do not use the link up
+		w = PerformRequest(r, "POST", "/api/v1/decks/join/"+link.Token, nil, token2)
+		if w.Code != 200 {
+			t.Errorf("Expected status code 200, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "GET", "/api/v1/decks/"+deck.ID+"/links", nil, token1)
+		if resp := w.Body.String(); w.Code != 200 || strings.Contains(resp, link.Token) || !strings.Contains(resp, `"uses":1`) {
+			t.Errorf("Expected the link used once without its token, got %d %q", w.Code, resp)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deck.ID+"/links/"+link.ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "POST", "/api/v1/decks/join/"+link.Token, nil, token2)
+		if w.Code != 400 {
+			t.Errorf("Expected status code 400 for a revoked link, got %d", w.Code)
+		}
+
+		w = PerformRequest(r, "DELETE", "/api/v1/decks/"+deck.ID, nil, token1)
+		if w.Code != 204 {
+			t.Errorf("Expected status code 204, got %d", w.Code)
+		}
+	})
+
 	// Delete one card from the deck
 	t.Run("Delete one card from the deck", func(t *testing.T) {
 		// First, get the list of cards to find a card ID to delete
@@ -3,6 +3,7 @@ package middleware
 import (
 	"memora/internal/errors"
 	"memora/internal/services"
+	"memora/internal/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -26,6 +27,9 @@ func FirebaseAuthMiddleware(auth *services.AuthService) gin.HandlerFunc {
 
 		c.Set("uid", token.UID)
 		c.Set("email", token.Claims["email"])
+		// Invites are only accepted for an email the account has verified
+		c.Set("email_verified", token.Claims["email_verified"] == true)
+		c.Request = c.Request.WithContext(utils.WithUserID(c.Request.Context(), token.UID))
 		c.Next()
 	}
 }
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"memora/internal/errors"
+	"memora/internal/services"
+	"memora/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeckAccess resolves the role of the user on the deck of the route once per request,
+// and sets it in the context as "deckRole" for the routes under the deck.
+// Users without access get an empty role, which RequireDeckRole rejects.
+func DeckAccess(decks *services.DeckService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := decks.GetDeckRole(
+			c.Request.Context(),
+			c.Param("deckID"), c.GetString("uid"),
+		)
+		if err != nil {
+			errors.HandleError(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Set("deckRole", role)
+		c.Next()
+	}
+}
+
+// RequireDeckRole stops requests from users without at least the required role
+// on the deck of the route, as resolved by DeckAccess.
+func RequireDeckRole(required string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !utils.RoleAllows(c.GetString("deckRole"), required) {
+			errors.HandleError(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
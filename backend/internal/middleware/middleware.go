@@ -3,6 +3,7 @@ package middleware
 import (
 	"log/slog"
 	"memora/internal/config"
+	"memora/internal/utils"
 	"net/http"
 	"time"
 
@@ -35,6 +36,9 @@ func Logging(logger *slog.Logger) gin.HandlerFunc {
 		reqID := uuid.New().String()
 		c.Set("reqID", reqID)
 
+		// Services only get the request context, so the ID is set on it too
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), reqID))
+
 		c.Next()
 
 		duration := time.Since(start)
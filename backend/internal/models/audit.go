@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// AuditEvent records a change made to a deck or one of its cards, by whom and in which request.
+type AuditEvent struct {
+	ID        string        `json:"id" firestore:"-"`
+	Action    string        `json:"action" firestore:"action"`
+	CardID    string        `json:"card_id,omitempty" firestore:"card_id,omitempty"`
+	ActorID   string        `json:"actor_id" firestore:"actor_id"`
+	RequestID string        `json:"request_id,omitempty" firestore:"request_id,omitempty"`
+	Changes   []FieldChange `json:"changes" firestore:"changes"`
+	CreatedAt time.Time     `json:"created_at" firestore:"created_at"`
+}
+
+// FieldChange is the value of a field before and after a change.
+// Fields of nested objects are named by their dotted path,
+// and the value is missing on the side where the field did not exist.
+type FieldChange struct {
+	Field string `json:"field" firestore:"field"`
+	Old   any    `json:"old,omitempty" firestore:"old,omitempty"`
+	New   any    `json:"new,omitempty" firestore:"new,omitempty"`
+}
+
+// AuditLogResponse is a page of the audit log of a deck, the latest events first.
+type AuditLogResponse struct {
+	Events  []AuditEvent `json:"events"`
+	HasMore bool         `json:"has_more"`
+	Cursor  string       `json:"cursor,omitempty"`
+}
+
+// DiffFields compares two documents field by field, as they are encoded in JSON.
+// Either may be nil, for documents created or deleted.
+// Returns the fields that differ sorted by path, or an error if a document can not be encoded.
+func DiffFields(before, after any) ([]FieldChange, error) {
+	oldFields, err := flattenFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := slices.Collect(maps.Keys(oldFields))
+	for path := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	changes := []FieldChange{}
+	for _, path := range paths {
+		if reflect.DeepEqual(oldFields[path], newFields[path]) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: path, Old: oldFields[path], New: newFields[path]})
+	}
+
+	return changes, nil
+}
+
+// flattenFields encodes a document to JSON and maps the dotted path of every field to its value.
+// Lists are values of their own, as their items have no path.
+func flattenFields(doc any) (map[string]any, error) {
+	bytes, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]any)
+	var flatten func(prefix string, value any)
+	flatten = func(prefix string, value any) {
+		object, ok := value.(map[string]any)
+		if !ok {
+			if prefix != "" && value != nil {
+				fields[prefix] = value
+			}
+			return
+		}
+		for key, field := range object {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flatten(key, field)
+		}
+	}
+	flatten("", decoded)
+
+	return fields, nil
+}
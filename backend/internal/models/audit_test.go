@@ -0,0 +1,71 @@
+package models_test
+
+import (
+	"memora/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   []models.FieldChange
+	}{
+		{
+			"changed field",
+			map[string]any{"title": "Spanish", "owner_id": "uid-alice"},
+			map[string]any{"title": "Spanish 101", "owner_id": "uid-alice"},
+			[]models.FieldChange{{Field: "title", Old: "Spanish", New: "Spanish 101"}},
+		},
+		{
+			"created document",
+			nil,
+			models.UpdateDeck{Title: "Spanish"},
+			[]models.FieldChange{{Field: "title", New: "Spanish"}},
+		},
+		{
+			"deleted document",
+			map[string]any{"front": "el gato", "tags": []string{"animals"}},
+			nil,
+			[]models.FieldChange{
+				{Field: "front", Old: "el gato"},
+				{Field: "tags", Old: []any{"animals"}},
+			},
+		},
+		{
+			"nested fields",
+			map[string]any{"memberships": map[string]models.Membership{
+				"uid-bob": {Email: "bob@x.com", Role: "viewer"},
+			}},
+			map[string]any{"memberships": map[string]models.Membership{
+				"uid-bob":   {Email: "bob@x.com", Role: "editor"},
+				"uid-carol": {Email: "carol@x.com", Role: "viewer"},
+			}},
+			[]models.FieldChange{
+				{Field: "memberships.uid-bob.role", Old: "viewer", New: "editor"},
+				{Field: "memberships.uid-carol.email", New: "carol@x.com"},
+				{Field: "memberships.uid-carol.role", New: "viewer"},
+			},
+		},
+		{
+			"typed and decoded values are equal",
+			map[string]any{"answer": 42.0, "tags": []any{"math"}},
+			map[string]any{"answer": 42, "tags": []string{"math"}},
+			[]models.FieldChange{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := models.DiffFields(tt.before, tt.after)
+			if err != nil {
+				t.Fatalf("DiffFields() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
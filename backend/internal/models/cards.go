@@ -13,8 +13,36 @@ type Card interface {
 
 	// SetID sets the ID of the card.
 	SetID(id string)
+
+	// Meta returns the fields shared by every card type.
+	Meta() *CardMeta
+
+	// MapText replaces every text field shown to the user with the result of fn.
+	MapText(fn func(string) string)
+}
+
+// CardMeta holds the fields shared by every card type, and is embedded in each of them.
+type CardMeta struct {
+	Tags   []string `json:"tags,omitempty" validate:"omitempty,dive,required,max=50,excludesall=0x2C" firestore:"tags,omitempty"`
+	Format string   `json:"format,omitempty" validate:"omitempty,oneof=plain markdown" firestore:"format,omitempty"`
+	// Source is set on cards imported from a Markdown document, to find them on re-import
+	Source *CardSource `json:"source,omitempty" firestore:"source,omitempty"`
+	// Upstream is set on the cards of a linked copy, to find their source card
+	Upstream *CardUpstream `json:"upstream,omitempty" firestore:"upstream,omitempty"`
+}
+
+// CardSource tells which block of an imported document a card was made from.
+type CardSource struct {
+	// Document names the imported document, such as its file name
+	Document string `json:"document" firestore:"document"`
+	// Key is the hash identifying the block in the document
+	Key string `json:"key" firestore:"key"`
+	// Hash is the hash of the content of the card when last imported
+	Hash string `json:"hash" firestore:"hash"`
 }
 
+func (m *CardMeta) Meta() *CardMeta { return m }
+
 // This tells Swagger that the response can be one of these types
 type AnyCard struct {
 	// @swagger:oneOf
@@ -22,6 +50,10 @@ type AnyCard struct {
 	MultipleChoiceCard MultipleChoiceCard
 	OrderedCard        OrderedCard
 	BlanksCard         BlanksCard
+	NoteCard           NoteCard
+	NumericCard        NumericCard
+	MatchingCard       MatchingCard
+	CodeCard           CodeCard
 }
 
 type AnyCardWithPaging struct {
@@ -35,60 +67,196 @@ type MultipleChoiceCard struct {
 	Type     string          `json:"type" validate:"required" firestore:"type"`
 	Question string          `json:"question" validate:"required" firestore:"question"`
 	Options  map[string]bool `json:"options" validate:"required" firestore:"options"`
+
+	CardMeta
 }
 
 func (m MultipleChoiceCard) GetType() string  { return utils.MULTIPLE_CHOICE_CARD }
 func (m *MultipleChoiceCard) SetID(id string) { m.ID = id }
+func (m *MultipleChoiceCard) MapText(fn func(string) string) {
+	m.Question = fn(m.Question)
+	options := make(map[string]bool, len(m.Options))
+	for option, correct := range m.Options {
+		options[fn(option)] = correct
+	}
+	m.Options = options
+}
 
 type FrontBackCard struct {
-	ID    string `json:"id,omitempty" firestore:"-"`
-	Type  string `json:"type" validate:"required" firestore:"type"`
-	Front string `json:"front" validate:"required" firestore:"front"`
-	Back  string `json:"back" validate:"required" firestore:"back"`
+	ID        string `json:"id,omitempty" firestore:"-"`
+	Type      string `json:"type" validate:"required" firestore:"type"`
+	Front     string `json:"front" validate:"required" firestore:"front"`
+	Back      string `json:"back" validate:"required" firestore:"back"`
+	Direction string `json:"direction,omitempty" validate:"omitempty,oneof=forward reverse both" firestore:"direction,omitempty"`
+
+	// ReviewDirection is only set on cards returned from a due queue,
+	// and tells which side of the card is being asked for.
+	ReviewDirection string `json:"review_direction,omitempty" firestore:"-"`
+
+	CardMeta
 }
 
 func (f FrontBackCard) GetType() string  { return utils.FRONT_BACK_CARD }
 func (f *FrontBackCard) SetID(id string) { f.ID = id }
+func (f *FrontBackCard) MapText(fn func(string) string) {
+	f.Front = fn(f.Front)
+	f.Back = fn(f.Back)
+}
 
 type OrderedCard struct {
 	ID       string   `json:"id,omitempty" firestore:"-"`
 	Type     string   `json:"type" validate:"required"  firestore:"type"`
 	Question string   `json:"question" validate:"required" firestore:"question"`
 	Options  []string `json:"options" validate:"required" firestore:"options"`
+
+	CardMeta
 }
 
 func (o OrderedCard) GetType() string  { return utils.ORDERED_CARD }
 func (o *OrderedCard) SetID(id string) { o.ID = id }
+func (o *OrderedCard) MapText(fn func(string) string) {
+	o.Question = fn(o.Question)
+	for i := range o.Options {
+		o.Options[i] = fn(o.Options[i])
+	}
+}
 
 type BlanksCard struct {
 	ID       string   `json:"id,omitempty" firestore:"-"`
 	Type     string   `json:"type" validate:"required" firestore:"type"`
 	Question string   `json:"question" validate:"required" firestore:"question"`
 	Answers  []string `json:"answers" validate:"required" firestore:"answers"`
+
+	CardMeta
 }
 
 func (b BlanksCard) GetType() string  { return utils.BLANKS_CARD }
 func (b *BlanksCard) SetID(id string) { b.ID = id }
+func (b *BlanksCard) MapText(fn func(string) string) {
+	b.Question = fn(b.Question)
+	for i := range b.Answers {
+		b.Answers[i] = fn(b.Answers[i])
+	}
+}
+
+// NumericCard is answered with a number, optionally followed by a unit such as "9.81 m/s²".
+// Answers within the tolerance are correct, absolute tolerance is in the unit of the card,
+// and relative tolerance is a fraction of the answer.
+type NumericCard struct {
+	ID            string   `json:"id,omitempty" firestore:"-"`
+	Type          string   `json:"type" validate:"required" firestore:"type"`
+	Question      string   `json:"question" validate:"required" firestore:"question"`
+	Answer        *float64 `json:"answer" validate:"required" firestore:"answer"`
+	Unit          string   `json:"unit,omitempty" validate:"omitempty,max=30" firestore:"unit,omitempty"`
+	Tolerance     float64  `json:"tolerance" validate:"gte=0" firestore:"tolerance"`
+	ToleranceMode string   `json:"tolerance_mode" validate:"required,oneof=absolute relative" firestore:"tolerance_mode"`
+
+	CardMeta
+}
+
+func (n NumericCard) GetType() string  { return utils.NUMERIC_CARD }
+func (n *NumericCard) SetID(id string) { n.ID = id }
+func (n *NumericCard) MapText(fn func(string) string) {
+	n.Question = fn(n.Question)
+}
+
+type MatchingPair struct {
+	Left  string `json:"left" validate:"required" firestore:"left"`
+	Right string `json:"right" validate:"required" firestore:"right"`
+}
+
+// MatchingCard asks to match every left item with its right item, such as countries to capitals.
+type MatchingCard struct {
+	ID       string         `json:"id,omitempty" firestore:"-"`
+	Type     string         `json:"type" validate:"required" firestore:"type"`
+	Question string         `json:"question" validate:"required" firestore:"question"`
+	Pairs    []MatchingPair `json:"pairs" validate:"required,min=2,max=50,unique=Left,unique=Right,dive" firestore:"pairs"`
+
+	CardMeta
+}
+
+func (m MatchingCard) GetType() string  { return utils.MATCHING_CARD }
+func (m *MatchingCard) SetID(id string) { m.ID = id }
+func (m *MatchingCard) MapText(fn func(string) string) {
+	m.Question = fn(m.Question)
+	for i := range m.Pairs {
+		m.Pairs[i].Left = fn(m.Pairs[i].Left)
+		m.Pairs[i].Right = fn(m.Pairs[i].Right)
+	}
+}
+
+// CodeCard shows a code snippet, with an optional range of lines to highlight.
+// The code is kept exactly as written, only the question and explanation are formatted.
+type CodeCard struct {
+	ID            string `json:"id,omitempty" firestore:"-"`
+	Type          string `json:"type" validate:"required" firestore:"type"`
+	Question      string `json:"question" validate:"required" firestore:"question"`
+	Language      string `json:"language" validate:"required,max=50" firestore:"language"`
+	Code          string `json:"code" validate:"required,max=20000" firestore:"code"`
+	HighlightFrom int    `json:"highlight_from,omitempty" validate:"omitempty,min=1,required_with=HighlightTo" firestore:"highlight_from,omitempty"`
+	HighlightTo   int    `json:"highlight_to,omitempty" validate:"omitempty,gtefield=HighlightFrom,required_with=HighlightFrom" firestore:"highlight_to,omitempty"`
+	Explanation   string `json:"explanation,omitempty" firestore:"explanation,omitempty"`
+
+	CardMeta
+}
+
+func (c CodeCard) GetType() string  { return utils.CODE_CARD }
+func (c *CodeCard) SetID(id string) { c.ID = id }
+func (c *CodeCard) MapText(fn func(string) string) {
+	c.Question = fn(c.Question)
+	c.Explanation = fn(c.Explanation)
+}
+
+// CodeHighlight is the code of a code card rendered to HTML.
+type CodeHighlight struct {
+	ID       string `json:"id"`
+	Language string `json:"language"`
+	Style    string `json:"style"`
+	HTML     string `json:"html"`
+}
+
+// NoteCard is generated from a note and one of the card templates of its note type.
+// It can be reviewed like any other card, but is only changed through its note.
+type NoteCard struct {
+	ID       string `json:"id,omitempty" firestore:"-"`
+	Type     string `json:"type" validate:"required" firestore:"type"`
+	NoteID   string `json:"note_id" validate:"required" firestore:"note_id"`
+	Template string `json:"template" validate:"required" firestore:"template"`
+	Front    string `json:"front" validate:"required" firestore:"front"`
+	Back     string `json:"back" firestore:"back"`
+
+	CardMeta
+}
+
+func (n NoteCard) GetType() string  { return utils.NOTE_CARD }
+func (n *NoteCard) SetID(id string) { n.ID = id }
+func (n *NoteCard) MapText(fn func(string) string) {
+	n.Front = fn(n.Front)
+	n.Back = fn(n.Back)
+}
 
 type CardType struct {
 	Type string `json:"type"`
 }
 
 type CardsResponse struct {
-	Cards   []Card `json:"cards"`
-	HasMore bool   `json:"has_more"`
+	Cards   []any `json:"cards"`
+	HasMore bool  `json:"has_more"`
 }
 
 type CardRating struct {
-	Rating string `json:"rating" validate:"oneof=again hard good easy"`
+	Rating    string `json:"rating" validate:"oneof=again hard good easy"`
+	Direction string `json:"direction,omitempty" validate:"omitempty,oneof=forward reverse"`
 }
 
+// CardProgress is the review schedule of a card for a user.
+// The limits are checked when progress is imported, reviews keep within them.
 type CardProgress struct {
-	EaseFactor   int       `firestore:"ease_factor" json:"ease_factor"`
-	Interval     float64   `firestore:"interval" json:"interval"`
-	Due          time.Time `firestore:"due" json:"due"`
-	Reps         int       `firestore:"reps" json:"reps"`
-	Lapses       int       `firestore:"lapses" json:"lapses"`
+	EaseFactor   int       `firestore:"ease_factor" json:"ease_factor" validate:"min=1300,max=3000"`
+	Interval     float64   `firestore:"interval" json:"interval" validate:"min=0,max=36500"`
+	Due          time.Time `firestore:"due" json:"due" validate:"required"`
+	Reps         int       `firestore:"reps" json:"reps" validate:"min=0"`
+	Lapses       int       `firestore:"lapses" json:"lapses" validate:"min=0"`
 	LastReviewed time.Time `firestore:"last_reviewed_at" json:"last_reviewed_at"`
 }
 
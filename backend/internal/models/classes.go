@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// CreateClass holds the fields of a new class, its teacher is the user creating it.
+type CreateClass struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// Class is a group of students taught by a teacher, who assigns decks to them.
+// Students join by accepting an invite to their email or with the join code of the class.
+type Class struct {
+	ID        string    `json:"id" firestore:"-"`
+	Name      string    `json:"name" firestore:"name"`
+	TeacherID string    `json:"teacher_id" firestore:"teacher_id"`
+	JoinCode  string    `json:"join_code" firestore:"join_code"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+
+	// StudentIDs holds the keys of Students, so the classes of a student can be queried
+	StudentIDs []string                `json:"-" firestore:"student_ids"`
+	Students   map[string]ClassStudent `json:"students" firestore:"students"`
+	// InvitedEmails are the emails invited to the class that have not joined it yet
+	InvitedEmails []string `json:"invited_emails" firestore:"invited_emails"`
+}
+
+// ClassStudent is a student enrolled in a class.
+type ClassStudent struct {
+	Email    string    `json:"email" firestore:"email"`
+	JoinedAt time.Time `json:"joined_at" firestore:"joined_at"`
+}
+
+// EnrollStudents invites the emails to a class, their users join it once they accept.
+type EnrollStudents struct {
+	Emails []string `json:"emails" validate:"required,min=1,dive,email"`
+}
+
+// Enrollment reports how many emails were invited to a class.
+// Whether an email has a registered user is not told.
+type Enrollment struct {
+	Invited int `json:"invited"`
+}
+
+// ClassInvite is a pending invite of the email of a user to a class.
+type ClassInvite struct {
+	ClassID   string `json:"class_id"`
+	ClassName string `json:"class_name"`
+}
+
+// CreateAssignment assigns a deck to the students of a class.
+type CreateAssignment struct {
+	DeckID string    `json:"deck_id" validate:"required"`
+	DueAt  time.Time `json:"due_at" validate:"required"`
+}
+
+// Assignment is a deck the students of a class have to study by a due date.
+type Assignment struct {
+	ID         string    `json:"id" firestore:"-"`
+	DeckID     string    `json:"deck_id" firestore:"deck_id"`
+	DeckTitle  string    `json:"deck_title" firestore:"deck_title"`
+	DueAt      time.Time `json:"due_at" firestore:"due_at"`
+	AssignedAt time.Time `json:"assigned_at" firestore:"assigned_at"`
+}
+
+// AssignmentProgress sums up the progress of a student on the deck of an assignment.
+// Retention is the share of reviews recalled, and Overdue the cards past their review date.
+type AssignmentProgress struct {
+	AssignmentID string    `json:"assignment_id"`
+	DeckID       string    `json:"deck_id"`
+	DueAt        time.Time `json:"due_at"`
+	Cards        int       `json:"cards"`
+	Studied      int       `json:"studied"`
+	Reviews      int       `json:"reviews"`
+	Retention    float64   `json:"retention"`
+	Overdue      int       `json:"overdue"`
+}
+
+// StudentProgress is the progress of a student on every assignment of a class.
+type StudentProgress struct {
+	UserID      string               `json:"user_id"`
+	Email       string               `json:"email"`
+	Assignments []AssignmentProgress `json:"assignments"`
+}
+
+// ClassProgress is the progress of every student of a class, sorted by email.
+type ClassProgress struct {
+	ClassID  string            `json:"class_id"`
+	Students []StudentProgress `json:"students"`
+}
+
+// StudentAssignment is an assignment of a class a user is enrolled in,
+// with the progress of the user on it.
+type StudentAssignment struct {
+	ClassID   string `json:"class_id"`
+	ClassName string `json:"class_name"`
+	Assignment
+	Progress AssignmentProgress `json:"progress"`
+}
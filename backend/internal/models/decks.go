@@ -1,11 +1,23 @@
 package models
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type CreateDeck struct {
 	Title        string   `json:"title" validate:"required" firestore:"title"`
 	OwnerID      string   `json:"owner_id" validate:"required" firestore:"owner_id"`
 	SharedEmails []string `json:"shared_emails" validate:"omitempty,dive,email" firestore:"shared_emails"`
+
+	// Memberships of the users of the shared emails, set when the deck is created
+	MemberIDs   []string              `json:"-" firestore:"member_ids"`
+	Memberships map[string]Membership `json:"-" firestore:"memberships,omitempty"`
+
+	// Only set when forking a deck, never from the request body
+	ForkedFrom string        `json:"-" firestore:"forked_from,omitempty"`
+	ForkedAt   *time.Time    `json:"-" firestore:"forked_at,omitempty"`
+	Upstream   *DeckUpstream `json:"-" firestore:"upstream,omitempty"`
 }
 
 type DeckResponse struct {
@@ -20,6 +32,33 @@ type Deck struct {
 	OwnerID      string   `json:"owner_id" firestore:"owner_id"`
 	Title        string   `json:"title" firestore:"title"`
 	SharedEmails []string `json:"shared_emails" firestore:"shared_emails"`
+
+	// Memberships maps the IDs of the members to their email and role.
+	// MemberIDs holds the same IDs, so the decks of a member can be queried.
+	// SharedEmails is kept in step for display, access only follows the IDs.
+	MemberIDs   []string              `json:"-" firestore:"member_ids"`
+	Memberships map[string]Membership `json:"memberships,omitempty" firestore:"memberships,omitempty"`
+
+	// ForkedFrom is the ID of the deck this deck was copied from
+	ForkedFrom string     `json:"forked_from,omitempty" firestore:"forked_from,omitempty"`
+	ForkedAt   *time.Time `json:"forked_at,omitempty" firestore:"forked_at,omitempty"`
+
+	// Upstream is set on linked copies, which can pull the changes of their source deck
+	Upstream *DeckUpstream `json:"upstream,omitempty" firestore:"upstream,omitempty"`
+
+	// Public decks can be read by anyone, and are listed in the library with their publication
+	Public      bool         `json:"public,omitempty" firestore:"public,omitempty"`
+	Publication *Publication `json:"publication,omitempty" firestore:"publication,omitempty"`
+
+	// Transfer is set while the owner waits for a member to take the deck over
+	Transfer *OwnershipTransfer `json:"transfer,omitempty" firestore:"transfer,omitempty"`
+}
+
+// ForkDeck holds the options of a fork, every field is optional.
+type ForkDeck struct {
+	// Title of the copy, the title of the source deck by default
+	Title           string `json:"title"`
+	IncludeProgress bool   `json:"include_progress"`
 }
 
 type UpdateDeck struct {
@@ -28,7 +67,10 @@ type UpdateDeck struct {
 
 type UpdateDeckEmails struct {
 	Opp    string   `json:"opp" validate:"required,oneof=add remove"`
-	Emails []string `json:"shared_emails" firestore:"shared_emails" validate:"required"`
+	Emails []string `json:"shared_emails" firestore:"shared_emails" validate:"required,dive,email"`
+
+	// Role given to added emails, editor by default
+	Role string `json:"role" validate:"omitempty,oneof=viewer editor co_owner"`
 }
 
 type DisplayDeck struct {
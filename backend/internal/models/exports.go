@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeckExport is a backup of a deck with every card, the notes generating some of them
+// and their note types, and optionally the progress of the user exporting it.
+// IDs are only used to match cards to their note and progress, and notes to their note type.
+type DeckExport struct {
+	SchemaVersion int                `json:"schema_version" validate:"required"`
+	ExportedAt    time.Time          `json:"exported_at"`
+	Deck          ExportedDeck       `json:"deck"`
+	Cards         []json.RawMessage  `json:"cards" validate:"required" swaggertype:"array,object"`
+	NoteTypes     []NoteType         `json:"note_types,omitempty" validate:"omitempty,dive"`
+	Notes         []Note             `json:"notes,omitempty" validate:"omitempty,dive"`
+	Progress      []ExportedProgress `json:"progress,omitempty" validate:"omitempty,dive"`
+}
+
+// ExportedDeck holds the metadata of an exported deck.
+// The library listing of a published deck is kept, but decks are imported unpublished.
+type ExportedDeck struct {
+	Title       string   `json:"title" validate:"required"`
+	CardCount   int      `json:"card_count"`
+	Description string   `json:"description,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Subjects    []string `json:"subjects,omitempty"`
+}
+
+// ExportedProgress is the progress on a card in one direction.
+type ExportedProgress struct {
+	CardID    string `json:"card_id" validate:"required"`
+	Direction string `json:"direction" validate:"required,oneof=forward reverse"`
+
+	CardProgress
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"memora/internal/utils"
+)
+
+// Margin for floating point errors when comparing numeric answers
+const numericEpsilon = 1e-9
+
+// GradableCard is implemented by the card types an answer can be graded against.
+type GradableCard interface {
+	Card
+
+	// Grade checks an answer to the card.
+	// Returns an error if the answer can not be read.
+	Grade(answer GradeAnswer) (GradeResult, error)
+}
+
+// GradeAnswer holds an answer to a card, numeric cards use Answer
+// and matching cards use Matches, mapping every left item to a right item.
+type GradeAnswer struct {
+	Answer  string            `json:"answer,omitempty"`
+	Matches map[string]string `json:"matches,omitempty"`
+}
+
+// GradeResult tells if an answer is correct, and the share of it that is, from 0 to 1.
+// Matching cards also tell which left items were matched correctly.
+type GradeResult struct {
+	Correct bool            `json:"correct"`
+	Score   float64         `json:"score"`
+	Matches map[string]bool `json:"matches,omitempty"`
+}
+
+// Grade checks a numeric answer, converting its unit to the unit of the card.
+// An answer without a unit is read in the unit of the card.
+// Returns an error if the answer is not a number or its unit is unknown.
+func (n *NumericCard) Grade(answer GradeAnswer) (GradeResult, error) {
+	value, unitText, err := utils.ParseQuantity(answer.Answer)
+	if err != nil {
+		return GradeResult{}, err
+	}
+
+	expectedUnit, err := utils.ParseUnit(n.Unit)
+	if err != nil {
+		return GradeResult{}, err
+	}
+
+	unit := expectedUnit
+	if unitText != "" {
+		if unit, err = utils.ParseUnit(unitText); err != nil {
+			return GradeResult{}, err
+		}
+	}
+
+	// Units measuring different things, such as m and s, are never equal
+	if unit.Dimensions != expectedUnit.Dimensions {
+		return GradeResult{}, nil
+	}
+
+	// Compare in SI base units, so equivalent prefixes give the same value
+	given := value * unit.Factor
+	expected := *n.Answer * expectedUnit.Factor
+
+	tolerance := n.Tolerance * expectedUnit.Factor
+	if n.ToleranceMode == utils.TOLERANCE_RELATIVE {
+		tolerance = n.Tolerance * math.Abs(expected)
+	}
+	tolerance += numericEpsilon * math.Max(math.Abs(expected), 1)
+
+	if math.Abs(given-expected) > tolerance {
+		return GradeResult{}, nil
+	}
+	return GradeResult{Correct: true, Score: 1}, nil
+}
+
+// Grade checks the matches of an answer, giving credit for every left item matched correctly.
+// Returns an error if the answer has no matches.
+func (m *MatchingCard) Grade(answer GradeAnswer) (GradeResult, error) {
+	if len(answer.Matches) == 0 {
+		return GradeResult{}, errors.New("no matches in answer")
+	}
+
+	result := GradeResult{Matches: make(map[string]bool, len(m.Pairs))}
+
+	correct := 0
+	for _, pair := range m.Pairs {
+		matched := answer.Matches[pair.Left] == pair.Right
+		result.Matches[pair.Left] = matched
+		if matched {
+			correct++
+		}
+	}
+
+	result.Score = float64(correct) / float64(len(m.Pairs))
+	result.Correct = correct == len(m.Pairs)
+
+	return result, nil
+}
@@ -0,0 +1,164 @@
+package models_test
+
+import (
+	"memora/internal/models"
+	"slices"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestNumericCardGrade(t *testing.T) {
+	gravity := 9.81
+	distance := 1.5
+
+	tests := []struct {
+		name   string
+		card   models.NumericCard
+		answer string
+		want   bool
+	}{
+		{
+			"exact answer with unit",
+			models.NumericCard{Answer: &gravity, Unit: "m/s²", ToleranceMode: "absolute"},
+			"9.81 m/s^2",
+			true,
+		},
+		{
+			"answer without unit uses the card unit",
+			models.NumericCard{Answer: &gravity, Unit: "m/s²", ToleranceMode: "absolute"},
+			"9.81",
+			true,
+		},
+		{
+			"within absolute tolerance",
+			models.NumericCard{Answer: &gravity, Unit: "m/s²", Tolerance: 0.1, ToleranceMode: "absolute"},
+			"9,8 m/s²",
+			true,
+		},
+		{
+			"outside absolute tolerance",
+			models.NumericCard{Answer: &gravity, Unit: "m/s²", Tolerance: 0.001, ToleranceMode: "absolute"},
+			"9.8 m/s²",
+			false,
+		},
+		{
+			"equivalent prefix",
+			models.NumericCard{Answer: &distance, Unit: "km", ToleranceMode: "absolute"},
+			"1500 m",
+			true,
+		},
+		{
+			"within relative tolerance with prefix",
+			models.NumericCard{Answer: &distance, Unit: "km", Tolerance: 0.01, ToleranceMode: "relative"},
+			"1510 m",
+			true,
+		},
+		{
+			"outside relative tolerance",
+			models.NumericCard{Answer: &distance, Unit: "km", Tolerance: 0.01, ToleranceMode: "relative"},
+			"1.6 km",
+			false,
+		},
+		{
+			"different dimensions",
+			models.NumericCard{Answer: &distance, Unit: "km", Tolerance: 0.01, ToleranceMode: "relative"},
+			"1.5 ks",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.card.Grade(models.GradeAnswer{Answer: tt.answer})
+			if err != nil {
+				t.Fatalf("Grade(%q) returned error %v", tt.answer, err)
+			}
+			if got.Correct != tt.want {
+				t.Errorf("Grade(%q) = %v, want %v", tt.answer, got.Correct, tt.want)
+			}
+		})
+	}
+
+	card := models.NumericCard{Answer: &gravity, Unit: "m/s²", ToleranceMode: "absolute"}
+	for _, answer := range []string{"about ten", "9.81 furlongs"} {
+		if _, err := card.Grade(models.GradeAnswer{Answer: answer}); err == nil {
+			t.Errorf("Grade(%q) returned no error", answer)
+		}
+	}
+}
+
+func TestMatchingCardGrade(t *testing.T) {
+	card := models.MatchingCard{Pairs: []models.MatchingPair{
+		{Left: "France", Right: "Paris"},
+		{Left: "Norway", Right: "Oslo"},
+		{Left: "Japan", Right: "Tokyo"},
+		{Left: "Peru", Right: "Lima"},
+	}}
+
+	got, err := card.Grade(models.GradeAnswer{Matches: map[string]string{
+		"France": "Paris",
+		"Norway": "Oslo",
+		"Japan":  "Lima",
+		"Peru":   "Tokyo",
+	}})
+	if err != nil {
+		t.Fatalf("Grade() returned error %v", err)
+	}
+	if got.Correct || got.Score != 0.5 || !got.Matches["France"] || got.Matches["Japan"] {
+		t.Errorf("Grade() = %+v, want half of the matches correct", got)
+	}
+
+	if _, err := card.Grade(models.GradeAnswer{}); err == nil {
+		t.Error("Grade() without matches returned no error")
+	}
+}
+
+func TestMatchingCardPresent(t *testing.T) {
+	card := models.MatchingCard{Pairs: []models.MatchingPair{
+		{Left: "France", Right: "Paris"},
+		{Left: "Norway", Right: "Oslo"},
+		{Left: "Japan", Right: "Tokyo"},
+	}}
+
+	got, ok := card.Present().(models.MatchingPresentation)
+	if !ok {
+		t.Fatalf("Present() returned %T, want models.MatchingPresentation", card.Present())
+	}
+
+	if !slices.Equal(sorted(got.Left), []string{"France", "Japan", "Norway"}) ||
+		!slices.Equal(sorted(got.Right), []string{"Oslo", "Paris", "Tokyo"}) {
+		t.Errorf("Present() = %+v, want every item on its side", got)
+	}
+}
+
+func TestMatchingCardValidation(t *testing.T) {
+	validate := validator.New()
+
+	tests := []struct {
+		name  string
+		pairs []models.MatchingPair
+		valid bool
+	}{
+		{"two pairs", []models.MatchingPair{{"a", "1"}, {"b", "2"}}, true},
+		{"single pair", []models.MatchingPair{{"a", "1"}}, false},
+		{"duplicate left", []models.MatchingPair{{"a", "1"}, {"a", "2"}}, false},
+		{"duplicate right", []models.MatchingPair{{"a", "1"}, {"b", "1"}}, false},
+		{"empty side", []models.MatchingPair{{"a", "1"}, {"b", ""}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := models.MatchingCard{Type: "matching", Question: "Match", Pairs: tt.pairs}
+			if err := validate.Struct(card); (err == nil) != tt.valid {
+				t.Errorf("Struct() returned %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func sorted(items []string) []string {
+	items = slices.Clone(items)
+	slices.Sort(items)
+	return items
+}
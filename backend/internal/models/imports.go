@@ -0,0 +1,81 @@
+package models
+
+// AnkiImportOptions are the form fields sent along with an Anki package.
+type AnkiImportOptions struct {
+	// Title of the new deck, the name of the Anki deck holding the most cards by default
+	Title           string `form:"title"`
+	IncludeProgress bool   `form:"include_progress"`
+}
+
+// ImportSummary reports what was created by an import, and what was left out.
+type ImportSummary struct {
+	DeckID   string `json:"deck_id"`
+	Cards    int    `json:"cards"`
+	Progress int    `json:"progress"`
+	// Skipped counts what could not be imported, keyed by the reason
+	Skipped map[string]int `json:"skipped"`
+}
+
+// CSVImportOptions describe how the rows of a CSV or TSV file become cards.
+// Columns are referenced by their header when the file has one, or by their index from 0.
+type CSVImportOptions struct {
+	// Delimiter between columns, a comma by default. "\t" or "tab" read TSV files.
+	Delimiter string `json:"delimiter"`
+	HasHeader bool   `json:"has_header"`
+
+	// TypeColumn holds the card type of each row, rows without one use DefaultType
+	TypeColumn  string `json:"type_column,omitempty"`
+	DefaultType string `json:"default_type,omitempty"`
+
+	// Mappings maps every card type to the column of each of its fields, such as
+	// {"front_back": {"front": "Word", "back": "Translation"}}
+	Mappings map[string]map[string]string `json:"mappings" validate:"required,min=1"`
+
+	// ListSeparator splits a column into the items of a list field, "|" by default.
+	// Correct multiple choice options start with "*", matching pairs are written "left=right".
+	ListSeparator string `json:"list_separator,omitempty" validate:"omitempty,max=5"`
+
+	// DryRun validates every row without creating any card
+	DryRun bool `json:"dry_run"`
+}
+
+// CSVImportReport is the outcome of a CSV import.
+// Cards are only created when no row has an error.
+type CSVImportReport struct {
+	DryRun   bool       `json:"dry_run"`
+	Rows     int        `json:"rows"`
+	Imported int        `json:"imported"`
+	CardIDs  []string   `json:"card_ids,omitempty"`
+	Errors   []RowError `json:"errors"`
+}
+
+// RowError tells why a row of an imported file could not become a card.
+type RowError struct {
+	// Row is the line of the row in the file, starting at 1
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// MarkdownImportOptions are the form fields sent along with a Markdown document.
+type MarkdownImportOptions struct {
+	// Source names the document, so cards are updated when it is imported again.
+	// The file name by default.
+	Source string `form:"source" validate:"required,max=200"`
+	// RemoveMissing deletes the cards of blocks no longer in the document
+	RemoveMissing bool `form:"remove_missing"`
+}
+
+// MarkdownImportReport is the outcome of a Markdown import.
+// Cards are only changed when no block has an error.
+type MarkdownImportReport struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	// Errors report the blocks that could not become cards, Row being their line
+	Errors []RowError `json:"errors"`
+	// Warnings report the blocks without an ID when missing cards are removed,
+	// as editing their text replaces their card
+	Warnings []RowError `json:"warnings,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Invite is a pending membership of a deck for an email without a registered user.
+// It becomes a membership when a user registers with the email or accepts it.
+type Invite struct {
+	ID        string    `json:"id" firestore:"-"`
+	DeckID    string    `json:"deck_id" firestore:"deck_id"`
+	DeckTitle string    `json:"deck_title" firestore:"deck_title"`
+	Email     string    `json:"email" firestore:"email"`
+	Role      string    `json:"role" firestore:"role"`
+	InvitedBy string    `json:"invited_by" firestore:"invited_by"`
+	CreatedAt time.Time `json:"created_at" firestore:"created_at"`
+}
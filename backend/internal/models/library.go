@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// PublishDeck is the listing of a deck in the public library, as sent by its owner.
+type PublishDeck struct {
+	Description string `json:"description" validate:"max=2000"`
+	// Language of the cards as a BCP 47 tag, such as "en" or "pt-BR"
+	Language string   `json:"language" validate:"required,bcp47_language_tag"`
+	Subjects []string `json:"subjects" validate:"max=10,dive,required,max=50,excludesall=0x2C"`
+}
+
+// Publication is the listing of a published deck.
+type Publication struct {
+	Description string   `json:"description" firestore:"description"`
+	Language    string   `json:"language" firestore:"language"`
+	Subjects    []string `json:"subjects" firestore:"subjects"`
+	// Learners counts the users studying the deck, used to rank it by popularity
+	Learners    int       `json:"learners" firestore:"learners"`
+	PublishedAt time.Time `json:"published_at" firestore:"published_at"`
+	UpdatedAt   time.Time `json:"updated_at" firestore:"updated_at"`
+}
+
+// PublicDeck is a deck as listed in the public library.
+type PublicDeck struct {
+	ID          string      `json:"id" firestore:"-"`
+	Title       string      `json:"title" firestore:"title"`
+	OwnerID     string      `json:"owner_id" firestore:"owner_id"`
+	CardCount   int         `json:"card_count" firestore:"-"`
+	Publication Publication `json:"publication" firestore:"publication"`
+}
+
+// PublicDecksResponse is a page of the public library.
+type PublicDecksResponse struct {
+	Decks   []PublicDeck `json:"decks"`
+	HasMore bool         `json:"has_more"`
+	Cursor  string       `json:"cursor,omitempty"`
+}
+
+// LibraryQuery filters and sorts the decks of the public library.
+type LibraryQuery struct {
+	// Search keeps the decks having every word in their title or description
+	Search   string `form:"q" validate:"max=200"`
+	Language string `form:"language"`
+	Subject  string `form:"subject"`
+	// Sort is popular for the most studied decks first, or recent for the last published
+	Sort   string `form:"sort" validate:"omitempty,oneof=popular recent"`
+	Limit  string `form:"limit"`
+	Cursor string `form:"cursor"`
+}
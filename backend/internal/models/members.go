@@ -0,0 +1,42 @@
+package models
+
+import "memora/internal/utils"
+
+// Membership is the role of a user on a deck, with the email the user was last known by.
+type Membership struct {
+	Email string `json:"email" firestore:"email"`
+	Role  string `json:"role" firestore:"role"`
+}
+
+// DeckMember is a user a deck is shared with.
+type DeckMember struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// DeckMembers lists the owner and the members of a deck.
+type DeckMembers struct {
+	OwnerID string       `json:"owner_id"`
+	Members []DeckMember `json:"members"`
+}
+
+// UpdateMemberRole changes the role of a member, owners are never members.
+type UpdateMemberRole struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor co_owner"`
+}
+
+// MemberID finds a member of the deck by their user ID, or by their email ignoring case.
+// Returns the ID of the member, and false if the deck has no such member.
+func (d Deck) MemberID(member string) (string, bool) {
+	if _, ok := d.Memberships[member]; ok {
+		return member, true
+	}
+	email := utils.NormalizeEmail(member)
+	for id, membership := range d.Memberships {
+		if membership.Email == email {
+			return id, true
+		}
+	}
+	return "", false
+}
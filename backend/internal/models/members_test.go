@@ -0,0 +1,35 @@
+package models_test
+
+import (
+	"memora/internal/models"
+	"testing"
+)
+
+func TestDeckMemberID(t *testing.T) {
+	deck := models.Deck{
+		Memberships: map[string]models.Membership{
+			"uid-alice": {Email: "alice@x.com", Role: "editor"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		member string
+		want   string
+		found  bool
+	}{
+		{"by user ID", "uid-alice", "uid-alice", true},
+		{"by email", "alice@x.com", "uid-alice", true},
+		{"by email in another case", "Alice@X.com", "uid-alice", true},
+		{"unknown email", "bob@x.com", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := deck.MemberID(tt.member)
+			if got != tt.want || found != tt.found {
+				t.Errorf("MemberID(%q) = %q, %v, want %q, %v", tt.member, got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
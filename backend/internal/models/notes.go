@@ -0,0 +1,61 @@
+package models
+
+// CardTemplate describes one card generated for every note of a note type.
+// Front and Back use {{Field}} to insert the value of a field, the back can also
+// use {{FrontSide}} to insert the rendered front.
+type CardTemplate struct {
+	Name  string `json:"name" validate:"required,max=50" firestore:"name"`
+	Front string `json:"front" validate:"required" firestore:"front"`
+	Back  string `json:"back" validate:"required" firestore:"back"`
+}
+
+type CreateNoteType struct {
+	Name      string         `json:"name" validate:"required,max=100"`
+	Fields    []string       `json:"fields" validate:"required,min=1,unique,dive,required,max=50,excludesall={}"`
+	Templates []CardTemplate `json:"templates" validate:"required,min=1,unique=Name,dive"`
+	Format    string         `json:"format,omitempty" validate:"omitempty,oneof=plain markdown"`
+}
+
+type NoteType struct {
+	ID        string         `json:"id" firestore:"-"`
+	OwnerID   string         `json:"owner_id" firestore:"owner_id"`
+	Name      string         `json:"name" firestore:"name"`
+	Fields    []string       `json:"fields" firestore:"fields"`
+	Templates []CardTemplate `json:"templates" firestore:"templates"`
+	Format    string         `json:"format,omitempty" firestore:"format,omitempty"`
+}
+
+type CreateNote struct {
+	NoteTypeID string            `json:"note_type_id" validate:"required"`
+	Fields     map[string]string `json:"fields" validate:"required"`
+	Tags       []string          `json:"tags,omitempty" validate:"omitempty,dive,required,max=50,excludesall=0x2C"`
+}
+
+type UpdateNote struct {
+	Fields map[string]string `json:"fields" validate:"required"`
+	Tags   []string          `json:"tags,omitempty" validate:"omitempty,dive,required,max=50,excludesall=0x2C"`
+}
+
+type Note struct {
+	ID         string            `json:"id" firestore:"-"`
+	NoteTypeID string            `json:"note_type_id" firestore:"note_type_id"`
+	Fields     map[string]string `json:"fields" firestore:"fields"`
+	Tags       []string          `json:"tags,omitempty" firestore:"tags,omitempty"`
+
+	// DeckID is only set when notes are read across decks
+	DeckID string `json:"-" firestore:"-"`
+}
+
+type NoteWithCards struct {
+	Note
+	Cards []NoteCard `json:"cards"`
+}
+
+type NotesResponse struct {
+	Notes   []Note `json:"notes"`
+	HasMore bool   `json:"has_more"`
+}
+
+type PreviewNote struct {
+	Fields map[string]string `json:"fields" validate:"required"`
+}
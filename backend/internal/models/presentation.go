@@ -0,0 +1,80 @@
+package models
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// Number of times the items of a card are shuffled while they are still in the answer order
+const maxShuffles = 5
+
+// PresentableCard is implemented by the card types that should be shown differently
+// from how they are stored, such as cards whose stored order gives away the answer.
+type PresentableCard interface {
+	Card
+
+	// Present returns the card as it should be shown to the learner.
+	Present() any
+}
+
+// PresentCards returns the cards as they should be shown to the learner,
+// keeping the cards without a special presentation as they are stored.
+func PresentCards(cards []Card) []any {
+	presented := make([]any, len(cards))
+	for i, card := range cards {
+		if presentable, ok := card.(PresentableCard); ok {
+			presented[i] = presentable.Present()
+		} else {
+			presented[i] = card
+		}
+	}
+	return presented
+}
+
+// MatchingPresentation is a matching card with both sides shuffled separately.
+type MatchingPresentation struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Question string   `json:"question"`
+	Left     []string `json:"left"`
+	Right    []string `json:"right"`
+	Format   string   `json:"format,omitempty"`
+}
+
+// Present shuffles both sides of the card, so the position of an item does not tell its match.
+func (m *MatchingCard) Present() any {
+	left := make([]string, len(m.Pairs))
+	right := make([]string, len(m.Pairs))
+	matches := make(map[string]string, len(m.Pairs))
+	for i, pair := range m.Pairs {
+		left[i] = pair.Left
+		right[i] = pair.Right
+		matches[pair.Left] = pair.Right
+	}
+
+	shuffle(left)
+
+	// Avoid showing every item right next to its match
+	for range maxShuffles {
+		shuffle(right)
+		if !slices.EqualFunc(left, right, func(l, r string) bool { return matches[l] == r }) {
+			break
+		}
+	}
+
+	return MatchingPresentation{
+		ID:       m.ID,
+		Type:     m.Type,
+		Question: m.Question,
+		Left:     left,
+		Right:    right,
+		Format:   m.Format,
+	}
+}
+
+// shuffle randomizes the order of the items in place.
+func shuffle(items []string) {
+	rand.Shuffle(len(items), func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CardRevision keeps the fields of a card as they were before a change,
+// along with the user who made the change and when.
+type CardRevision struct {
+	ID        string         `json:"id" firestore:"-"`
+	Fields    map[string]any `json:"fields" firestore:"fields"`
+	AuthorID  string         `json:"author_id" firestore:"author_id"`
+	CreatedAt time.Time      `json:"created_at" firestore:"created_at"`
+
+	// RestoredFrom is the revision the change restored, empty for edits
+	RestoredFrom string `json:"restored_from,omitempty" firestore:"restored_from,omitempty"`
+}
+
+// RevisionDiff lists the fields that differ between two revisions of a card.
+// To is "current" when comparing with the card as it is now.
+type RevisionDiff struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// CreateShareLink holds the options of a new share link of a deck.
+type CreateShareLink struct {
+	// Co-owners are never granted by link, only by email
+	Role      string     `json:"role" validate:"required,oneof=viewer editor"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Most users joining through the link, unlimited when 0
+	MaxUses int `json:"max_uses" validate:"gte=0"`
+}
+
+// ShareLink makes anyone with its token a member of a deck.
+// Only a hash of the token is stored, as the ID of the link.
+type ShareLink struct {
+	ID        string     `json:"id" firestore:"-"`
+	DeckID    string     `json:"deck_id" firestore:"deck_id"`
+	Role      string     `json:"role" firestore:"role"`
+	CreatedBy string     `json:"created_by" firestore:"created_by"`
+	CreatedAt time.Time  `json:"created_at" firestore:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" firestore:"expires_at,omitempty"`
+	MaxUses   int        `json:"max_uses,omitempty" firestore:"max_uses"`
+	Uses      int        `json:"uses" firestore:"uses"`
+}
+
+// CreatedShareLink is a new share link along with its token, which is never shown again.
+type CreatedShareLink struct {
+	ShareLink
+	Token string `json:"token"`
+}
+
+// ShareLinkRedemption tells the deck joined through a share link and the role on it.
+type ShareLinkRedemption struct {
+	DeckID string `json:"deck_id"`
+	Role   string `json:"role"`
+}
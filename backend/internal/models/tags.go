@@ -0,0 +1,69 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagFilter selects cards based on their tags.
+// A card matches when it has at least one of Any, every tag in All, and none of Not.
+type TagFilter struct {
+	Any []string
+	All []string
+	Not []string
+}
+
+// IsEmpty reports whether the filter matches every card.
+func (f TagFilter) IsEmpty() bool {
+	return len(f.Any) == 0 && len(f.All) == 0 && len(f.Not) == 0
+}
+
+// Matches reports whether a card with the given tags passes the filter.
+func (f TagFilter) Matches(tags []string) bool {
+	if len(f.Any) > 0 && !slices.ContainsFunc(f.Any, func(t string) bool {
+		return slices.Contains(tags, t)
+	}) {
+		return false
+	}
+
+	for _, t := range f.All {
+		if !slices.Contains(tags, t) {
+			return false
+		}
+	}
+
+	for _, t := range f.Not {
+		if slices.Contains(tags, t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Key returns a string identifying the filter, used as part of cache keys.
+func (f TagFilter) Key() string {
+	if f.IsEmpty() {
+		return ""
+	}
+	return "any=" + strings.Join(f.Any, ",") +
+		";all=" + strings.Join(f.All, ",") +
+		";not=" + strings.Join(f.Not, ",")
+}
+
+type UpdateTags struct {
+	Opp     string   `json:"opp" validate:"required,oneof=add remove rename"`
+	CardIDs []string `json:"card_ids" validate:"omitempty,dive,required"`
+	Tags    []string `json:"tags" validate:"required_unless=Opp rename,omitempty,dive,required,max=50,excludesall=0x2C"`
+	From    string   `json:"from" validate:"required_if=Opp rename"`
+	To      string   `json:"to" validate:"required_if=Opp rename,omitempty,max=50,excludesall=0x2C"`
+}
+
+type UpdateTagsResponse struct {
+	Updated int `json:"updated"`
+}
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,37 @@
+package models_test
+
+import (
+	"memora/internal/models"
+	"testing"
+)
+
+func TestTagFilterMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.TagFilter
+		tags   []string
+		want   bool
+	}{
+		{"empty filter matches untagged card", models.TagFilter{}, nil, true},
+		{"any matches one tag", models.TagFilter{Any: []string{"verbs", "nouns"}}, []string{"nouns"}, true},
+		{"any without a match", models.TagFilter{Any: []string{"verbs"}}, []string{"nouns"}, false},
+		{"all matches every tag", models.TagFilter{All: []string{"a", "b"}}, []string{"b", "a", "c"}, true},
+		{"all missing a tag", models.TagFilter{All: []string{"a", "b"}}, []string{"a"}, false},
+		{"not excludes tag", models.TagFilter{Not: []string{"hard"}}, []string{"easy", "hard"}, false},
+		{"not without the tag", models.TagFilter{Not: []string{"hard"}}, []string{"easy"}, true},
+		{
+			"combined expression",
+			models.TagFilter{Any: []string{"a", "b"}, All: []string{"c"}, Not: []string{"d"}},
+			[]string{"b", "c"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.tags); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
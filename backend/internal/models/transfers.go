@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// OwnershipTransfer is a pending handover of a deck to one of its members,
+// which the member has to accept.
+type OwnershipTransfer struct {
+	ToID        string    `json:"to_id" firestore:"to_id"`
+	ToEmail     string    `json:"to_email" firestore:"to_email"`
+	RequestedAt time.Time `json:"requested_at" firestore:"requested_at"`
+}
+
+// RequestTransfer chooses the member a deck is handed over to, by their user ID or email.
+type RequestTransfer struct {
+	Member string `json:"member" validate:"required"`
+}
+
+// TransferOffer is a deck a user was asked to take over.
+type TransferOffer struct {
+	DeckID      string    `json:"deck_id"`
+	DeckTitle   string    `json:"deck_title"`
+	OwnerID     string    `json:"owner_id"`
+	RequestedAt time.Time `json:"requested_at"`
+}
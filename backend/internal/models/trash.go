@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// DeletedDeck is a deck in the trash of its owner, it can be restored until it is purged.
+type DeletedDeck struct {
+	ID        string    `json:"id" firestore:"-"`
+	Title     string    `json:"title" firestore:"title"`
+	OwnerID   string    `json:"owner_id" firestore:"owner_id"`
+	DeletedBy string    `json:"deleted_by" firestore:"deleted_by"`
+	DeletedAt time.Time `json:"deleted_at" firestore:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at" firestore:"-"`
+}
+
+// DeletedCard is a card in the trash of its deck, it can be restored until it is purged.
+// Deleted notes are kept in the trash as an entry with the note instead of a card,
+// the cards generated from the note are in the trash on their own and come back with it.
+type DeletedCard struct {
+	ID        string         `json:"id" firestore:"-"`
+	Card      map[string]any `json:"card,omitempty" firestore:"card,omitempty"`
+	Note      *Note          `json:"note,omitempty" firestore:"note,omitempty"`
+	DeletedBy string         `json:"deleted_by" firestore:"deleted_by"`
+	DeletedAt time.Time      `json:"deleted_at" firestore:"deleted_at"`
+	PurgeAt   time.Time      `json:"purge_at" firestore:"-"`
+}
+
+// TrashPurge counts the decks and cards purged from the trash.
+type TrashPurge struct {
+	Decks int
+	Cards int
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DeckUpstream tells which deck a linked copy follows, and when it was last synced.
+type DeckUpstream struct {
+	DeckID   string    `json:"deck_id" firestore:"deck_id"`
+	SyncedAt time.Time `json:"synced_at" firestore:"synced_at"`
+}
+
+// CardUpstream links a card of a linked copy to its source card.
+type CardUpstream struct {
+	CardID string `json:"card_id" firestore:"card_id"`
+	// Hash is the hash of the content of both cards when last synced,
+	// telling which side changed since
+	Hash string `json:"hash" firestore:"hash"`
+}
+
+// UpstreamChange is a change of the source deck not pulled into the linked copy yet.
+type UpstreamChange struct {
+	// CardID is the ID of the card in the source deck
+	CardID      string `json:"card_id"`
+	LocalCardID string `json:"local_card_id,omitempty"`
+	// Status is added, modified or removed
+	Status string `json:"status"`
+	// Conflict is set when the local card was edited as well,
+	// pulling the change replaces the local edits
+	Conflict bool `json:"conflict"`
+	// Card is the card in the source deck, missing when it was removed
+	Card json.RawMessage `json:"card,omitempty" swaggertype:"object"`
+}
+
+// UpstreamChanges lists the changes of the source deck since the last sync.
+type UpstreamChanges struct {
+	DeckID   string           `json:"deck_id"`
+	SyncedAt time.Time        `json:"synced_at"`
+	Changes  []UpstreamChange `json:"changes"`
+}
+
+// UpstreamPull selects the changes to pull into a linked copy.
+type UpstreamPull struct {
+	// CardIDs are the IDs of the changed cards in the source deck
+	CardIDs []string `json:"card_ids" validate:"required,min=1,dive,required"`
+	// Overwrite pulls conflicting changes, replacing the local edits
+	Overwrite bool `json:"overwrite"`
+}
+
+// UpstreamPullResult reports the changes pulled into a linked copy.
+type UpstreamPullResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+	// Conflicts are the IDs of the cards left out, as they were edited locally
+	Conflicts []string `json:"conflicts"`
+}
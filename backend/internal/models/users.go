@@ -19,4 +19,6 @@ type PatchUser struct {
 type UserDecks struct {
 	OwnedDecks  []DisplayDeck `json:"owned_decks"`
 	SharedDecks []DisplayDeck `json:"shared_decks"`
+	// StudyDecks are the public decks the user added to their study list
+	StudyDecks []DisplayDeck `json:"study_decks"`
 }
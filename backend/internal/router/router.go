@@ -3,8 +3,10 @@ package router
 import (
 	"log/slog"
 	"memora/internal/config"
+	"memora/internal/handlers/classes"
 	"memora/internal/handlers/decks"
 	"memora/internal/handlers/docs"
+	"memora/internal/handlers/notetypes"
 	"memora/internal/handlers/status"
 	"memora/internal/handlers/users"
 	"memora/internal/middleware"
@@ -61,6 +63,112 @@ func Route(c *gin.Engine, services *services.Services) {
 				"/decks",
 				users.GetDecks(services.Users),
 			)
+			userRoute.GET(
+				"/invites",
+				users.GetInvites(services.Users),
+			)
+			userRoute.POST(
+				"/invites/:inviteID/accept",
+				users.AcceptInvite(services.Users),
+			)
+			userRoute.GET(
+				"/transfers",
+				users.GetTransferOffers(services.Users),
+			)
+			userRoute.GET(
+				"/assignments",
+				users.GetAssignments(services.Classes),
+			)
+		}
+
+		// Class endpoints, classes are taught by a user who assigns decks to their students
+		classRoute := v1.Group("/classes")
+		classRoute.Use(middleware.FirebaseAuthMiddleware(services.Auth))
+		classRoute.Use(middleware.RateLimit(utils.REQUESTS_PER_MINUTE, services.Rdb))
+		{
+			classRoute.GET(
+				"/",
+				classes.GetClasses(services.Classes),
+			)
+			classRoute.POST(
+				"/",
+				classes.CreateClass(services.Classes),
+			)
+			classRoute.POST(
+				"/join/:code",
+				classes.JoinClass(services.Classes),
+			)
+			classRoute.GET(
+				"/invites",
+				classes.GetClassInvites(services.Classes),
+			)
+			classRoute.GET(
+				"/:classID",
+				classes.GetClass(services.Classes),
+			)
+			classRoute.DELETE(
+				"/:classID",
+				classes.DeleteClass(services.Classes),
+			)
+			classRoute.POST(
+				"/:classID/students",
+				classes.InviteStudents(services.Classes),
+			)
+			classRoute.POST(
+				"/:classID/accept",
+				classes.AcceptClassInvite(services.Classes),
+			)
+			classRoute.DELETE(
+				"/:classID/students/:studentID",
+				classes.RemoveStudent(services.Classes),
+			)
+			classRoute.POST(
+				"/:classID/assignments",
+				classes.AssignDeck(services.Classes),
+			)
+			classRoute.GET(
+				"/:classID/assignments",
+				classes.GetAssignments(services.Classes),
+			)
+			classRoute.DELETE(
+				"/:classID/assignments/:assignmentID",
+				classes.DeleteAssignment(services.Classes),
+			)
+			classRoute.GET(
+				"/:classID/progress",
+				classes.GetClassProgress(services.Classes),
+			)
+		}
+
+		// Note type endpoints, note types belong to a user and are used by notes in any deck
+		noteTypeRoute := v1.Group("/note-types")
+		noteTypeRoute.Use(middleware.FirebaseAuthMiddleware(services.Auth))
+		noteTypeRoute.Use(middleware.RateLimit(utils.REQUESTS_PER_MINUTE, services.Rdb))
+		{
+			noteTypeRoute.GET(
+				"/",
+				notetypes.GetNoteTypes(services.Notes),
+			)
+			noteTypeRoute.POST(
+				"/",
+				notetypes.CreateNoteType(services.Notes),
+			)
+			noteTypeRoute.GET(
+				"/:noteTypeID",
+				notetypes.GetNoteType(services.Notes),
+			)
+			noteTypeRoute.PUT(
+				"/:noteTypeID",
+				notetypes.UpdateNoteType(services.Notes),
+			)
+			noteTypeRoute.DELETE(
+				"/:noteTypeID",
+				notetypes.DeleteNoteType(services.Notes),
+			)
+			noteTypeRoute.POST(
+				"/:noteTypeID/preview",
+				notetypes.PreviewNoteType(services.Notes),
+			)
 		}
 
 		// Deck-related endpoints
@@ -69,64 +177,310 @@ func Route(c *gin.Engine, services *services.Services) {
 		deckRoute.Use(middleware.RateLimit(utils.REQUESTS_PER_MINUTE, services.Rdb))
 		{
 			deckRoute.GET(
-				"/:deckID",
-				decks.GetDeck(services.Decks),
+				"/library",
+				decks.ListPublicDecks(services.Decks),
 			)
 			deckRoute.POST(
 				"/",
 				decks.CreateDeck(services.Decks),
 			)
-			deckRoute.DELETE(
-				"/:deckID",
-				decks.DeleteDeck(services.Decks),
+			deckRoute.POST(
+				"/import",
+				decks.ImportDeck(services.Decks),
 			)
-
-			// PATCH routes for updating specific fields of a deck
-			deckRoute.PATCH(
-				"/:deckID",
-				decks.PatchDeck(services.Decks),
+			deckRoute.POST(
+				"/import/anki",
+				decks.ImportAnkiDeck(services.Decks),
 			)
-			deckRoute.PATCH(
-				"/:deckID/emails",
-				decks.UpdateEmails(services.Decks),
+			deckRoute.POST(
+				"/join/:token",
+				decks.RedeemShareLink(services.Decks),
+			)
+			deckRoute.GET(
+				"/trash",
+				decks.GetDeletedDecks(services.Decks),
+			)
+			// Only the owner of a deleted deck can restore it, which is checked against the trash
+			deckRoute.POST(
+				"/trash/:deckID/restore",
+				decks.RestoreDeck(services.Decks),
 			)
 
-			cardRoute := deckRoute.Group("/:deckID/cards")
+			// Endpoints of one deck, the role of the user on the deck is resolved once
+			// and checked against the least role each endpoint requires
+			oneDeck := deckRoute.Group("/:deckID")
+			oneDeck.Use(middleware.DeckAccess(services.Decks))
 			{
-				cardRoute.GET(
-					"/due",
-					decks.GetDueCardsInDeck(services.Decks),
+				oneDeck.GET(
+					"",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.GetDeck(services.Decks),
+				)
+				oneDeck.DELETE(
+					"",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.DeleteDeck(services.Decks),
+				)
+				oneDeck.PATCH(
+					"",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.PatchDeck(services.Decks),
 				)
-				cardRoute.GET(
-					"/",
-					decks.GetCardsInDeck(services.Decks),
+				oneDeck.GET(
+					"/audit",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.GetAuditLog(services.Decks),
 				)
-				cardRoute.POST(
-					"/",
-					decks.CreateCardInDeck(services.Decks),
+				oneDeck.GET(
+					"/trash",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.GetDeletedCards(services.Decks),
 				)
-				cardRoute.GET(
-					"/:cardID",
-					decks.GetCardInDeck(services.Decks),
+				oneDeck.POST(
+					"/trash/:cardID/restore",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.RestoreCard(services.Decks),
 				)
-				cardRoute.PUT(
-					"/:cardID",
-					decks.UpdateCard(services.Decks),
+				oneDeck.POST(
+					"/transfer",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.RequestTransfer(services.Decks),
 				)
-				cardRoute.DELETE(
-					"/:cardID",
-					decks.DeleteCardInDeck(services.Decks),
+				oneDeck.DELETE(
+					"/transfer",
+					middleware.RequireDeckRole(utils.ROLE_VIEWER),
+					decks.CancelTransfer(services.Decks),
 				)
-				progress := cardRoute.Group("/:cardID/progress")
+				oneDeck.POST(
+					"/transfer/accept",
+					middleware.RequireDeckRole(utils.ROLE_VIEWER),
+					decks.AcceptTransfer(services.Decks),
+				)
+				oneDeck.PATCH(
+					"/emails",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.UpdateEmails(services.Decks),
+				)
+				oneDeck.GET(
+					"/members",
+					middleware.RequireDeckRole(utils.ROLE_VIEWER),
+					decks.GetDeckMembers(services.Decks),
+				)
+				oneDeck.PUT(
+					"/members/:member",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.UpdateDeckMember(services.Decks),
+				)
+				oneDeck.DELETE(
+					"/members/:member",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.RemoveDeckMember(services.Decks),
+				)
+				oneDeck.GET(
+					"/invites",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.GetDeckInvites(services.Decks),
+				)
+				oneDeck.DELETE(
+					"/invites/:inviteID",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.RevokeInvite(services.Decks),
+				)
+				oneDeck.POST(
+					"/links",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.CreateShareLink(services.Decks),
+				)
+				oneDeck.GET(
+					"/links",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.GetShareLinks(services.Decks),
+				)
+				oneDeck.DELETE(
+					"/links/:linkID",
+					middleware.RequireDeckRole(utils.ROLE_CO_OWNER),
+					decks.RevokeShareLink(services.Decks),
+				)
+				oneDeck.GET(
+					"/tags",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.GetTags(services.Decks),
+				)
+				oneDeck.PATCH(
+					"/tags",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.UpdateTags(services.Decks),
+				)
+				oneDeck.PUT(
+					"/publish",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.PublishDeck(services.Decks),
+				)
+				oneDeck.DELETE(
+					"/publish",
+					middleware.RequireDeckRole(utils.ROLE_OWNER),
+					decks.UnpublishDeck(services.Decks),
+				)
+				oneDeck.POST(
+					"/study",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.AddToStudyList(services.Decks),
+				)
+				// Anyone can remove a deck from their study list, even once it is no longer public
+				oneDeck.DELETE(
+					"/study",
+					decks.RemoveFromStudyList(services.Decks),
+				)
+				oneDeck.POST(
+					"/fork",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.ForkDeck(services.Decks),
+				)
+				oneDeck.POST(
+					"/subscribe",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.SubscribeToDeck(services.Decks),
+				)
+				oneDeck.GET(
+					"/upstream/changes",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.GetUpstreamChanges(services.Decks),
+				)
+				oneDeck.POST(
+					"/upstream/pull",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.PullUpstreamChanges(services.Decks),
+				)
+				oneDeck.GET(
+					"/export",
+					middleware.RequireDeckRole(utils.ROLE_READER),
+					decks.ExportDeck(services.Decks),
+				)
+				oneDeck.POST(
+					"/import/csv",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.ImportCSV(services.Decks),
+				)
+				oneDeck.POST(
+					"/import/markdown",
+					middleware.RequireDeckRole(utils.ROLE_EDITOR),
+					decks.ImportMarkdown(services.Decks),
+				)
+
+				noteRoute := oneDeck.Group("/notes")
 				{
-					progress.GET(
+					noteRoute.GET(
+						"/",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetNotesInDeck(services.Decks),
+					)
+					noteRoute.POST(
 						"/",
-						decks.GetProgress(services.Decks),
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.CreateNoteInDeck(services.Decks),
+					)
+					noteRoute.GET(
+						"/:noteID",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetNoteInDeck(services.Decks),
+					)
+					noteRoute.PUT(
+						"/:noteID",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.UpdateNoteInDeck(services.Decks),
+					)
+					noteRoute.DELETE(
+						"/:noteID",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.DeleteNoteInDeck(services.Decks),
 					)
-					progress.PUT(
+					noteRoute.POST(
+						"/:noteID/restore",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.RestoreNoteInDeck(services.Decks),
+					)
+				}
+
+				cardRoute := oneDeck.Group("/cards")
+				{
+					cardRoute.GET(
+						"/due",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetDueCardsInDeck(services.Decks),
+					)
+					cardRoute.GET(
 						"/",
-						decks.UpdateProgress(services.Decks),
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetCardsInDeck(services.Decks),
+					)
+					cardRoute.POST(
+						"/",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.CreateCardInDeck(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetCardInDeck(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID/html",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetCardHTMLInDeck(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID/highlight",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GetCodeHighlightInDeck(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID/presentation",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.PresentCardInDeck(services.Decks),
+					)
+					cardRoute.POST(
+						"/:cardID/grade",
+						middleware.RequireDeckRole(utils.ROLE_READER),
+						decks.GradeCard(services.Decks),
+					)
+					cardRoute.PUT(
+						"/:cardID",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.UpdateCard(services.Decks),
+					)
+					cardRoute.DELETE(
+						"/:cardID",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.DeleteCardInDeck(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID/revisions",
+						middleware.RequireDeckRole(utils.ROLE_VIEWER),
+						decks.GetCardRevisions(services.Decks),
+					)
+					cardRoute.GET(
+						"/:cardID/revisions/diff",
+						middleware.RequireDeckRole(utils.ROLE_VIEWER),
+						decks.DiffCardRevisions(services.Decks),
+					)
+					cardRoute.POST(
+						"/:cardID/revisions/:revisionID/restore",
+						middleware.RequireDeckRole(utils.ROLE_EDITOR),
+						decks.RestoreCardRevision(services.Decks),
 					)
+					progress := cardRoute.Group("/:cardID/progress")
+					{
+						progress.GET(
+							"/",
+							middleware.RequireDeckRole(utils.ROLE_READER),
+							decks.GetProgress(services.Decks),
+						)
+						progress.PUT(
+							"/",
+							middleware.RequireDeckRole(utils.ROLE_READER),
+							decks.UpdateProgress(services.Decks),
+						)
+					}
 				}
 			}
 		}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"memora/internal/firebase"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"time"
+)
+
+// Longest wait for the audit log to be written after a change
+const AuditOpTimeout = 10 * time.Second
+
+// AuditService records the changes made to decks and their cards, and lists them.
+type AuditService struct {
+	repo firebase.AuditRepository
+}
+
+// NewAuditService creates a new instance of AuditService.
+func NewAuditService(deps *ServiceDeps) *AuditService {
+	return &AuditService{repo: deps.AuditRepo}
+}
+
+// Record stores events in the audit log of a deck, made by the user and in the request
+// of the context. Failing to store them is only logged, as the changes are already made.
+func (s *AuditService) Record(ctx context.Context, deckID string, events ...models.AuditEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	// The log is written even if the client is gone, as the change was made
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), AuditOpTimeout)
+	defer cancel()
+
+	actorID := utils.UserIDFromContext(ctx)
+	reqID := utils.RequestIDFromContext(ctx)
+	now := time.Now().UTC()
+	for i := range events {
+		events[i].ActorID = actorID
+		events[i].RequestID = reqID
+		events[i].CreatedAt = now
+	}
+
+	if err := s.repo.AddEvents(ctx, deckID, events); err != nil {
+		slog.Error("failed to record audit events",
+			"reqID", reqID,
+			"deckID", deckID,
+			"action", events[0].Action,
+			"error", err,
+		)
+	}
+}
+
+// GetAuditLog lists a page of the audit log of a deck, the latest events first.
+// Returns the events or an error if the cursor is not valid or the operation fails.
+func (s *AuditService) GetAuditLog(
+	ctx context.Context,
+	deckID, limitStr, cursor string,
+) (models.AuditLogResponse, error) {
+	events, hasMore, err := s.repo.GetEvents(ctx, deckID, utils.ParseLimit(limitStr), cursor)
+	if err != nil {
+		return models.AuditLogResponse{}, err
+	}
+
+	response := models.AuditLogResponse{Events: events, HasMore: hasMore}
+	if hasMore {
+		response.Cursor = events[len(events)-1].ID
+	}
+
+	return response, nil
+}
+
+// auditEvent describes a change to a deck or one of its cards by the fields that differ
+// between the document before and after it. Documents created have no before,
+// and documents deleted no after.
+func auditEvent(action, cardID string, before, after any) models.AuditEvent {
+	changes, err := models.DiffFields(before, after)
+	if err != nil {
+		slog.Error("failed to diff audited document", "action", action, "error", err)
+	}
+
+	return models.AuditEvent{Action: action, CardID: cardID, Changes: changes}
+}
+
+// recordCardChanges records the cards of a deck changed in bulk in its audit log,
+// diffing the stored cards before the change with the cards after it.
+// Cards missing before were created, and cards missing after were deleted.
+func (s *AuditService) recordCardChanges(
+	ctx context.Context,
+	deckID string,
+	before map[string]map[string]any,
+	after map[string]any,
+) {
+	ids := slices.Collect(maps.Keys(after))
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	events := make([]models.AuditEvent, 0, len(ids))
+	for _, id := range ids {
+		old, existed := before[id]
+		card, exists := after[id]
+
+		var event models.AuditEvent
+		switch {
+		case !existed:
+			event = auditEvent(utils.AUDIT_CARD_CREATE, id, nil, card)
+		case !exists:
+			event = auditEvent(utils.AUDIT_CARD_DELETE, id, old, nil)
+		default:
+			event = auditEvent(utils.AUDIT_CARD_UPDATE, id, old, card)
+		}
+
+		// Cards written again as they were did not change
+		if event.Action == utils.AUDIT_CARD_UPDATE && len(event.Changes) == 0 {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	s.Record(ctx, deckID, events...)
+}
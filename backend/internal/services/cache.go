@@ -15,6 +15,7 @@ const (
 	CardTTL     = 10 * time.Minute
 	DeckListTTL = 2 * time.Minute
 	CardListTTL = 2 * time.Minute
+	RoleTTL     = 2 * time.Minute
 
 	CacheOpTimeout = 5 * time.Second
 )
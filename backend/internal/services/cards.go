@@ -4,21 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"memora/internal/errors"
 	"memora/internal/firebase"
 	"memora/internal/models"
 	"memora/internal/utils"
+	"slices"
 	"strconv"
 	"time"
 
+	"cloud.google.com/go/firestore"
 	"github.com/go-playground/validator/v10"
 )
 
 // Used to get the type of card based on request
 var cardRegistry = map[string]func() models.Card{
 	utils.BLANKS_CARD:          func() models.Card { return &models.BlanksCard{} },
+	utils.CODE_CARD:            func() models.Card { return &models.CodeCard{} },
 	utils.FRONT_BACK_CARD:      func() models.Card { return &models.FrontBackCard{} },
+	utils.MATCHING_CARD:        func() models.Card { return &models.MatchingCard{} },
 	utils.MULTIPLE_CHOICE_CARD: func() models.Card { return &models.MultipleChoiceCard{} },
+	utils.NOTE_CARD:            func() models.Card { return &models.NoteCard{} },
+	utils.NUMERIC_CARD:         func() models.Card { return &models.NumericCard{} },
 	utils.ORDERED_CARD:         func() models.Card { return &models.OrderedCard{} },
 }
 
@@ -26,6 +34,7 @@ var cardRegistry = map[string]func() models.Card{
 type CardService struct {
 	repo     firebase.CardRepository
 	cache    *CacheService
+	audit    *AuditService
 	validate *validator.Validate
 }
 
@@ -36,6 +45,7 @@ func NewCardService(
 	return &CardService{
 		repo:     deps.CardRepo,
 		cache:    deps.Cache,
+		audit:    NewAuditService(deps),
 		validate: deps.Validate,
 	}
 }
@@ -81,11 +91,13 @@ func (s *CardService) GetCardInDeck(
 
 // GetCardsInDeck retrieves all cards in a specified deck with cursor-based pagination.
 // cursor is the ID of the last card from the previous page (empty string for first page)
+// Only cards matching the tag filter are returned.
 // Returns a list of cards or an error if the operation fails.
 func (s *CardService) GetCardsInDeck(
 	ctx context.Context,
 	deckID, limit_str string,
 	cursor string,
+	filter models.TagFilter,
 ) ([]models.Card, bool, error) {
 	limit := utils.ParseLimit(limit_str)
 
@@ -93,6 +105,9 @@ func (s *CardService) GetCardsInDeck(
 	if cursor != "" {
 		cacheKey = fmt.Sprintf("%s:cursor:%s", cacheKey, cursor)
 	}
+	if !filter.IsEmpty() {
+		cacheKey = fmt.Sprintf("%s:tags:%s", cacheKey, filter.Key())
+	}
 
 	// Try cache first
 	var cached models.CacheResult
@@ -109,7 +124,7 @@ func (s *CardService) GetCardsInDeck(
 	}
 
 	// Cache miss - fetch from Firestore
-	docs, hasMore, err := s.repo.GetCardsInDeck(ctx, deckID, limit, cursor)
+	docs, hasMore, err := s.repo.GetCardsInDeck(ctx, deckID, limit, cursor, filter)
 	if err != nil {
 		return nil, false, err
 	}
@@ -143,6 +158,26 @@ func (s *CardService) GetCardsInDeck(
 	return cards, hasMore, nil
 }
 
+// CountCards counts the cards in a deck.
+// Returns the number of cards or an error if the operation fails.
+func (s *CardService) CountCards(ctx context.Context, deckID string) (int, error) {
+	// Cached with the card lists, so it is invalidated along with them
+	cacheKey := utils.DeckCardsKey(deckID) + ":count"
+	var count int
+	if err := s.cache.Get(ctx, cacheKey, &count); err == nil {
+		return count, nil
+	}
+
+	count, err := s.repo.CountCards(ctx, deckID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.cache.SetAsync(cacheKey, count, CardListTTL)
+
+	return count, nil
+}
+
 // CreateCard creates a new card from the provided raw JSON data.
 // Validates the card and returns its ID or an error if the operation fails.
 func (s *CardService) CreateCard(
@@ -156,8 +191,8 @@ func (s *CardService) CreateCard(
 		return "", err
 	}
 
-	if err := s.validate.Struct(card); err != nil {
-		return "", errors.ErrInvalidCard
+	if err := s.prepareNewCard(card); err != nil {
+		return "", err
 	}
 
 	id, err := s.repo.CreateCard(ctx, card, deckID)
@@ -166,16 +201,140 @@ func (s *CardService) CreateCard(
 	}
 
 	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_CARD_CREATE, id, nil, card))
 
 	return id, nil
 }
 
+// CreateCards validates and creates several cards in a deck at once.
+// Nothing is created if one of the cards is not valid.
+// Returns the IDs in the order of the cards, or an error if the operation fails.
+func (s *CardService) CreateCards(
+	ctx context.Context,
+	deckID string,
+	cards []models.Card,
+) ([]string, error) {
+	docs := make([]any, 0, len(cards))
+	for _, card := range cards {
+		if err := s.prepareNewCard(card); err != nil {
+			return nil, err
+		}
+		docs = append(docs, card)
+	}
+
+	ids, err := s.repo.CreateCards(ctx, deckID, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+
+	created := make(map[string]any, len(ids))
+	for i, id := range ids {
+		created[id] = docs[i]
+	}
+	s.audit.recordCardChanges(ctx, deckID, nil, created)
+
+	return ids, nil
+}
+
+// ReplaceCards validates and overwrites several cards of a deck at once, keyed by card ID.
+// Nothing is written if one of the cards is not valid.
+// Returns an error if the operation fails.
+func (s *CardService) ReplaceCards(
+	ctx context.Context,
+	deckID string,
+	cards map[string]models.Card,
+) error {
+	docs := make(map[string]any, len(cards))
+	for id, card := range cards {
+		if err := s.prepareNewCard(card); err != nil {
+			return err
+		}
+		docs[id] = card
+	}
+
+	// The cards are overwritten, so they are read first to record what changed
+	before, err := s.repo.GetCardsByID(ctx, deckID, slices.Collect(maps.Keys(docs)))
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.ReplaceCards(ctx, deckID, docs, newRevision(ctx, "")); err != nil {
+		return err
+	}
+
+	for id := range cards {
+		s.cache.Delete(ctx, utils.DeckCardKey(deckID, id))
+	}
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.recordCardChanges(ctx, deckID, before, docs)
+
+	return nil
+}
+
+// DeleteCards moves several cards of a deck to its trash at once.
+// Returns an error if the operation fails.
+func (s *CardService) DeleteCards(
+	ctx context.Context,
+	deckID string,
+	cardIDs []string,
+) error {
+	before, err := s.repo.GetCardsByID(ctx, deckID, cardIDs)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteCards(ctx, deckID, cardIDs, utils.UserIDFromContext(ctx), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range cardIDs {
+		s.cache.Delete(ctx, utils.DeckCardKey(deckID, id))
+	}
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.recordCardChanges(ctx, deckID, before, nil)
+
+	return nil
+}
+
+// prepareNewCard normalizes the tags, sanitizes and validates a card about to be created.
+// Returns an error if the card is not valid.
+func (s *CardService) prepareNewCard(card models.Card) error {
+	// Note cards are only created by saving their note
+	if card.GetType() == utils.NOTE_CARD {
+		return errors.ErrInvalidCard
+	}
+
+	card.Meta().Tags = normalizeTags(card.Meta().Tags)
+
+	// Validated after sanitizing, as removing HTML can leave required fields empty
+	if err := prepareContent(card, card.Meta().Format); err != nil {
+		return err
+	}
+
+	return s.validateCard(card)
+}
+
 // UpdateCard updates an existing card identified by its ID with the provided raw JSON data.
+// The fields replaced are kept as a revision of the card.
 // Validates the updated card and returns the updated card or an error if the operation fails.
-func (s CardService) UpdateCard(
+func (s *CardService) UpdateCard(
 	ctx context.Context,
 	rawData []byte,
 	deckID, cardID string,
+) error {
+	return s.updateCard(ctx, rawData, deckID, cardID, "")
+}
+
+// updateCard validates and applies an update of a card, keeping the fields it replaces
+// as a revision. restoredFrom is the revision the update restores, empty for edits.
+// Returns an error if the card is not valid or the operation fails.
+func (s *CardService) updateCard(
+	ctx context.Context,
+	rawData []byte,
+	deckID, cardID, restoredFrom string,
 ) error {
 	card, err := GetCardStruct(rawData, errors.ErrInvalidCard)
 	if err != nil {
@@ -193,12 +352,31 @@ func (s CardService) UpdateCard(
 		return fmt.Errorf("internal server error")
 	}
 
-	if t != card.GetType() {
+	// Note cards are only changed by updating their note
+	if t != card.GetType() || t == utils.NOTE_CARD {
 		return errors.ErrInvalidCard
 	}
 
-	if err := s.validate.Struct(card); err != nil {
-		return errors.ErrInvalidCard
+	card.Meta().Tags = normalizeTags(card.Meta().Tags)
+
+	// Keep using the stored format when the update does not change it
+	format := card.Meta().Format
+	if format == "" {
+		format, _ = originalCard["format"].(string)
+	}
+
+	// Validated after sanitizing, as removing HTML can leave required fields empty
+	if err := prepareContent(card, format); err != nil {
+		return err
+	}
+
+	if err := s.validateCard(card); err != nil {
+		return err
+	}
+
+	// The review direction only describes due queue items and is never stored
+	if frontBack, ok := card.(*models.FrontBackCard); ok {
+		frontBack.ReviewDirection = ""
 	}
 
 	// Convert the updated card struct to firestore updates
@@ -207,33 +385,174 @@ func (s CardService) UpdateCard(
 		return errors.ErrInvalidCard
 	}
 
-	// Perform the update in the repository
-	err = s.repo.UpdateCard(ctx, update, deckID, cardID)
+	// Zero is a valid answer and tolerance, which StructToUpdate skips
+	if numeric, ok := card.(*models.NumericCard); ok {
+		update = utils.SetUpdate(update, "answer", *numeric.Answer)
+		update = utils.SetUpdate(update, "tolerance", numeric.Tolerance)
+	}
+
+	// No highlighted lines and no explanation are valid too, and clear the stored ones
+	if code, ok := card.(*models.CodeCard); ok {
+		update = utils.SetUpdate(update, "highlight_from", code.HighlightFrom)
+		update = utils.SetUpdate(update, "highlight_to", code.HighlightTo)
+		update = utils.SetUpdate(update, "explanation", code.Explanation)
+
+		// The highlighted lines have to exist in the code of the card as it is stored after the update
+		if err := s.validateMergedCard(originalCard, update); err != nil {
+			return err
+		}
+	}
+
+	revision := newRevision(ctx, restoredFrom)
+
+	// A restore writes every field of the revision, so fields it left empty are cleared too
+	var previous map[string]any
+	var after any
+	if restoredFrom != "" {
+		previous, err = s.repo.ReplaceCard(ctx, card, deckID, cardID, revision)
+		after = card
+	} else {
+		previous, err = s.repo.UpdateCard(ctx, update, deckID, cardID, revision)
+		after = applyUpdates(previous, update)
+	}
 	if err != nil {
 		return err
 	}
 
+	s.cache.Delete(ctx, utils.DeckCardKey(deckID, cardID))
 	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_CARD_UPDATE, cardID, previous, after))
 
 	return nil
 }
 
-// DeleteCard deletes a card by its ID.
+// DeleteCard moves a card to the trash of its deck by its ID, it can be restored
+// until the trash is purged of it.
 // Returns an error if the operation fails or the card is not found.
 func (s *CardService) DeleteCard(
 	ctx context.Context,
 	deckID, cardID string,
 ) error {
-	err := s.repo.DeleteCard(ctx, deckID, cardID)
+	card, err := s.repo.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return err
+	}
+
+	// Note cards are only deleted along with their note
+	if card["type"] == utils.NOTE_CARD {
+		return errors.ErrInvalidCard
+	}
+
+	err = s.repo.DeleteCard(ctx, deckID, cardID, utils.UserIDFromContext(ctx), time.Now().UTC())
 	if err != nil {
 		return err
 	}
 
+	s.cache.Delete(ctx, utils.DeckCardKey(deckID, cardID))
 	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_CARD_DELETE, cardID, card, nil))
+
+	return nil
+}
+
+// validateMergedCard validates a stored card as it is after updates of its fields,
+// so the fields the updates leave out are checked along with the updated ones.
+// Returns an error if the merged card is not valid.
+func (s *CardService) validateMergedCard(card map[string]any, updates []firestore.Update) error {
+	rawData, err := json.Marshal(applyUpdates(card, updates))
+	if err != nil {
+		return errors.ErrInvalidCard
+	}
+
+	merged, err := GetCardStruct(rawData, errors.ErrInvalidCard)
+	if err != nil {
+		return errors.ErrInvalidCard
+	}
+
+	return s.validateCard(merged)
+}
+
+// applyUpdates returns a copy of a stored card with updates of its fields applied,
+// as the card is stored after them.
+func applyUpdates(card map[string]any, updates []firestore.Update) map[string]any {
+	updated := make(map[string]any, len(card)+len(updates))
+	maps.Copy(updated, card)
+	for _, update := range updates {
+		updated[update.Path] = update.Value
+	}
+	return updated
+}
+
+// validateCard validates a card, including the rules its struct tags can not express.
+// Returns an error if the card is not valid.
+func (s *CardService) validateCard(card models.Card) error {
+	// The validation errors are kept, so imports can report the fields at fault
+	if err := s.validate.Struct(card); err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrInvalidCard, err)
+	}
+
+	switch c := card.(type) {
+	case *models.NumericCard:
+		// A relative tolerance is a fraction of the answer
+		if c.ToleranceMode == utils.TOLERANCE_RELATIVE && c.Tolerance > 1 {
+			return errors.ErrInvalidCard
+		}
+		if _, err := utils.ParseUnit(c.Unit); err != nil {
+			return errors.ErrInvalidCard
+		}
+	case *models.CodeCard:
+		if !utils.IsKnownLanguage(c.Language) || c.HighlightTo > utils.CountLines(c.Code) {
+			return errors.ErrInvalidCard
+		}
+	}
 
 	return nil
 }
 
+// GradeCard checks an answer to a card that can be graded.
+// Returns the result, or an error if the card can not be graded or the answer can not be read.
+func (s *CardService) GradeCard(
+	ctx context.Context,
+	deckID, cardID string,
+	answer models.GradeAnswer,
+) (models.GradeResult, error) {
+	card, err := s.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return models.GradeResult{}, err
+	}
+
+	gradable, ok := card.(models.GradableCard)
+	if !ok {
+		return models.GradeResult{}, errors.ErrInvalidCard
+	}
+
+	result, err := gradable.Grade(answer)
+	if err != nil {
+		return models.GradeResult{}, errors.ErrInvalidAnswer
+	}
+
+	return result, nil
+}
+
+// PresentCard retrieves a card as it should be shown to the learner.
+// Cards without a special presentation are returned as they are stored.
+// Returns the presentation or an error if the operation fails.
+func (s *CardService) PresentCard(
+	ctx context.Context,
+	deckID, cardID string,
+) (any, error) {
+	card, err := s.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if presentable, ok := card.(models.PresentableCard); ok {
+		return presentable.Present(), nil
+	}
+
+	return card, nil
+}
+
 // GetCardStruct takes a byte array and an error to return if the type is not found.
 // It returns a card struct of the appropriate type based on the "type" field in the JSON data.
 func GetCardStruct(
@@ -263,13 +582,58 @@ func GetCardStruct(
 	return card, nil
 }
 
+// GetCardProgress retrieves a user's progress on a card reviewed in the given direction,
+// the first one the card is reviewed in when empty.
+// Returns the progress or an error if the direction is invalid or the progress is not found.
 func (s *CardService) GetCardProgress(
 	ctx context.Context,
-	deckID, cardID, userID string,
+	deckID, cardID, userID, direction string,
 ) (models.CardProgress, error) {
-	return s.repo.GetCardProgress(ctx, deckID, cardID, userID)
+	// Without a direction, the progress is the one ratings are stored under by default
+	if direction == "" {
+		resolved, err := s.ResolveReviewDirection(ctx, deckID, cardID, direction)
+		if err != nil {
+			return models.CardProgress{}, err
+		}
+		direction = resolved
+	}
+
+	if err := s.validate.Var(direction, "oneof=forward reverse"); err != nil {
+		return models.CardProgress{}, errors.ErrInvalidCard
+	}
+
+	return s.repo.GetCardProgress(ctx, deckID, utils.ProgressID(cardID, direction), userID)
 }
 
+// ResolveReviewDirection checks that a card is reviewed in a direction, so it can be rated in it.
+// Without a direction, the first one the card is reviewed in is used.
+// Returns the direction, or an error if the card does not exist or is not reviewed in it.
+func (s *CardService) ResolveReviewDirection(
+	ctx context.Context,
+	deckID, cardID, direction string,
+) (string, error) {
+	card, err := s.repo.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return "", err
+	}
+
+	directions := utils.ReviewDirections(card)
+	if direction == "" {
+		return directions[0], nil
+	}
+	if !slices.Contains(directions, direction) {
+		return "", errors.ErrInvalidUser
+	}
+	return direction, nil
+}
+
+// Longest wait for the progress of a rated card to be written
+const ProgressOpTimeout = 30 * time.Second
+
+// UpdateCardProgress rates a card for a user in the direction of the rating, the first one
+// the card is reviewed in when left out. The rating is checked before returning, while the
+// progress is written in the background as the caller does not wait for it.
+// Returns an error if the rating is invalid or the card is not reviewed in its direction.
 func (s *CardService) UpdateCardProgress(
 	ctx context.Context,
 	deckID, cardID, userID string,
@@ -279,7 +643,32 @@ func (s *CardService) UpdateCardProgress(
 		return errors.ErrInvalidUser
 	}
 
-	progress, err := s.GetCardProgress(ctx, deckID, cardID, userID)
+	// Each direction of a card keeps its own progress
+	direction, err := s.ResolveReviewDirection(ctx, deckID, cardID, rating.Direction)
+	if err != nil {
+		return err
+	}
+
+	// The progress is written even if the client is gone, as the rating was accepted
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ProgressOpTimeout)
+		defer cancel()
+
+		if err := s.rateCard(ctx, deckID, cardID, userID, direction, rating.Rating); err != nil {
+			slog.Error("failed to update card progress",
+				"deckID", deckID, "cardID", cardID, "direction", direction, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// rateCard schedules the next review of a card in a resolved direction from a rating.
+func (s *CardService) rateCard(
+	ctx context.Context,
+	deckID, cardID, userID, direction, rating string,
+) error {
+	progress, err := s.repo.GetCardProgress(ctx, deckID, utils.ProgressID(cardID, direction), userID)
 	if err != nil {
 		if err == errors.ErrInvalidId {
 			progress = models.CardProgress{
@@ -301,7 +690,7 @@ func (s *CardService) UpdateCardProgress(
 	lapses := progress.Lapses
 	interval := float64(progress.Interval)
 
-	switch rating.Rating {
+	switch rating {
 	case "again":
 		easeFactor -= 200
 		lapses += 1
@@ -335,16 +724,21 @@ func (s *CardService) UpdateCardProgress(
 	progress.LastReviewed = now
 	progress.Due = now.Add(time.Duration(interval*24) * time.Hour)
 
-	return s.repo.UpdateProgress(ctx, deckID, cardID, userID, progress)
+	return s.repo.UpdateProgress(ctx, deckID, utils.ProgressID(cardID, direction), userID, progress)
 }
 
+// GetDueCardsInDeck retrieves the cards a user should review, unstudied cards first.
+// Only cards matching the tag filter are returned.
+// Cards are returned as presented by PresentCard, so they do not give away their answers.
+// Returns the cards and the cursor for the next page, or an error if the operation fails.
 func (s *CardService) GetDueCardsInDeck(
 	ctx context.Context,
 	deckID, userID string,
 	limit, cursor string,
-) ([]models.Card, string, bool, error) {
+	filter models.TagFilter,
+) ([]any, string, bool, error) {
 	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
+	if err != nil || limitInt < 1 {
 		return nil, "", false, errors.ErrInvalidUser
 	}
 
@@ -354,6 +748,7 @@ func (s *CardService) GetDueCardsInDeck(
 		userID,
 		limitInt,
 		cursor,
+		filter,
 	)
 	if err != nil {
 		return nil, "", false, err
@@ -375,5 +770,5 @@ func (s *CardService) GetDueCardsInDeck(
 		cards = append(cards, card)
 	}
 
-	return cards, nextCursor, hasMore, nil
+	return models.PresentCards(cards), nextCursor, hasMore, nil
 }
@@ -0,0 +1,140 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"memora/internal/firebase"
+	"memora/internal/models"
+	"memora/internal/services"
+	"testing"
+
+	apperrors "memora/internal/errors"
+
+	"cloud.google.com/go/firestore"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeCardRepo keeps a single stored card and the last updates applied to it.
+type fakeCardRepo struct {
+	firebase.CardRepository
+	card    map[string]any
+	updates []firestore.Update
+}
+
+func (r *fakeCardRepo) GetCardInDeck(context.Context, string, string) (map[string]any, error) {
+	return r.card, nil
+}
+
+func (r *fakeCardRepo) UpdateCard(
+	_ context.Context,
+	updates []firestore.Update,
+	_, _ string,
+	_ models.CardRevision,
+) (map[string]any, error) {
+	r.updates = updates
+	return r.card, nil
+}
+
+// fakeAuditRepo drops every audit event.
+type fakeAuditRepo struct {
+	firebase.AuditRepository
+}
+
+func (fakeAuditRepo) AddEvents(context.Context, string, []models.AuditEvent) error {
+	return nil
+}
+
+func TestUpdateCodeCard(t *testing.T) {
+	stored := map[string]any{
+		"type":           "code",
+		"question":       "What does it print?",
+		"language":       "go",
+		"code":           "a := 1\nb := 2\nc := 3\nfmt.Println(a + b + c)",
+		"highlight_from": int64(2),
+		"highlight_to":   int64(3),
+		"explanation":    "The sum of the three",
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+		want    map[string]any
+	}{
+		{
+			"clears the highlight and explanation left out",
+			`{"type": "code", "question": "What does it print?", "language": "go", "code": "fmt.Println(6)"}`,
+			nil,
+			map[string]any{"highlight_from": 0, "highlight_to": 0, "explanation": ""},
+		},
+		{
+			"keeps a highlight within the code",
+			`{"type": "code", "question": "Q", "language": "go", "code": "a\nb", "highlight_from": 1, "highlight_to": 2}`,
+			nil,
+			map[string]any{"highlight_from": 1, "highlight_to": 2},
+		},
+		{
+			"rejects a highlight past the code",
+			`{"type": "code", "question": "Q", "language": "go", "code": "a\nb", "highlight_from": 2, "highlight_to": 3}`,
+			apperrors.ErrInvalidCard,
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCardRepo{card: stored}
+			cards := services.NewCardService(&services.ServiceDeps{
+				CardRepo:  repo,
+				AuditRepo: fakeAuditRepo{},
+				Cache:     services.NewCacheService(redis.NewClient(&redis.Options{Addr: "127.0.0.1:0", MaxRetries: -1})),
+				Validate:  validator.New(),
+			})
+
+			err := cards.UpdateCard(context.Background(), []byte(tt.body), "deck", "card")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UpdateCard() error = %v, want %v", err, tt.wantErr)
+			}
+
+			updated := make(map[string]any, len(repo.updates))
+			for _, update := range repo.updates {
+				updated[update.Path] = update.Value
+			}
+			for path, want := range tt.want {
+				if got, ok := updated[path]; !ok || got != want {
+					t.Errorf("update of %q = %v, want %v", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveReviewDirection(t *testing.T) {
+	tests := []struct {
+		name      string
+		card      map[string]any
+		direction string
+		want      string
+		wantErr   error
+	}{
+		{"forward by default", map[string]any{"type": "front_back"}, "", "forward", nil},
+		{"forward card", map[string]any{"type": "front_back"}, "forward", "forward", nil},
+		{"reverse of a forward card", map[string]any{"type": "front_back"}, "reverse", "", apperrors.ErrInvalidUser},
+		{"reverse of a card in both directions", map[string]any{"type": "front_back", "direction": "both"}, "reverse", "reverse", nil},
+		{"reverse card by default", map[string]any{"type": "front_back", "direction": "reverse"}, "", "reverse", nil},
+		{"forward of a reverse card", map[string]any{"type": "front_back", "direction": "reverse"}, "forward", "", apperrors.ErrInvalidUser},
+		{"reverse of another card type", map[string]any{"type": "code"}, "reverse", "", apperrors.ErrInvalidUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cards := services.NewCardService(&services.ServiceDeps{CardRepo: &fakeCardRepo{card: tt.card}})
+
+			got, err := cards.ResolveReviewDirection(context.Background(), "deck", "card", tt.direction)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ResolveReviewDirection() = %q, %v, want %q, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
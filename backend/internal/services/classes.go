@@ -0,0 +1,647 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"maps"
+	"memora/internal/errors"
+	"memora/internal/firebase"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Characters of a join code, leaving out the ones easily mistaken for each other
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Length of a join code, and how many codes are tried before giving up on a unique one
+const (
+	joinCodeLength   = 8
+	joinCodeAttempts = 5
+)
+
+// How many progress reads of students on assigned decks run at once
+const progressReads = 10
+
+// ClassService provides methods for managing classes, their students and assignments.
+type ClassService struct {
+	repo     firebase.ClassRepository
+	cards    firebase.CardRepository
+	decks    *DeckService
+	validate *validator.Validate
+}
+
+// NewClassService creates a new instance of ClassService.
+func NewClassService(
+	deps *ServiceDeps,
+) *ClassService {
+	return &ClassService{
+		repo:     deps.ClassRepo,
+		cards:    deps.CardRepo,
+		decks:    NewDeckService(deps),
+		validate: deps.Validate,
+	}
+}
+
+// CreateClass creates a class taught by the user, with a join code for students.
+// Returns the class or an error if it is not valid or the operation fails.
+func (s *ClassService) CreateClass(
+	ctx context.Context,
+	teacherID string,
+	input models.CreateClass,
+) (models.Class, error) {
+	if err := s.validate.Struct(input); err != nil {
+		return models.Class{}, errors.ErrInvalidClass
+	}
+
+	code, err := s.newJoinCode(ctx)
+	if err != nil {
+		return models.Class{}, err
+	}
+
+	class := models.Class{
+		Name:       input.Name,
+		TeacherID:  teacherID,
+		JoinCode:   code,
+		CreatedAt:  time.Now().UTC(),
+		StudentIDs: []string{},
+		Students:   map[string]models.ClassStudent{},
+	}
+	class.ID, err = s.repo.CreateClass(ctx, class)
+	if err != nil {
+		return models.Class{}, err
+	}
+
+	return class, nil
+}
+
+// GetClasses lists the classes taught by a user.
+// Returns the classes or an error if the operation fails.
+func (s *ClassService) GetClasses(
+	ctx context.Context,
+	teacherID string,
+) ([]models.Class, error) {
+	return s.repo.GetTeacherClasses(ctx, teacherID)
+}
+
+// GetClass retrieves a class taught by the user.
+// Returns the class, or an error if the user does not teach it or the operation fails.
+func (s *ClassService) GetClass(
+	ctx context.Context,
+	classID, userID string,
+) (models.Class, error) {
+	return s.teacherClass(ctx, classID, userID)
+}
+
+// DeleteClass deletes a class taught by the user, with its assignments.
+// Students keep their access to the decks assigned to them.
+// Returns an error if the user does not teach the class or the operation fails.
+func (s *ClassService) DeleteClass(
+	ctx context.Context,
+	classID, userID string,
+) error {
+	if _, err := s.teacherClass(ctx, classID, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteClass(ctx, classID)
+}
+
+// InviteStudents invites emails to a class taught by the user. Their users are enrolled,
+// and given access to the decks assigned to the class, once they accept.
+// Emails with and without a registered user are invited alike, so the teacher
+// can not tell which emails have an account.
+// Returns how many emails were invited, or an error if the input is not valid
+// or the operation fails.
+func (s *ClassService) InviteStudents(
+	ctx context.Context,
+	classID, userID string,
+	input models.EnrollStudents,
+) (models.Enrollment, error) {
+	if err := s.validate.Struct(input); err != nil {
+		return models.Enrollment{}, errors.ErrInvalidClass
+	}
+
+	if _, err := s.teacherClass(ctx, classID, userID); err != nil {
+		return models.Enrollment{}, err
+	}
+
+	emails := make([]string, len(input.Emails))
+	for i, email := range input.Emails {
+		emails[i] = utils.NormalizeEmail(email)
+	}
+
+	invited, err := s.repo.InviteEmails(ctx, classID, emails)
+	if err != nil {
+		return models.Enrollment{}, err
+	}
+
+	return models.Enrollment{Invited: invited}, nil
+}
+
+// GetClassInvites lists the classes the email of a user is invited to.
+// Returns the invites or an error if the operation fails.
+func (s *ClassService) GetClassInvites(
+	ctx context.Context,
+	email string,
+) ([]models.ClassInvite, error) {
+	classes, err := s.repo.GetInvitedClasses(ctx, utils.NormalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]models.ClassInvite, 0, len(classes))
+	for _, class := range classes {
+		invites = append(invites, models.ClassInvite{ClassID: class.ID, ClassName: class.Name})
+	}
+	return invites, nil
+}
+
+// AcceptClassInvite enrolls a user in a class their email is invited to,
+// and gives them access to the decks assigned to the class.
+// Invites are matched by email, so they are only accepted for a verified email.
+// Returns an error if the email is not verified, is not invited to the class,
+// or the operation fails.
+func (s *ClassService) AcceptClassInvite(
+	ctx context.Context,
+	classID, userID, email string,
+	emailVerified bool,
+) error {
+	if !emailVerified {
+		return errors.ErrEmailNotVerified
+	}
+	email = utils.NormalizeEmail(email)
+
+	class, err := s.repo.GetClass(ctx, classID)
+	if err != nil {
+		return err
+	}
+	// Classes the email is not invited to are not revealed
+	if !slices.Contains(class.InvitedEmails, email) {
+		return errors.ErrNotFound
+	}
+	if class.TeacherID == userID {
+		return nil
+	}
+
+	_, err = s.addStudents(ctx, classID, map[string]models.ClassStudent{
+		userID: {Email: email, JoinedAt: time.Now().UTC()},
+	})
+	return err
+}
+
+// JoinClass enrolls a user in the class with a join code,
+// and gives them access to the decks assigned to the class.
+// Returns the ID of the class, or an error if no class has the code or the operation fails.
+func (s *ClassService) JoinClass(
+	ctx context.Context,
+	code, userID, email string,
+) (string, error) {
+	class, err := s.repo.GetClassByJoinCode(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	if class.TeacherID == userID {
+		return class.ID, nil
+	}
+
+	_, err = s.addStudents(ctx, class.ID, map[string]models.ClassStudent{
+		userID: {Email: utils.NormalizeEmail(email), JoinedAt: time.Now().UTC()},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return class.ID, nil
+}
+
+// RemoveStudent removes a student from a class taught by the user.
+// The student keeps access to the decks assigned before.
+// Returns an error if the user does not teach the class, the student is not enrolled
+// or the operation fails.
+func (s *ClassService) RemoveStudent(
+	ctx context.Context,
+	classID, userID, studentID string,
+) error {
+	if _, err := s.teacherClass(ctx, classID, userID); err != nil {
+		return err
+	}
+
+	return s.repo.RemoveStudent(ctx, classID, studentID)
+}
+
+// AssignDeck assigns a deck to a class taught by the user, and makes the students
+// viewers of the deck unless they have access to it already.
+// Only owners and co-owners of a deck can assign it.
+// Returns the assignment, or an error if the input is not valid, the user can not
+// assign the deck or the operation fails.
+func (s *ClassService) AssignDeck(
+	ctx context.Context,
+	classID, userID string,
+	input models.CreateAssignment,
+) (models.Assignment, error) {
+	if err := s.validate.Struct(input); err != nil {
+		return models.Assignment{}, errors.ErrInvalidClass
+	}
+
+	class, err := s.teacherClass(ctx, classID, userID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	deck, err := s.decks.repo.GetOneDeck(
+		ctx,
+		input.DeckID,
+		[]string{"title", "owner_id", "memberships", "public"},
+	)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if !utils.RoleAllows(deckRole(deck, userID), utils.ROLE_CO_OWNER) {
+		return models.Assignment{}, errors.ErrUnauthorized
+	}
+
+	assignment := models.Assignment{
+		DeckID:     input.DeckID,
+		DeckTitle:  deck.Title,
+		DueAt:      input.DueAt.UTC(),
+		AssignedAt: time.Now().UTC(),
+	}
+	assignment.ID, err = s.repo.AddAssignment(ctx, classID, assignment)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	if err := s.grantAccess(ctx, input.DeckID, class.Students); err != nil {
+		return models.Assignment{}, err
+	}
+
+	return assignment, nil
+}
+
+// GetAssignments lists the assignments of a class taught by the user, the earliest due first.
+// Returns the assignments, or an error if the user does not teach the class
+// or the operation fails.
+func (s *ClassService) GetAssignments(
+	ctx context.Context,
+	classID, userID string,
+) ([]models.Assignment, error) {
+	if _, err := s.teacherClass(ctx, classID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.activeAssignments(ctx, classID)
+}
+
+// DeleteAssignment deletes an assignment of a class taught by the user.
+// Students keep their access to the deck.
+// Returns an error if the user does not teach the class or the operation fails.
+func (s *ClassService) DeleteAssignment(
+	ctx context.Context,
+	classID, userID, assignmentID string,
+) error {
+	if _, err := s.teacherClass(ctx, classID, userID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteAssignment(ctx, classID, assignmentID)
+}
+
+// GetClassProgress sums up the progress of every student of a class taught by the user
+// on every assignment, from the progress the students made on the assigned decks.
+// Returns the progress sorted by email, or an error if the user does not teach the class
+// or the operation fails.
+func (s *ClassService) GetClassProgress(
+	ctx context.Context,
+	classID, userID string,
+) (models.ClassProgress, error) {
+	class, err := s.teacherClass(ctx, classID, userID)
+	if err != nil {
+		return models.ClassProgress{}, err
+	}
+
+	assignments, err := s.activeAssignments(ctx, classID)
+	if err != nil {
+		return models.ClassProgress{}, err
+	}
+
+	// Cards are counted once per deck, not once per student
+	cardCounts, err := s.countCards(ctx, assignments)
+	if err != nil {
+		return models.ClassProgress{}, err
+	}
+
+	studentIDs := slices.Collect(maps.Keys(class.Students))
+	summaries, err := s.assignmentsProgress(ctx, assignments, cardCounts, studentIDs)
+	if err != nil {
+		return models.ClassProgress{}, err
+	}
+
+	report := models.ClassProgress{
+		ClassID:  classID,
+		Students: make([]models.StudentProgress, 0, len(class.Students)),
+	}
+	for _, studentID := range studentIDs {
+		report.Students = append(report.Students, models.StudentProgress{
+			UserID:      studentID,
+			Email:       class.Students[studentID].Email,
+			Assignments: summaries[studentID],
+		})
+	}
+	slices.SortFunc(report.Students, func(a, b models.StudentProgress) int {
+		return cmp.Compare(a.Email, b.Email)
+	})
+
+	return report, nil
+}
+
+// GetStudentAssignments lists the assignments of every class a user is enrolled in,
+// with the progress of the user on them, the earliest due first.
+// Returns the assignments or an error if the operation fails.
+func (s *ClassService) GetStudentAssignments(
+	ctx context.Context,
+	userID string,
+) ([]models.StudentAssignment, error) {
+	classes, err := s.repo.GetStudentClasses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.StudentAssignment{}
+	for _, class := range classes {
+		assignments, err := s.activeAssignments(ctx, class.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		cardCounts, err := s.countCards(ctx, assignments)
+		if err != nil {
+			return nil, err
+		}
+
+		summaries, err := s.assignmentsProgress(ctx, assignments, cardCounts, []string{userID})
+		if err != nil {
+			return nil, err
+		}
+
+		for i, assignment := range assignments {
+			result = append(result, models.StudentAssignment{
+				ClassID:    class.ID,
+				ClassName:  class.Name,
+				Assignment: assignment,
+				Progress:   summaries[userID][i],
+			})
+		}
+	}
+	slices.SortFunc(result, func(a, b models.StudentAssignment) int {
+		return a.DueAt.Compare(b.DueAt)
+	})
+
+	return result, nil
+}
+
+// teacherClass fetches a class and checks that the user teaches it.
+// Returns the class, or an error if the user does not teach it or the operation fails.
+func (s *ClassService) teacherClass(
+	ctx context.Context,
+	classID, userID string,
+) (models.Class, error) {
+	class, err := s.repo.GetClass(ctx, classID)
+	if err != nil {
+		return models.Class{}, err
+	}
+	if class.TeacherID != userID {
+		return models.Class{}, errors.ErrUnauthorized
+	}
+	return class, nil
+}
+
+// addStudents enrolls students in a class and gives them access to its assigned decks.
+// Returns the number of students added, or an error if the operation fails.
+func (s *ClassService) addStudents(
+	ctx context.Context,
+	classID string,
+	students map[string]models.ClassStudent,
+) (int, error) {
+	if len(students) == 0 {
+		return 0, nil
+	}
+
+	added, err := s.repo.AddStudents(ctx, classID, students)
+	if err != nil {
+		return 0, err
+	}
+
+	assignments, err := s.repo.GetAssignments(ctx, classID)
+	if err != nil {
+		return 0, err
+	}
+	for _, assignment := range assignments {
+		if err := s.grantAccess(ctx, assignment.DeckID, students); err != nil {
+			return 0, err
+		}
+	}
+
+	return added, nil
+}
+
+// grantAccess makes students viewers of a deck, keeping the role of those with access,
+// and clears the caches of the deck.
+// Returns an error if the operation fails.
+func (s *ClassService) grantAccess(
+	ctx context.Context,
+	deckID string,
+	students map[string]models.ClassStudent,
+) error {
+	if len(students) == 0 {
+		return nil
+	}
+
+	members := make(map[string]models.Membership, len(students))
+	for id, student := range students {
+		members[id] = models.Membership{Email: student.Email, Role: utils.ROLE_VIEWER}
+	}
+	before, err := s.decks.auditedDeck(ctx, deckID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.decks.repo.GrantAccess(ctx, deckID, members); err != nil {
+		return err
+	}
+
+	s.decks.invalidateDeckCaches(ctx, deckID)
+	s.decks.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_SHARE, &before)
+
+	return nil
+}
+
+// activeAssignments fetches the assignments of a class whose deck is not in the trash.
+// The assignments of a restored deck are listed again.
+// Returns the assignments, or an error if the operation fails.
+func (s *ClassService) activeAssignments(
+	ctx context.Context,
+	classID string,
+) ([]models.Assignment, error) {
+	assignments, err := s.repo.GetAssignments(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	deckIDs := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		deckIDs = append(deckIDs, assignment.DeckID)
+	}
+	existing, err := s.decks.repo.ExistingDecks(ctx, deckIDs)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(assignments, func(assignment models.Assignment) bool {
+		return !existing[assignment.DeckID]
+	}), nil
+}
+
+// countCards counts the cards of the decks of assignments.
+// Returns the counts keyed by deck ID, or an error if the operation fails.
+func (s *ClassService) countCards(
+	ctx context.Context,
+	assignments []models.Assignment,
+) (map[string]int, error) {
+	counts := make(map[string]int, len(assignments))
+	for _, assignment := range assignments {
+		if _, ok := counts[assignment.DeckID]; ok {
+			continue
+		}
+		count, err := s.cards.CountCards(ctx, assignment.DeckID)
+		if err != nil {
+			return nil, err
+		}
+		counts[assignment.DeckID] = count
+	}
+	return counts, nil
+}
+
+// assignmentsProgress sums up the progress of students on the decks of assignments,
+// reading the progress of a few students at once.
+// Returns the summaries of each student in the order of the assignments,
+// or an error if the progress could not be fetched.
+func (s *ClassService) assignmentsProgress(
+	ctx context.Context,
+	assignments []models.Assignment,
+	cardCounts map[string]int,
+	studentIDs []string,
+) (map[string][]models.AssignmentProgress, error) {
+	now := time.Now().UTC()
+
+	summaries := make(map[string][]models.AssignmentProgress, len(studentIDs))
+	for _, studentID := range studentIDs {
+		summaries[studentID] = make([]models.AssignmentProgress, len(assignments))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	reads := make(chan struct{}, progressReads)
+
+	for _, studentID := range studentIDs {
+		for i, assignment := range assignments {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reads <- struct{}{}
+				defer func() { <-reads }()
+
+				progress, err := s.cards.GetProgressInDeck(ctx, assignment.DeckID, studentID)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+				// Each goroutine writes its own element, so no lock is needed
+				summaries[studentID][i] = summarizeProgress(assignment, cardCounts[assignment.DeckID], progress, now)
+			}()
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return summaries, nil
+}
+
+// summarizeProgress sums up the progress documents of a student on a deck.
+// A card counts as studied once reviewed in any direction, and retention is the share
+// of reviews that were not lapses.
+func summarizeProgress(
+	assignment models.Assignment,
+	cards int,
+	progress map[string]models.CardProgress,
+	now time.Time,
+) models.AssignmentProgress {
+	summary := models.AssignmentProgress{
+		AssignmentID: assignment.ID,
+		DeckID:       assignment.DeckID,
+		DueAt:        assignment.DueAt,
+		Cards:        cards,
+	}
+
+	studied := make(map[string]bool, len(progress))
+	overdue := make(map[string]bool)
+	lapses := 0
+	for progressID, p := range progress {
+		if p.Reps == 0 {
+			continue
+		}
+		cardID, _ := utils.SplitProgressID(progressID)
+		studied[cardID] = true
+		if p.Due.Before(now) {
+			overdue[cardID] = true
+		}
+		summary.Reviews += p.Reps
+		lapses += p.Lapses
+	}
+
+	// Progress of cards deleted since is not counted past the cards of the deck
+	summary.Studied = min(len(studied), cards)
+	summary.Overdue = min(len(overdue), cards)
+	if summary.Reviews > 0 {
+		summary.Retention = float64(summary.Reviews-lapses) / float64(summary.Reviews)
+	}
+
+	return summary
+}
+
+// newJoinCode generates a join code no other class has.
+// Returns the code, or an error if no unique code was found or the operation fails.
+func (s *ClassService) newJoinCode(ctx context.Context) (string, error) {
+	for range joinCodeAttempts {
+		random := make([]byte, joinCodeLength)
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		code := make([]byte, joinCodeLength)
+		for i, b := range random {
+			code[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+		}
+
+		_, err := s.repo.GetClassByJoinCode(ctx, string(code))
+		if err == errors.ErrNotFound {
+			return string(code), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.ErrAlreadyExists
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+)
+
+// prepareContent validates the text fields of a card in the given format,
+// and strips dangerous HTML from them before the card is stored.
+// Returns an error if the content is not valid in its format.
+func prepareContent(card models.Card, format string) error {
+	var err error
+	card.MapText(func(text string) string {
+		if format == utils.FORMAT_MARKDOWN {
+			if validateErr := utils.ValidateMarkdown(text); validateErr != nil {
+				err = errors.ErrInvalidCard
+			}
+		}
+		return utils.SanitizeContent(text, format)
+	})
+	return err
+}
+
+// GetCardHTML retrieves a card with every text field rendered to sanitized HTML.
+// Math delimiters are kept, so math can be rendered by the client.
+// Returns the rendered card or an error if the operation fails.
+func (s *CardService) GetCardHTML(
+	ctx context.Context,
+	deckID, cardID string,
+) (models.Card, error) {
+	card, err := s.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	format := card.Meta().Format
+	card.MapText(func(text string) string {
+		return utils.RenderHTML(text, format)
+	})
+
+	return card, nil
+}
+
+// GetCodeHighlight renders the code of a code card to HTML with the named style.
+// Returns the highlighted code, or an error if the card is not a code card or the style is unknown.
+func (s *CardService) GetCodeHighlight(
+	ctx context.Context,
+	deckID, cardID, style string,
+) (models.CodeHighlight, error) {
+	if !utils.IsKnownCodeStyle(style) {
+		return models.CodeHighlight{}, errors.ErrInvalidCard
+	}
+
+	card, err := s.GetCardInDeck(ctx, deckID, cardID)
+	if err != nil {
+		return models.CodeHighlight{}, err
+	}
+
+	codeCard, ok := card.(*models.CodeCard)
+	if !ok {
+		return models.CodeHighlight{}, errors.ErrInvalidCard
+	}
+
+	html, err := utils.HighlightCode(
+		codeCard.Code,
+		codeCard.Language,
+		style,
+		codeCard.HighlightFrom,
+		codeCard.HighlightTo,
+	)
+	if err != nil {
+		return models.CodeHighlight{}, err
+	}
+
+	return models.CodeHighlight{
+		ID:       cardID,
+		Language: codeCard.Language,
+		Style:    style,
+		HTML:     html,
+	}, nil
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Separator between the items of a list field when none is given
+const defaultListSeparator = "|"
+
+// Marks a correct multiple choice option, and separates the sides of a matching pair
+const (
+	correctOptionMarker = "*"
+	pairSeparator       = "="
+)
+
+// csvField is a card field that can be read from a column.
+type csvField struct {
+	// goName is the name of the struct field, as reported by validation errors
+	goName string
+	typ    reflect.Type
+}
+
+// ImportCSV reads cards from a CSV or TSV file and creates them in a deck.
+// Every row is validated like a card sent to CreateCard, and nothing is created
+// when a row has an error or on a dry run.
+// Returns a report with the errors of every row, or an error if the options are not valid.
+func (s *CardService) ImportCSV(
+	ctx context.Context,
+	deckID string,
+	file io.Reader,
+	options models.CSVImportOptions,
+) (models.CSVImportReport, error) {
+	if err := s.validate.Struct(options); err != nil {
+		return models.CSVImportReport{}, errors.ErrInvalidImport
+	}
+
+	delimiter, ok := csvDelimiter(options.Delimiter)
+	if !ok {
+		return models.CSVImportReport{}, errors.ErrInvalidImport
+	}
+
+	separator := options.ListSeparator
+	if separator == "" {
+		separator = defaultListSeparator
+	}
+
+	fields := make(map[string]map[string]csvField, len(options.Mappings))
+	for cardType, mapping := range options.Mappings {
+		factory, ok := cardRegistry[cardType]
+		if !ok || cardType == utils.NOTE_CARD {
+			return models.CSVImportReport{}, errors.ErrInvalidImport
+		}
+
+		fields[cardType] = csvFields(factory())
+		for field := range mapping {
+			if _, ok := fields[cardType][field]; !ok {
+				return models.CSVImportReport{}, errors.ErrInvalidImport
+			}
+		}
+	}
+
+	// A single mapping is used for every row without a type
+	defaultType := options.DefaultType
+	if defaultType == "" && len(options.Mappings) == 1 {
+		for cardType := range options.Mappings {
+			defaultType = cardType
+		}
+	}
+
+	reader := csv.NewReader(file)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	report := models.CSVImportReport{DryRun: options.DryRun, Errors: []models.RowError{}}
+	var header map[string]int
+	var cards []models.Card
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		// The rest of the file can not be read reliably after a syntax error
+		var parseErr *csv.ParseError
+		if stderrors.As(err, &parseErr) {
+			report.Errors = append(report.Errors, models.RowError{
+				Row:   parseErr.Line,
+				Error: parseErr.Err.Error(),
+			})
+			break
+		}
+		if err != nil {
+			return models.CSVImportReport{}, err
+		}
+
+		row, _ := reader.FieldPos(0)
+
+		if options.HasHeader && header == nil {
+			header = make(map[string]int, len(record))
+			for i, name := range record {
+				header[strings.TrimSpace(name)] = i
+			}
+			if rowErr := checkColumns(options, header); rowErr != nil {
+				rowErr.Row = row
+				report.Errors = append(report.Errors, *rowErr)
+				break
+			}
+			continue
+		}
+
+		report.Rows++
+		if report.Rows > utils.MAX_IMPORT_ROWS {
+			report.Errors = append(report.Errors, models.RowError{
+				Row:   row,
+				Error: fmt.Sprintf("files are limited to %d rows", utils.MAX_IMPORT_ROWS),
+			})
+			break
+		}
+
+		card, rowErr := s.csvRowToCard(record, header, options, defaultType, separator, fields)
+		if rowErr != nil {
+			rowErr.Row = row
+			report.Errors = append(report.Errors, *rowErr)
+			continue
+		}
+		cards = append(cards, card)
+	}
+
+	if len(report.Errors) > 0 || options.DryRun {
+		return report, nil
+	}
+
+	ids, err := s.CreateCards(ctx, deckID, cards)
+	if err != nil {
+		return models.CSVImportReport{}, err
+	}
+
+	report.Imported = len(ids)
+	report.CardIDs = ids
+
+	return report, nil
+}
+
+// csvRowToCard converts a row to a card of the type of the row, and validates it.
+// Returns the card, or the error of the row.
+func (s *CardService) csvRowToCard(
+	record []string,
+	header map[string]int,
+	options models.CSVImportOptions,
+	defaultType, separator string,
+	fields map[string]map[string]csvField,
+) (models.Card, *models.RowError) {
+	cardType := defaultType
+	if options.TypeColumn != "" {
+		if value, _ := csvColumn(record, header, options.TypeColumn); strings.TrimSpace(value) != "" {
+			cardType = strings.TrimSpace(value)
+		}
+	}
+
+	mapping, ok := options.Mappings[cardType]
+	if !ok {
+		return nil, &models.RowError{
+			Column: options.TypeColumn,
+			Error:  fmt.Sprintf("no mapping for card type %q", cardType),
+		}
+	}
+
+	data := map[string]any{"type": cardType}
+	for field, column := range mapping {
+		value, ok := csvColumn(record, header, column)
+		if !ok {
+			return nil, &models.RowError{Column: column, Error: "unknown column"}
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := parseCSVValue(value, fields[cardType][field].typ, separator)
+		if err != nil {
+			return nil, &models.RowError{Column: column, Error: fmt.Sprintf("%s: %v", field, err)}
+		}
+		data[field] = parsed
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, &models.RowError{Error: err.Error()}
+	}
+
+	card, err := GetCardStruct(raw, errors.ErrInvalidCard)
+	if err != nil {
+		return nil, &models.RowError{Error: errors.ErrInvalidCard.Error()}
+	}
+
+	if err := s.prepareNewCard(card); err != nil {
+		return nil, &models.RowError{Error: describeCardError(err, fields[cardType], mapping)}
+	}
+
+	return card, nil
+}
+
+// checkColumns checks that every column referenced by the options exists in the header.
+// Returns the error of the header row, or nil if every column exists.
+func checkColumns(options models.CSVImportOptions, header map[string]int) *models.RowError {
+	columns := []string{}
+	if options.TypeColumn != "" {
+		columns = append(columns, options.TypeColumn)
+	}
+	for _, mapping := range options.Mappings {
+		for _, column := range mapping {
+			columns = append(columns, column)
+		}
+	}
+
+	for _, column := range columns {
+		if _, ok := header[column]; ok {
+			continue
+		}
+		if i, err := strconv.Atoi(column); err == nil && i >= 0 && i < len(header) {
+			continue
+		}
+		return &models.RowError{Column: column, Error: "unknown column"}
+	}
+
+	return nil
+}
+
+// csvColumn returns the value of a column, referenced by its header or its index.
+// Columns missing at the end of a row are empty.
+// Returns false if the column does not exist.
+func csvColumn(record []string, header map[string]int, column string) (string, bool) {
+	i, ok := header[column]
+	if !ok {
+		var err error
+		if i, err = strconv.Atoi(column); err != nil || i < 0 {
+			return "", false
+		}
+	}
+
+	if i >= len(record) {
+		return "", true
+	}
+	return record[i], true
+}
+
+// csvDelimiter parses the delimiter of a file, which has to be a single character.
+// Returns false if the delimiter can not separate columns.
+func csvDelimiter(delimiter string) (rune, bool) {
+	switch delimiter {
+	case "":
+		return ',', true
+	case "tab", `\t`:
+		return '\t', true
+	}
+
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, false
+	}
+	return r, true
+}
+
+// csvFields returns the fields of a card that can be read from a column, keyed by JSON name.
+func csvFields(card models.Card) map[string]csvField {
+	fields := make(map[string]csvField)
+
+	var collect func(t reflect.Type)
+	collect = func(t reflect.Type) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if field.Anonymous {
+				collect(field.Type)
+				continue
+			}
+
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			// Only fields stored with the card can be imported
+			if name == "" || name == "-" || name == "id" || name == "type" ||
+				field.Tag.Get("firestore") == "-" {
+				continue
+			}
+			fields[name] = csvField{goName: field.Name, typ: field.Type}
+		}
+	}
+	collect(reflect.TypeOf(card).Elem())
+
+	return fields
+}
+
+// parseCSVValue converts the text of a column to the JSON value of a field of the given type.
+// Returns an error if the text is not valid for the type.
+func parseCSVValue(value string, typ reflect.Type, separator string) (any, error) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ.Kind() == reflect.String:
+		return value, nil
+	case typ.Kind() == reflect.Float64:
+		return strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	case typ.Kind() == reflect.Int:
+		return strconv.Atoi(value)
+	case typ.Kind() == reflect.Bool:
+		return strconv.ParseBool(value)
+	case typ == reflect.TypeOf(map[string]bool{}):
+		options := make(map[string]bool)
+		for _, item := range splitList(value, separator) {
+			option, correct := strings.CutPrefix(item, correctOptionMarker)
+			options[strings.TrimSpace(option)] = correct
+		}
+		return options, nil
+	case typ == reflect.TypeOf([]models.MatchingPair{}):
+		var pairs []models.MatchingPair
+		for _, item := range splitList(value, separator) {
+			left, right, ok := strings.Cut(item, pairSeparator)
+			if !ok {
+				return nil, fmt.Errorf("pair %q has no %q", item, pairSeparator)
+			}
+			pairs = append(pairs, models.MatchingPair{
+				Left:  strings.TrimSpace(left),
+				Right: strings.TrimSpace(right),
+			})
+		}
+		return pairs, nil
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.String:
+		return splitList(value, separator), nil
+	}
+
+	return nil, fmt.Errorf("unsupported field")
+}
+
+// splitList splits a column into the trimmed, non-empty items of a list.
+func splitList(value, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// describeCardError explains why a card read from a row is not valid,
+// naming the fields and columns at fault when validation tells them.
+func describeCardError(
+	err error,
+	fields map[string]csvField,
+	mapping map[string]string,
+) string {
+	var validationErrs validator.ValidationErrors
+	if !stderrors.As(err, &validationErrs) {
+		return err.Error()
+	}
+
+	var problems []string
+	for _, fieldErr := range validationErrs {
+		name := fieldErr.Field()
+		for jsonName, field := range fields {
+			if field.goName == fieldErr.StructField() {
+				name = jsonName
+			}
+		}
+
+		problem := fmt.Sprintf("%s failed on %s", name, fieldErr.Tag())
+		if column, ok := mapping[name]; ok {
+			problem += fmt.Sprintf(" (column %s)", column)
+		}
+		problems = append(problems, problem)
+	}
+
+	return strings.Join(problems, ", ")
+}
@@ -2,25 +2,33 @@ package services
 
 import (
 	"context"
+	"io"
+	"log/slog"
 	"memora/internal/errors"
 	"memora/internal/firebase"
 	"memora/internal/models"
 	"memora/internal/utils"
 	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 )
 
 // Default filter for all fields, used when updating a deck
-const defaultFilterDecks = "title,owner_id,shared_emails"
+const defaultFilterDecks = "title,owner_id,shared_emails,member_ids,memberships,forked_from,forked_at,upstream,public,publication,transfer"
 
 // DeckService provides methods for managing decks.
 type DeckService struct {
 	repo     firebase.DeckRepository
+	invites  firebase.InviteRepository
+	links    firebase.ShareLinkRepository
 	validate *validator.Validate
 	cache    *CacheService
+	audit    *AuditService
 	Cards    *CardService
+	Notes    *NoteService
 }
 
 // NewDeckService creates a new instance of DeckService.
@@ -29,49 +37,64 @@ func NewDeckService(
 ) *DeckService {
 	return &DeckService{
 		repo:     deps.DeckRepo,
+		invites:  deps.InviteRepo,
+		links:    deps.LinkRepo,
 		validate: deps.Validate,
 		cache:    deps.Cache,
+		audit:    NewAuditService(deps),
 		Cards:    NewCardService(deps),
+		Notes:    NewNoteService(deps),
 	}
 }
 
 func (s *DeckService) GetCardsInDeck(
 	ctx context.Context,
 	deckID, limit_str, cursor string,
+	filter models.TagFilter,
 ) ([]models.Card, bool, error) {
-	return s.Cards.GetCardsInDeck(ctx, deckID, limit_str, cursor)
+	return s.Cards.GetCardsInDeck(ctx, deckID, limit_str, cursor, filter)
 }
 
-func (s *DeckService) CheckIfUserCanAccessDeck(
+// GetDeckRole resolves the role of a user on a deck.
+// Owners have the owner role, members the role they were given,
+// and anyone else can read public decks.
+// The role is cached until the owner, the members or the visibility of the deck change.
+// Returns the role, empty if the user has no access, or an error if the deck could not be fetched.
+func (s *DeckService) GetDeckRole(
 	ctx context.Context,
-	deckID, userID, userEmail string,
-) (bool, error) {
-	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"owner_id", "shared_emails"})
-	if err != nil {
-		return false, err
+	deckID, userID string,
+) (string, error) {
+	cacheKey := utils.DeckRoleKey(deckID, userID)
+	var role string
+	if err := s.cache.Get(ctx, cacheKey, &role); err == nil {
+		return role, nil
 	}
 
-	if deck.OwnerID == userID || slices.Contains(deck.SharedEmails, userEmail) {
-		return true, nil
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"owner_id", "memberships", "public"})
+	if err != nil {
+		return "", err
 	}
 
-	return false, nil
-}
+	// Users without access are cached too, so they can not make every request fetch the deck
+	role = deckRole(deck, userID)
+	s.cache.SetAsync(cacheKey, role, RoleTTL)
 
-func (s *DeckService) UserOwnsDeck(
-	ctx context.Context,
-	deckID, userID string,
-) (bool, error) {
-	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"owner_id"})
-	if err != nil {
-		return false, err
-	}
+	return role, nil
+}
 
+// deckRole resolves the role of a user on a fetched deck.
+// Members are found by their ID, so changing their email keeps their access.
+func deckRole(deck models.Deck, userID string) string {
 	if deck.OwnerID == userID {
-		return true, nil
+		return utils.ROLE_OWNER
 	}
-
-	return false, nil
+	if membership, ok := deck.Memberships[userID]; ok {
+		return membership.Role
+	}
+	if deck.Public {
+		return utils.ROLE_READER
+	}
+	return ""
 }
 
 // RegisterNewDeck creates a new deck from the provided data.
@@ -79,18 +102,19 @@ func (s *DeckService) UserOwnsDeck(
 func (s *DeckService) RegisterNewDeck(
 	ctx context.Context,
 	deck models.CreateDeck,
-	ownerEmail string,
 ) (string, error) {
 	if err := s.validate.Struct(deck); err != nil {
 		return "", errors.ErrInvalidDeck
 	}
 
+	// Emails shared on creation are made editors by the repository
 	id, err := s.repo.AddDeck(ctx, deck)
 	if err != nil {
 		return "", err
 	}
 
-	s.invalidateDeckCaches(id, ownerEmail, deck.SharedEmails)
+	s.invalidateDeckCaches(ctx, id)
+	s.recordDeckChange(ctx, id, utils.AUDIT_DECK_CREATE, nil)
 
 	return id, nil
 }
@@ -136,6 +160,24 @@ func (s *DeckService) GetCardInDeck(
 	return s.Cards.GetCardInDeck(ctx, deckID, cardID)
 }
 
+// GetCardHTMLInDeck retrieves a card from a deck with its text rendered to sanitized HTML.
+// Returns the card or an error if the operation fails.
+func (s *DeckService) GetCardHTMLInDeck(
+	ctx context.Context,
+	deckID, cardID string,
+) (models.Card, error) {
+	return s.Cards.GetCardHTML(ctx, deckID, cardID)
+}
+
+// GetCodeHighlightInDeck renders the code of a code card in a deck to HTML.
+// Returns the highlighted code or an error if the operation fails.
+func (s *DeckService) GetCodeHighlightInDeck(
+	ctx context.Context,
+	deckID, cardID, style string,
+) (models.CodeHighlight, error) {
+	return s.Cards.GetCodeHighlight(ctx, deckID, cardID, style)
+}
+
 // AddCardToDeck creates a new card in the specified deck from the provided raw JSON data.
 // Validates the card and returns the updated deck or an error if the operation fails.
 func (s *DeckService) AddCardToDeck(
@@ -159,7 +201,7 @@ func (s *DeckService) AddCardToDeck(
 // Validates the updated deck and returns the updated deck or an error if the operation fails.
 func (s *DeckService) UpdateDeck(
 	ctx context.Context,
-	deckID, ownerEmail string,
+	deckID string,
 	update models.UpdateDeck,
 ) (models.Deck, error) {
 	if err := s.validate.Struct(update); err != nil {
@@ -171,7 +213,7 @@ func (s *DeckService) UpdateDeck(
 		return models.Deck{}, err
 	}
 
-	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"shared_emails"})
+	before, err := s.auditedDeck(ctx, deckID)
 	if err != nil {
 		return models.Deck{}, err
 	}
@@ -181,7 +223,8 @@ func (s *DeckService) UpdateDeck(
 		return models.Deck{}, err
 	}
 
-	s.invalidateDeckCaches(deckID, ownerEmail, deck.SharedEmails)
+	s.invalidateDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_UPDATE, &before)
 
 	// Fetch and return the updated deck
 	return s.GetOneDeck(ctx, deckID, defaultFilterDecks)
@@ -202,11 +245,26 @@ func (s *DeckService) UpdateCardInDeck(
 	return s.GetCardInDeck(ctx, deckID, cardID)
 }
 
+// RestoreCardRevisionInDeck updates a card in a deck back to one of its revisions.
+// Returns the restored card or an error if the revision can not be restored.
+func (s *DeckService) RestoreCardRevisionInDeck(
+	ctx context.Context,
+	deckID, cardID, revisionID string,
+) (models.Card, error) {
+	if err := s.Cards.RestoreCardRevision(ctx, deckID, cardID, revisionID); err != nil {
+		return nil, err
+	}
+
+	return s.GetCardInDeck(ctx, deckID, cardID)
+}
+
 // UpdateEmailsInDeck updates the shared emails of a deck based on the provided operation (add or remove).
+// Added emails are given the role of the input, editor by default, and emails without
+// a registered user are invited with it. Removed emails are matched ignoring case.
 // Validates the input and returns the updated deck or an error if the operation fails.
 func (s *DeckService) UpdateEmailsInDeck(
 	ctx context.Context,
-	deckID, ownerEmail string,
+	deckID, userID string,
 	emails models.UpdateDeckEmails,
 ) (models.Deck, error) {
 	var err error
@@ -216,49 +274,68 @@ func (s *DeckService) UpdateEmailsInDeck(
 		return models.Deck{}, errors.ErrInvalidDeck
 	}
 
-	oldDeck, err := s.repo.GetOneDeck(ctx, deckID, []string{"shared_emails"})
+	// The users removed are no longer members, so their deck lists are invalidated first
+	oldDeck, err := s.auditedDeck(ctx, deckID)
 	if err != nil {
 		return models.Deck{}, err
 	}
-	oldShared := oldDeck.SharedEmails
 
 	// Perform the appropriate operation based on the Opp field
+	var invited []string
+	role := emails.Role
 	switch emails.Opp {
 	case utils.OPP_ADD:
 		// Add emails to the deck's shared emails
-		err = s.repo.AddEmailsToShared(ctx, deckID, emails.Emails)
+		if role == "" {
+			role = utils.ROLE_EDITOR
+		}
+		invited, err = s.repo.AddEmailsToShared(ctx, deckID, emails.Emails, role, userID)
 	case utils.OPP_REMOVE:
-		// Remove emails from the deck's shared emails
-		err = s.repo.RemoveEmailsFromShared(ctx, deckID, emails.Emails)
+		// Remove the members of the emails from the deck
+		var memberIDs []string
+		for _, email := range emails.Emails {
+			memberID, ok := oldDeck.MemberID(email)
+			if !ok {
+				return models.Deck{}, errors.ErrInvalidEmailNotPresent
+			}
+			memberIDs = append(memberIDs, memberID)
+		}
+		err = s.repo.RemoveMembers(ctx, deckID, memberIDs)
 	}
 	if err != nil {
 		return models.Deck{}, err
 	}
 
-	newDeck, err := s.repo.GetOneDeck(ctx, deckID, []string{"shared_emails"})
-	if err != nil {
-		return models.Deck{}, err
-	}
-	newShared := newDeck.SharedEmails
-
-	// Invalidate caches for both old and new shared emails
-	allShared := append(oldShared, newShared...)
-	s.invalidateDeckCaches(deckID, ownerEmail, allShared)
+	s.invalidateUserDecks(ctx, deckUsers(oldDeck))
+	s.invalidateDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_SHARE, &oldDeck)
+	s.recordInvites(ctx, deckID, invited, role, true)
 
 	// Fetch and return the updated deck
 	return s.GetOneDeck(ctx, deckID, defaultFilterDecks)
 }
 
+// DeleteDeck moves a deck to the trash of its owner, it can be restored
+// until the trash is purged of it.
+// Returns an error if the deck does not exist or the operation fails.
 func (s *DeckService) DeleteDeck(
 	ctx context.Context,
-	id, ownerEmail string,
+	id string,
 ) error {
-	err := s.repo.DeleteDeck(ctx, id)
+	// The deck is gone after deleting it, so its users are fetched first
+	deck, err := s.auditedDeck(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	s.invalidateDeckCaches(id, ownerEmail, nil)
+	if err := s.repo.DeleteDeck(ctx, id, utils.UserIDFromContext(ctx), time.Now().UTC()); err != nil {
+		return err
+	}
+
+	s.invalidateUserDecks(ctx, deckUsers(deck))
+	s.clearDeckCaches(ctx, id)
+	s.Cards.clearCardCaches(ctx, id)
+	s.audit.Record(ctx, id, auditEvent(utils.AUDIT_DECK_DELETE, "", deck, nil))
 
 	return nil
 }
@@ -272,13 +349,17 @@ func (s *DeckService) DeleteCardInDeck(
 	return s.Cards.DeleteCard(ctx, deckID, cardID)
 }
 
+// GetCardProgress retrieves a user's progress on a card in a deck reviewed in a direction.
+// Returns the progress of the first direction of the card when empty, or an error if not found.
 func (s *DeckService) GetCardProgress(
 	ctx context.Context,
-	deckID, cardID, userID string,
+	deckID, cardID, userID, direction string,
 ) (models.CardProgress, error) {
-	return s.Cards.GetCardProgress(ctx, deckID, cardID, userID)
+	return s.Cards.GetCardProgress(ctx, deckID, cardID, userID, direction)
 }
 
+// UpdateCardProgress rates a card in a deck for a user, writing the progress in the background.
+// Returns an error if the rating is invalid or the card is not reviewed in its direction.
 func (s *DeckService) UpdateCardProgress(
 	ctx context.Context,
 	deckID, cardID, userID string,
@@ -291,12 +372,151 @@ func (s *DeckService) GetDueCardsInDeck(
 	ctx context.Context,
 	deckID, userID string,
 	limit, cursor string,
-) ([]models.Card, string, bool, error) {
-	return s.Cards.GetDueCardsInDeck(ctx, deckID, userID, limit, cursor)
+	filter models.TagFilter,
+) ([]any, string, bool, error) {
+	return s.Cards.GetDueCardsInDeck(ctx, deckID, userID, limit, cursor, filter)
+}
+
+// GradeCardInDeck checks an answer to a card in a deck.
+// Returns the result or an error if the card can not be graded.
+func (s *DeckService) GradeCardInDeck(
+	ctx context.Context,
+	deckID, cardID string,
+	answer models.GradeAnswer,
+) (models.GradeResult, error) {
+	return s.Cards.GradeCard(ctx, deckID, cardID, answer)
 }
 
-func (s *DeckService) invalidateDeckCaches(deckID, ownerEmail string, sharedEmails []string) {
-	ctx, cancel := context.WithTimeout(context.Background(), CacheOpTimeout)
+// ImportCSV creates cards in a deck from the rows of a CSV file.
+// Returns a report of the import or an error if the options are not valid.
+func (s *DeckService) ImportCSV(
+	ctx context.Context,
+	deckID string,
+	file io.Reader,
+	options models.CSVImportOptions,
+) (models.CSVImportReport, error) {
+	return s.Cards.ImportCSV(ctx, deckID, file, options)
+}
+
+// ImportMarkdown turns the blocks of a Markdown document into cards of a deck,
+// updating the cards made from the same document before.
+// Returns a report of the import, or an error if the operation fails.
+func (s *DeckService) ImportMarkdown(
+	ctx context.Context,
+	deckID, text string,
+	options models.MarkdownImportOptions,
+) (models.MarkdownImportReport, error) {
+	return s.Cards.ImportMarkdown(ctx, deckID, text, options)
+}
+
+// PresentCardInDeck retrieves a card in a deck as it should be shown to the learner.
+// Returns the presentation or an error if the operation fails.
+func (s *DeckService) PresentCardInDeck(
+	ctx context.Context,
+	deckID, cardID string,
+) (any, error) {
+	return s.Cards.PresentCard(ctx, deckID, cardID)
+}
+
+// UpdateTagsInDeck adds, removes or renames tags on the cards of a deck.
+// Returns the number of cards updated or an error if the operation fails.
+func (s *DeckService) UpdateTagsInDeck(
+	ctx context.Context,
+	deckID string,
+	update models.UpdateTags,
+) (int, error) {
+	return s.Cards.UpdateTags(ctx, deckID, update)
+}
+
+// GetTagsInDeck lists the tags used in a deck along with how many cards have them.
+// Returns the tags or an error if the operation fails.
+func (s *DeckService) GetTagsInDeck(
+	ctx context.Context,
+	deckID string,
+) ([]models.TagCount, error) {
+	return s.Cards.GetTags(ctx, deckID)
+}
+
+// GetAuditLog lists a page of the changes made to a deck and its cards, the latest first.
+// Returns the events or an error if the cursor is not valid or the operation fails.
+func (s *DeckService) GetAuditLog(
+	ctx context.Context,
+	deckID, limit, cursor string,
+) (models.AuditLogResponse, error) {
+	return s.audit.GetAuditLog(ctx, deckID, limit, cursor)
+}
+
+// auditedDeck fetches the fields of a deck recorded in its audit log,
+// to diff it with the deck after a change.
+// Returns the deck or an error if it could not be fetched.
+func (s *DeckService) auditedDeck(ctx context.Context, deckID string) (models.Deck, error) {
+	return s.repo.GetOneDeck(ctx, deckID, strings.Split(defaultFilterDecks, ","))
+}
+
+// recordDeckChange records a change of a deck in its audit log, diffing the deck before
+// the change with the deck stored after it. Decks created have no before,
+// and changes leaving the deck as it was are not recorded.
+func (s *DeckService) recordDeckChange(
+	ctx context.Context,
+	deckID, action string,
+	before *models.Deck,
+) {
+	after, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		slog.Error("failed to fetch audited deck", "deckID", deckID, "action", action, "error", err)
+		return
+	}
+
+	var old any
+	if before != nil {
+		old = *before
+	}
+	event := auditEvent(action, "", old, after)
+	if len(event.Changes) == 0 {
+		return
+	}
+	s.audit.Record(ctx, deckID, event)
+}
+
+// recordInvites records the invites to a deck sent or revoked in its audit log.
+func (s *DeckService) recordInvites(
+	ctx context.Context,
+	deckID string,
+	emails []string,
+	role string,
+	sent bool,
+) {
+	if len(emails) == 0 {
+		return
+	}
+
+	invites := make(map[string]string, len(emails))
+	for _, email := range emails {
+		invites[email] = role
+	}
+	fields := map[string]any{"invites": invites}
+
+	if sent {
+		s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_DECK_SHARE, "", nil, fields))
+	} else {
+		s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_DECK_SHARE, "", fields, nil))
+	}
+}
+
+// invalidateDeckCaches clears the cached deck and roles of users on it,
+// and the deck lists of its owner and members.
+func (s *DeckService) invalidateDeckCaches(ctx context.Context, deckID string) {
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"owner_id", "member_ids"})
+	if err == nil {
+		s.invalidateUserDecks(ctx, deckUsers(deck))
+	}
+
+	s.clearDeckCaches(ctx, deckID)
+}
+
+// clearDeckCaches clears the cached deck and the roles of users on it.
+func (s *DeckService) clearDeckCaches(ctx context.Context, deckID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CacheOpTimeout)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -308,21 +528,42 @@ func (s *DeckService) invalidateDeckCaches(deckID, ownerEmail string, sharedEmai
 		s.cache.Delete(ctx, utils.DeckKey(deckID))
 	}()
 
-	// Invalidate owner's deck list
+	// Delete the roles of users on the deck
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		s.cache.Delete(ctx, utils.UserEmailDecksKey(ownerEmail))
+		s.cache.DeletePattern(ctx, utils.DeckRolesKey(deckID)+"*")
 	}()
 
-	// Invalidate shared users' deck lists
-	for _, email := range sharedEmails {
-		wg.Add(1)
-		go func(e string) {
-			defer wg.Done()
-			s.cache.Delete(ctx, utils.UserEmailDecksKey(e))
-		}(email)
+	wg.Wait()
+}
+
+// invalidateUserDecks clears the cached deck lists of users.
+func (s *DeckService) invalidateUserDecks(ctx context.Context, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
 	}
 
-	wg.Wait()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CacheOpTimeout)
+	defer cancel()
+
+	keys := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		keys[i] = utils.UserDecksKey(userID)
+	}
+	s.cache.Delete(ctx, keys...)
+}
+
+// deckUsers returns the IDs of the owner and the members of a deck.
+func deckUsers(deck models.Deck) []string {
+	users := append([]string{}, deck.MemberIDs...)
+	for id := range deck.Memberships {
+		if !slices.Contains(users, id) {
+			users = append(users, id)
+		}
+	}
+	if deck.OwnerID != "" {
+		users = append(users, deck.OwnerID)
+	}
+	return users
 }
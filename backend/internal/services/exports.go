@@ -0,0 +1,417 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"time"
+)
+
+// Number of cards read at once when exporting a deck
+const exportPageSize = "100"
+
+// ExportDeck builds a backup of a deck holding every card of the deck, the notes
+// generating cards and their note types, and the progress of the user when asked for.
+// Returns the export or an error if the operation fails.
+func (s *DeckService) ExportDeck(
+	ctx context.Context,
+	deckID, userID string,
+	includeProgress bool,
+) (models.DeckExport, error) {
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"title", "publication"})
+	if err != nil {
+		return models.DeckExport{}, err
+	}
+
+	export := models.DeckExport{
+		SchemaVersion: utils.EXPORT_SCHEMA_VERSION,
+		ExportedAt:    time.Now().UTC(),
+		Deck:          models.ExportedDeck{Title: deck.Title},
+		Cards:         []json.RawMessage{},
+	}
+	if deck.Publication != nil {
+		export.Deck.Description = deck.Publication.Description
+		export.Deck.Language = deck.Publication.Language
+		export.Deck.Subjects = deck.Publication.Subjects
+	}
+
+	cardIDs := make(map[string]bool)
+	err = s.forEachCard(ctx, deckID, func(id string, _ models.Card, raw json.RawMessage) error {
+		export.Cards = append(export.Cards, raw)
+		cardIDs[id] = true
+		return nil
+	})
+	if err != nil {
+		return models.DeckExport{}, err
+	}
+	export.Deck.CardCount = len(export.Cards)
+
+	export.Notes, export.NoteTypes, err = s.exportNotes(ctx, deckID)
+	if err != nil {
+		return models.DeckExport{}, err
+	}
+
+	if !includeProgress {
+		return export, nil
+	}
+
+	progress, err := s.Cards.repo.GetProgressInDeck(ctx, deckID, userID)
+	if err != nil {
+		return models.DeckExport{}, err
+	}
+
+	for progressID, p := range progress {
+		cardID, direction := utils.SplitProgressID(progressID)
+		// Progress left behind by deleted cards is not exported
+		if !cardIDs[cardID] {
+			continue
+		}
+		export.Progress = append(export.Progress, models.ExportedProgress{
+			CardID:       cardID,
+			Direction:    direction,
+			CardProgress: p,
+		})
+	}
+
+	slices.SortFunc(export.Progress, func(a, b models.ExportedProgress) int {
+		return cmp.Or(cmp.Compare(a.CardID, b.CardID), cmp.Compare(a.Direction, b.Direction))
+	})
+
+	return export, nil
+}
+
+// ImportDeck recreates an exported deck owned by the user, with new IDs for the deck,
+// its cards, its notes and their note types, which are owned by the user as well.
+// Cards generated from notes missing from the export become front/back cards.
+// Returns a summary of the import, or an error if the schema version is unknown
+// or a card, note, note type or progress is not valid, in which case nothing is created.
+func (s *DeckService) ImportDeck(
+	ctx context.Context,
+	ownerID string,
+	export models.DeckExport,
+	includeProgress bool,
+) (models.ImportSummary, error) {
+	if export.SchemaVersion != utils.EXPORT_SCHEMA_VERSION {
+		return models.ImportSummary{}, errors.ErrUnsupportedVersion
+	}
+
+	// Progress is checked along with the rest of the export
+	if err := s.validate.Struct(export); err != nil {
+		return models.ImportSummary{}, errors.ErrInvalidImport
+	}
+
+	noteTypes, notes, err := s.prepareImportedNotes(export)
+	if err != nil {
+		return models.ImportSummary{}, fmt.Errorf("%w: %w", errors.ErrInvalidImport, err)
+	}
+
+	cards := make([]models.Card, 0, len(export.Cards))
+	oldIDs := make([]string, 0, len(export.Cards))
+	// Cards generated from an imported note are generated again from it
+	noteCards := make(map[string]models.NoteCard)
+	for _, raw := range export.Cards {
+		card, err := GetCardStruct(raw, errors.ErrInvalidCard)
+		if err != nil {
+			return models.ImportSummary{}, fmt.Errorf("%w: %w", errors.ErrInvalidImport, err)
+		}
+
+		var ref struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(raw, &ref)
+
+		if noteCard, ok := card.(*models.NoteCard); ok {
+			if _, ok := notes[noteCard.NoteID]; ok {
+				noteCards[ref.ID] = *noteCard
+				continue
+			}
+		}
+
+		card = detachCard(card)
+
+		// Checked before creating the deck, so an invalid export leaves nothing behind
+		if err := s.Cards.prepareNewCard(card); err != nil {
+			return models.ImportSummary{}, fmt.Errorf("%w: %w", errors.ErrInvalidImport, err)
+		}
+
+		cards = append(cards, card)
+		oldIDs = append(oldIDs, ref.ID)
+	}
+
+	deckID, err := s.RegisterNewDeck(ctx, models.CreateDeck{
+		Title:        export.Deck.Title,
+		OwnerID:      ownerID,
+		SharedEmails: []string{},
+	})
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	summary := models.ImportSummary{DeckID: deckID, Skipped: make(map[string]int)}
+
+	// A failure past this point removes the deck, so a retry does not leave a copy behind
+	newIDs, noteTypeIDs, err := s.importCards(ctx, deckID, ownerID, cards, oldIDs, noteTypes, notes, noteCards, &summary)
+	if err != nil {
+		s.discardImport(ctx, deckID, noteTypeIDs)
+		return models.ImportSummary{}, err
+	}
+
+	if !includeProgress {
+		return summary, nil
+	}
+
+	progress := make(map[string]models.CardProgress, len(export.Progress))
+	for _, p := range export.Progress {
+		id, ok := newIDs[p.CardID]
+		if !ok {
+			summary.Skipped[skipUnknownCard]++
+			continue
+		}
+		progress[utils.ProgressID(id, p.Direction)] = p.CardProgress
+	}
+
+	if err := s.Cards.repo.SetProgressInBulk(ctx, deckID, ownerID, progress); err != nil {
+		s.discardImport(ctx, deckID, noteTypeIDs)
+		return models.ImportSummary{}, err
+	}
+	summary.Progress = len(progress)
+
+	return summary, nil
+}
+
+// exportNotes reads every note of a deck, along with the note types they use.
+// Notes whose note type was deleted are left out, their cards are exported on their own.
+// Returns the notes and note types, or an error if the operation fails.
+func (s *DeckService) exportNotes(
+	ctx context.Context,
+	deckID string,
+) ([]models.Note, []models.NoteType, error) {
+	var notes []models.Note
+	var noteTypes []models.NoteType
+	found := make(map[string]bool)
+
+	cursor := ""
+	for {
+		page, hasMore, err := s.Notes.repo.GetNotesInDeck(ctx, deckID, utils.ParseLimit(exportPageSize), cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, note := range page {
+			cursor = note.ID
+
+			exists, checked := found[note.NoteTypeID]
+			if !checked {
+				noteType, err := s.Notes.repo.GetNoteType(ctx, note.NoteTypeID)
+				exists = err == nil
+				found[note.NoteTypeID] = exists
+				if exists {
+					// The note type is owned by whoever imports it
+					noteType.OwnerID = ""
+					noteTypes = append(noteTypes, noteType)
+				}
+			}
+			if exists {
+				notes = append(notes, note)
+			}
+		}
+
+		if !hasMore || len(page) == 0 {
+			return notes, noteTypes, nil
+		}
+	}
+}
+
+// prepareImportedNotes checks the note types and notes of an export, and that every note
+// uses one of the note types and generates cards.
+// Returns the note types and the notes keyed by their ID in the export, or an error if one is not valid.
+func (s *DeckService) prepareImportedNotes(
+	export models.DeckExport,
+) (map[string]models.NoteType, map[string]models.Note, error) {
+	noteTypes := make(map[string]models.NoteType, len(export.NoteTypes))
+	for _, noteType := range export.NoteTypes {
+		err := s.Notes.validateNoteType(models.CreateNoteType{
+			Name:      noteType.Name,
+			Fields:    noteType.Fields,
+			Templates: noteType.Templates,
+			Format:    noteType.Format,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if noteType.ID == "" {
+			return nil, nil, errors.ErrInvalidNoteType
+		}
+		noteTypes[noteType.ID] = noteType
+	}
+
+	notes := make(map[string]models.Note, len(export.Notes))
+	for _, note := range export.Notes {
+		noteType, ok := noteTypes[note.NoteTypeID]
+		if !ok || note.ID == "" {
+			return nil, nil, errors.ErrInvalidNote
+		}
+		for name := range note.Fields {
+			if !slices.Contains(noteType.Fields, name) {
+				return nil, nil, errors.ErrInvalidNote
+			}
+		}
+		note.Tags = normalizeTags(note.Tags)
+		if _, err := generateCards(noteType, note); err != nil {
+			return nil, nil, err
+		}
+		notes[note.ID] = note
+	}
+
+	return noteTypes, notes, nil
+}
+
+// importCards creates the cards of an import in a new deck, along with its note types
+// owned by the user and its notes, counting them in the summary.
+// Returns the new card IDs keyed by their ID in the export and the IDs of the note types
+// created, also when the operation fails, or an error if it fails.
+func (s *DeckService) importCards(
+	ctx context.Context,
+	deckID, ownerID string,
+	cards []models.Card,
+	oldIDs []string,
+	noteTypes map[string]models.NoteType,
+	notes map[string]models.Note,
+	noteCards map[string]models.NoteCard,
+	summary *models.ImportSummary,
+) (map[string]string, []string, error) {
+	ids, err := s.Cards.CreateCards(ctx, deckID, cards)
+	if err != nil {
+		return nil, nil, err
+	}
+	summary.Cards = len(ids)
+
+	newIDs := make(map[string]string, len(ids)+len(noteCards))
+	for i, id := range ids {
+		if oldIDs[i] != "" {
+			newIDs[oldIDs[i]] = id
+		}
+	}
+
+	noteTypeIDs := make(map[string]string, len(noteTypes))
+	created := make([]string, 0, len(noteTypes))
+	for oldID, noteType := range noteTypes {
+		noteType.OwnerID = ownerID
+		id, err := s.Notes.repo.CreateNoteType(ctx, noteType)
+		if err != nil {
+			return nil, created, err
+		}
+		noteTypeIDs[oldID] = id
+		created = append(created, id)
+	}
+
+	noteIDs := make(map[string]string, len(notes))
+	generated := make(map[string]bool)
+	for oldID, note := range notes {
+		noteType := noteTypes[note.NoteTypeID]
+		noteType.ID = noteTypeIDs[note.NoteTypeID]
+		note.ID = s.Notes.repo.NewNoteID(deckID)
+		note.NoteTypeID = noteType.ID
+
+		saved, err := s.Notes.saveNote(ctx, deckID, noteType, note)
+		if err != nil {
+			return nil, created, err
+		}
+		noteIDs[oldID] = note.ID
+		for _, card := range saved.Cards {
+			generated[card.ID] = true
+		}
+		summary.Cards += len(saved.Cards)
+	}
+
+	// Note cards are found again by their note and template, if the template still generates one
+	for oldID, card := range noteCards {
+		if id := utils.NoteCardID(noteIDs[card.NoteID], card.Template); generated[id] {
+			newIDs[oldID] = id
+		}
+	}
+
+	return newIDs, created, nil
+}
+
+// discardImport removes a deck created by an import that failed halfway, with everything
+// already stored in it. Note types created for it are deleted too, once no note uses them.
+// Failing to remove it is only logged, as the error of the import is reported.
+func (s *DeckService) discardImport(ctx context.Context, deckID string, noteTypeIDs []string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := s.repo.DiscardDeck(ctx, deckID); err != nil {
+		slog.Error("failed to discard the deck of a failed import", "deckID", deckID, "error", err)
+		return
+	}
+	s.invalidateDeckCaches(ctx, deckID)
+
+	for _, id := range noteTypeIDs {
+		if err := s.Notes.repo.DeleteNoteType(ctx, id); err != nil {
+			slog.Error("failed to delete a note type of a failed import", "noteTypeID", id, "error", err)
+		}
+	}
+}
+
+// detachCard prepares a card to be copied into another deck, without its ID
+// or its link to a source card.
+// Cards generated from notes become front/back cards, as their notes are not copied.
+func detachCard(card models.Card) models.Card {
+	if note, ok := card.(*models.NoteCard); ok {
+		card = &models.FrontBackCard{
+			Type:     utils.FRONT_BACK_CARD,
+			Front:    note.Front,
+			Back:     note.Back,
+			CardMeta: note.CardMeta,
+		}
+	}
+	card.SetID("")
+	card.Meta().Upstream = nil
+	return card
+}
+
+// forEachCard calls fn with the ID, card and JSON of every card in a deck,
+// reading them page by page.
+// Returns the first error of fn, or an error if the cards can not be read.
+func (s *DeckService) forEachCard(
+	ctx context.Context,
+	deckID string,
+	fn func(id string, card models.Card, raw json.RawMessage) error,
+) error {
+	cursor := ""
+	for {
+		cards, hasMore, err := s.Cards.GetCardsInDeck(ctx, deckID, exportPageSize, cursor, models.TagFilter{})
+		if err != nil {
+			return err
+		}
+
+		for _, card := range cards {
+			raw, err := json.Marshal(card)
+			if err != nil {
+				return err
+			}
+
+			var ref struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(raw, &ref); err != nil {
+				return err
+			}
+
+			if err := fn(ref.ID, card, raw); err != nil {
+				return err
+			}
+			cursor = ref.ID
+		}
+
+		if !hasMore || len(cards) == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+)
+
+// ForkDeck copies a deck and all its cards into a new deck owned by the user,
+// which remembers the deck it was forked from and when.
+// Cards generated from notes become front/back cards, as notes are not copied.
+// The progress of the user is carried over onto the new cards when asked for.
+// Returns a summary of the fork, or an error if the operation fails.
+func (s *DeckService) ForkDeck(
+	ctx context.Context,
+	deckID, userID string,
+	options models.ForkDeck,
+) (models.ImportSummary, error) {
+	return s.copyDeck(ctx, deckID, userID, options, false)
+}
+
+// copyDeck copies a deck and its cards into a new deck owned by the user.
+// A linked copy follows the source deck and links every card to its source card,
+// any other copy only remembers the deck it was forked from.
+// Returns a summary of the copy, or an error if the operation fails.
+func (s *DeckService) copyDeck(
+	ctx context.Context,
+	deckID, userID string,
+	options models.ForkDeck,
+	linked bool,
+) (models.ImportSummary, error) {
+	source, err := s.repo.GetOneDeck(ctx, deckID, []string{"title"})
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	var cards []models.Card
+	var oldIDs []string
+	err = s.forEachCard(ctx, deckID, func(id string, card models.Card, _ json.RawMessage) error {
+		card = detachCard(card)
+		if linked {
+			hash, err := syncHash(card)
+			if err != nil {
+				return err
+			}
+			card.Meta().Upstream = &models.CardUpstream{CardID: id, Hash: hash}
+		}
+
+		cards = append(cards, card)
+		oldIDs = append(oldIDs, id)
+		return nil
+	})
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	title := options.Title
+	if title == "" {
+		title = source.Title
+	}
+
+	now := time.Now().UTC()
+	deck := models.CreateDeck{
+		Title:        title,
+		OwnerID:      userID,
+		SharedEmails: []string{},
+	}
+	if linked {
+		deck.Upstream = &models.DeckUpstream{DeckID: deckID, SyncedAt: now}
+	} else {
+		deck.ForkedFrom, deck.ForkedAt = deckID, &now
+	}
+
+	newDeckID, err := s.RegisterNewDeck(ctx, deck)
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	summary := models.ImportSummary{DeckID: newDeckID, Skipped: make(map[string]int)}
+
+	ids, err := s.Cards.CreateCards(ctx, newDeckID, cards)
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+	summary.Cards = len(ids)
+
+	if !options.IncludeProgress {
+		return summary, nil
+	}
+
+	newIDs := make(map[string]string, len(ids))
+	for i, id := range ids {
+		newIDs[oldIDs[i]] = id
+	}
+
+	progress, err := s.Cards.repo.GetProgressInDeck(ctx, deckID, userID)
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	forked := make(map[string]models.CardProgress, len(progress))
+	for progressID, p := range progress {
+		cardID, direction := utils.SplitProgressID(progressID)
+		id, ok := newIDs[cardID]
+		// Progress left behind by deleted cards is not carried over
+		if !ok {
+			summary.Skipped[skipUnknownCard]++
+			continue
+		}
+		forked[utils.ProgressID(id, direction)] = p
+	}
+
+	if err := s.Cards.repo.SetProgressInBulk(ctx, newDeckID, userID, forked); err != nil {
+		return models.ImportSummary{}, err
+	}
+	summary.Progress = len(forked)
+
+	return summary, nil
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"memora/internal/anki"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reasons reported in the summary of an import
+const (
+	skipUnknownNoteType = "unknown_note_type"
+	skipEmptyCard       = "empty_card"
+	skipInvalidCard     = "invalid_card"
+	skipInvalidTag      = "invalid_tag"
+	skipMedia           = "media"
+	skipUnknownCard     = "unknown_card"
+)
+
+// Title of an imported deck when the package does not name one
+const defaultImportTitle = "Anki import"
+
+// importedCard is a card converted from another application, along with the
+// cards it came from in that application, keyed by the direction they match.
+type importedCard struct {
+	card    models.Card
+	sources map[string]anki.Card
+}
+
+// ImportAnki creates a deck owned by the user from an Anki package.
+// Basic notes become front/back cards, reviewed in both directions when the note has
+// a reversed card, and every cloze number of a cloze note becomes a blanks card.
+// Notes of other types become a front/back card per template.
+// Media is not imported, and the review history only when asked for.
+// Returns a summary of the import, or an error if the package holds no card to import.
+func (s *DeckService) ImportAnki(
+	ctx context.Context,
+	ownerID string,
+	file io.ReaderAt,
+	size int64,
+	options models.AnkiImportOptions,
+) (models.ImportSummary, error) {
+	col, err := anki.Open(file, size)
+	if err != nil {
+		return models.ImportSummary{}, fmt.Errorf("%w: %v", errors.ErrInvalidImport, err)
+	}
+
+	summary := models.ImportSummary{Skipped: make(map[string]int)}
+
+	var cards []models.Card
+	var imported []importedCard
+	for _, c := range convertAnkiCollection(col, summary.Skipped) {
+		if err := s.Cards.prepareNewCard(c.card); err != nil {
+			summary.Skipped[skipInvalidCard]++
+			continue
+		}
+		cards = append(cards, c.card)
+		imported = append(imported, c)
+	}
+
+	if len(cards) == 0 {
+		return models.ImportSummary{}, errors.ErrInvalidImport
+	}
+
+	title := strings.TrimSpace(options.Title)
+	if title == "" {
+		title = ankiDeckTitle(col)
+	}
+
+	summary.DeckID, err = s.RegisterNewDeck(ctx, models.CreateDeck{
+		Title:        title,
+		OwnerID:      ownerID,
+		SharedEmails: []string{},
+	})
+	if err != nil {
+		return models.ImportSummary{}, err
+	}
+
+	// A failure past this point removes the deck, so a retry does not leave a copy behind
+	ids, err := s.Cards.CreateCards(ctx, summary.DeckID, cards)
+	if err != nil {
+		s.discardImport(ctx, summary.DeckID, nil)
+		return models.ImportSummary{}, err
+	}
+	summary.Cards = len(ids)
+
+	if !options.IncludeProgress {
+		return summary, nil
+	}
+
+	progress := make(map[string]models.CardProgress)
+	for i, c := range imported {
+		for direction, source := range c.sources {
+			if p, ok := ankiProgress(source, col.Created); ok {
+				progress[utils.ProgressID(ids[i], direction)] = p
+			}
+		}
+	}
+
+	err = s.Cards.repo.SetProgressInBulk(ctx, summary.DeckID, ownerID, progress)
+	if err != nil {
+		s.discardImport(ctx, summary.DeckID, nil)
+		return models.ImportSummary{}, err
+	}
+	summary.Progress = len(progress)
+
+	return summary, nil
+}
+
+// convertAnkiCollection converts the notes of a collection to cards,
+// counting what could not be converted in skipped.
+func convertAnkiCollection(col *anki.Collection, skipped map[string]int) []importedCard {
+	// Cards are tagged with their Anki deck when they come from several decks
+	decks := make(map[int64]bool)
+	for _, cards := range col.Cards {
+		for _, c := range cards {
+			decks[c.DeckID] = true
+		}
+	}
+
+	var result []importedCard
+	for _, note := range col.Notes {
+		model, ok := col.Models[note.ModelID]
+		if !ok {
+			skipped[skipUnknownNoteType]++
+			continue
+		}
+
+		cards := col.Cards[note.ID]
+		if len(cards) == 0 {
+			skipped[skipEmptyCard]++
+			continue
+		}
+
+		fields := make(map[string]string, len(model.Fields))
+		hasMedia := false
+		for i, name := range model.Fields {
+			if i < len(note.Fields) {
+				value, removed := anki.StripMedia(note.Fields[i])
+				fields[name] = value
+				hasMedia = hasMedia || removed
+			}
+		}
+		if hasMedia {
+			skipped[skipMedia]++
+		}
+
+		tags := slices.Clone(note.Tags)
+		if len(decks) > 1 {
+			tags = append(tags, col.Decks[cards[0].DeckID])
+		}
+		meta := models.CardMeta{
+			Tags:   ankiTags(tags, skipped),
+			Format: utils.FORMAT_MARKDOWN,
+		}
+
+		if model.Cloze {
+			result = append(result, convertClozeNote(model, fields, cards, meta, skipped)...)
+		} else {
+			result = append(result, convertStandardNote(model, fields, cards, meta, skipped)...)
+		}
+	}
+
+	return result
+}
+
+// convertClozeNote converts every cloze number of a note to a blanks card.
+func convertClozeNote(
+	model anki.Model,
+	fields map[string]string,
+	cards []anki.Card,
+	meta models.CardMeta,
+	skipped map[string]int,
+) []importedCard {
+	field := ""
+	if len(model.Templates) > 0 {
+		field = anki.ClozeTemplateField(model.Templates[0].Front)
+	}
+	if field == "" && len(model.Fields) > 0 {
+		field = model.Fields[0]
+	}
+	text := fields[field]
+
+	var result []importedCard
+	for _, c := range cards {
+		question, answers := anki.RenderCloze(text, c.Ord+1)
+		if len(answers) == 0 {
+			skipped[skipEmptyCard]++
+			continue
+		}
+
+		// Braces already in the text would be read as more blanks
+		if strings.Count(question, "{}") != len(answers) {
+			skipped[skipInvalidCard]++
+			continue
+		}
+
+		result = append(result, importedCard{
+			card: &models.BlanksCard{
+				Type:     utils.BLANKS_CARD,
+				Question: strings.TrimSpace(question),
+				Answers:  answers,
+				CardMeta: cloneMeta(meta),
+			},
+			sources: map[string]anki.Card{utils.DIRECTION_FORWARD: c},
+		})
+	}
+
+	return result
+}
+
+// convertStandardNote converts the cards of a note to front/back cards.
+// A note whose second card swaps the sides of the first becomes a single card
+// reviewed in both directions, as with the "Basic (and reversed card)" note type.
+func convertStandardNote(
+	model anki.Model,
+	fields map[string]string,
+	cards []anki.Card,
+	meta models.CardMeta,
+	skipped map[string]int,
+) []importedCard {
+	type side struct{ front, back string }
+
+	sides := make([]side, 0, len(cards))
+	sources := make([]anki.Card, 0, len(cards))
+	for _, c := range cards {
+		if c.Ord >= len(model.Templates) {
+			skipped[skipEmptyCard]++
+			continue
+		}
+		template := model.Templates[c.Ord]
+
+		front := strings.TrimSpace(anki.RenderTemplate(template.Front, fields))
+		back := strings.TrimSpace(anki.RenderAnswer(template.Back, fields))
+		if front == "" || back == "" {
+			skipped[skipEmptyCard]++
+			continue
+		}
+
+		sides = append(sides, side{front, back})
+		sources = append(sources, c)
+	}
+
+	if len(sides) == 2 && sides[0].front == sides[1].back && sides[0].back == sides[1].front {
+		return []importedCard{{
+			card: &models.FrontBackCard{
+				Type:      utils.FRONT_BACK_CARD,
+				Front:     sides[0].front,
+				Back:      sides[0].back,
+				Direction: utils.DIRECTION_BOTH,
+				CardMeta:  meta,
+			},
+			sources: map[string]anki.Card{
+				utils.DIRECTION_FORWARD: sources[0],
+				utils.DIRECTION_REVERSE: sources[1],
+			},
+		}}
+	}
+
+	result := make([]importedCard, 0, len(sides))
+	for i, s := range sides {
+		result = append(result, importedCard{
+			card: &models.FrontBackCard{
+				Type:     utils.FRONT_BACK_CARD,
+				Front:    s.front,
+				Back:     s.back,
+				CardMeta: cloneMeta(meta),
+			},
+			sources: map[string]anki.Card{utils.DIRECTION_FORWARD: sources[i]},
+		})
+	}
+
+	return result
+}
+
+// ankiTags keeps the tags that are valid on a card, counting the others in skipped.
+func ankiTags(tags []string, skipped map[string]int) []string {
+	var result []string
+	for _, tag := range tags {
+		if tag == "" || len(tag) > 50 || strings.Contains(tag, ",") {
+			skipped[skipInvalidTag]++
+			continue
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// cloneMeta copies card metadata, so cards of the same note do not share their tags.
+func cloneMeta(meta models.CardMeta) models.CardMeta {
+	meta.Tags = append([]string(nil), meta.Tags...)
+	return meta
+}
+
+// ankiDeckTitle returns the name of the Anki deck holding the most cards.
+func ankiDeckTitle(col *anki.Collection) string {
+	counts := make(map[int64]int)
+	for _, cards := range col.Cards {
+		for _, c := range cards {
+			counts[c.DeckID]++
+		}
+	}
+
+	title, most := "", 0
+	for deckID, count := range counts {
+		name := col.Decks[deckID]
+		if count > most || (count == most && name < title) {
+			title, most = name, count
+		}
+	}
+
+	if title == "" {
+		return defaultImportTitle
+	}
+	return title
+}
+
+// ankiProgress converts the scheduling of an Anki card to progress.
+// Returns false for cards that were never studied.
+func ankiProgress(c anki.Card, created time.Time) (models.CardProgress, bool) {
+	if c.Type == 0 {
+		return models.CardProgress{}, false
+	}
+
+	progress := models.CardProgress{
+		EaseFactor:   min(max(c.Factor, 1300), 3000),
+		Reps:         c.Reps,
+		Lapses:       c.Lapses,
+		LastReviewed: c.LastReview,
+	}
+
+	// Cards still being learned have no ease factor yet
+	if c.Factor == 0 {
+		progress.EaseFactor = 2500
+	}
+
+	// Negative intervals are in seconds, for cards being learned
+	if c.Interval >= 0 {
+		progress.Interval = float64(c.Interval)
+	} else {
+		progress.Interval = float64(-c.Interval) / (24 * 60 * 60)
+	}
+
+	// Cards being learned are due at a timestamp, others on a day counted from the
+	// creation of the collection. Day numbers never get close to a timestamp.
+	if c.Due > 1_000_000_000 {
+		progress.Due = time.Unix(c.Due, 0)
+	} else {
+		progress.Due = created.AddDate(0, 0, int(c.Due))
+	}
+
+	return progress, true
+}
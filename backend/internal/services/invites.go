@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+)
+
+// GetDeckInvites lists the pending invites to a deck.
+// Returns the invites or an error if the operation fails.
+func (s *DeckService) GetDeckInvites(
+	ctx context.Context,
+	deckID string,
+) ([]models.Invite, error) {
+	return s.invites.GetDeckInvites(ctx, deckID)
+}
+
+// RevokeInvite deletes a pending invite to a deck.
+// Returns an error if the invite is not one of the deck or the operation fails.
+func (s *DeckService) RevokeInvite(
+	ctx context.Context,
+	deckID, inviteID string,
+) error {
+	invite, err := s.invites.GetInvite(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	if invite.DeckID != deckID {
+		return errors.ErrNotFound
+	}
+
+	if err := s.invites.DeleteInvite(ctx, inviteID); err != nil {
+		return err
+	}
+
+	s.recordInvites(ctx, deckID, []string{invite.Email}, invite.Role, false)
+
+	return nil
+}
+
+// GetInvites lists the pending invites of the email of a user.
+// Returns the invites or an error if the operation fails.
+func (s *UserService) GetInvites(
+	ctx context.Context,
+	email string,
+) ([]models.Invite, error) {
+	return s.invites.GetEmailInvites(ctx, email)
+}
+
+// AcceptInvite makes a user a member of the deck of an invite to their email.
+// Returns an error if the invite is not for the email, the email is not verified
+// or the operation fails.
+func (s *UserService) AcceptInvite(
+	ctx context.Context,
+	inviteID, userID, email string,
+	emailVerified bool,
+) error {
+	invite, err := s.invites.GetInvite(ctx, inviteID)
+	if err != nil {
+		return err
+	}
+	// Invites of other emails are not revealed
+	if invite.Email != utils.NormalizeEmail(email) {
+		return errors.ErrNotFound
+	}
+
+	return s.acceptInvites(ctx, userID, []models.Invite{invite}, emailVerified)
+}
+
+// acceptInvites turns invites into memberships of a user, and invalidates the caches
+// of the decks joined and of the deck list of the user.
+// Invites are matched by email, so they are only accepted for a verified email,
+// otherwise anyone signing up with an invited email would get the role.
+// Returns an error if the email is not verified or the operation fails.
+func (s *UserService) acceptInvites(
+	ctx context.Context,
+	userID string,
+	invites []models.Invite,
+	emailVerified bool,
+) error {
+	if len(invites) == 0 {
+		return nil
+	}
+	if !emailVerified {
+		return errors.ErrEmailNotVerified
+	}
+
+	joined, err := s.invites.AcceptInvites(ctx, userID, invites)
+	if err != nil {
+		return err
+	}
+
+	for _, deckID := range joined {
+		s.cache.Delete(ctx, utils.DeckKey(deckID))
+		s.cache.DeletePattern(ctx, utils.DeckRolesKey(deckID)+"*")
+	}
+	s.cache.Delete(ctx, utils.UserDecksKey(userID))
+
+	return nil
+}
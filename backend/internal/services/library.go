@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+
+	"cloud.google.com/go/firestore"
+)
+
+// PublishDeck lists a deck in the public library, making it readable by anyone.
+// Publishing a public deck again updates its listing.
+// Returns the published deck, or an error if the listing is not valid or the operation fails.
+func (s *DeckService) PublishDeck(
+	ctx context.Context,
+	deckID string,
+	listing models.PublishDeck,
+) (models.Deck, error) {
+	listing.Subjects = normalizeTags(listing.Subjects)
+	if listing.Subjects == nil {
+		listing.Subjects = []string{}
+	}
+
+	if err := s.validate.Struct(listing); err != nil {
+		return models.Deck{}, errors.ErrInvalidDeck
+	}
+
+	before, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		return models.Deck{}, err
+	}
+
+	if err := s.repo.PublishDeck(ctx, deckID, listing); err != nil {
+		return models.Deck{}, err
+	}
+
+	s.cache.Delete(ctx, utils.DeckKey(deckID))
+	s.cache.DeletePattern(ctx, utils.DeckRolesKey(deckID)+"*")
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_PUBLISH, &before)
+
+	return s.GetOneDeck(ctx, deckID, defaultFilterDecks)
+}
+
+// UnpublishDeck removes a deck from the public library. Its listing and learners are kept
+// for when it is published again.
+// Returns an error if the operation fails.
+func (s *DeckService) UnpublishDeck(ctx context.Context, deckID string) error {
+	before, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		return err
+	}
+
+	update := []firestore.Update{{Path: "public", Value: false}}
+	if err := s.repo.UpdateDeck(ctx, update, deckID); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, utils.DeckKey(deckID))
+	s.cache.DeletePattern(ctx, utils.DeckRolesKey(deckID)+"*")
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_PUBLISH, &before)
+
+	return nil
+}
+
+// ListPublicDecks retrieves a page of the public library, the most studied decks first
+// unless sorted by recency, along with the number of cards of each deck.
+// The limit is capped, and a search may return a short page before the last one.
+// Returns the page, or an error if the query is not valid or the operation fails.
+func (s *DeckService) ListPublicDecks(
+	ctx context.Context,
+	query models.LibraryQuery,
+) (models.PublicDecksResponse, error) {
+	if err := s.validate.Struct(query); err != nil {
+		return models.PublicDecksResponse{}, errors.ErrInvalidDeck
+	}
+
+	limit := min(utils.ParseLimit(query.Limit), utils.MAX_LIBRARY_LIMIT)
+	decks, cursor, err := s.repo.ListPublicDecks(ctx, query, limit)
+	if err != nil {
+		return models.PublicDecksResponse{}, err
+	}
+
+	for i := range decks {
+		decks[i].CardCount, err = s.Cards.CountCards(ctx, decks[i].ID)
+		if err != nil {
+			return models.PublicDecksResponse{}, err
+		}
+	}
+
+	response := models.PublicDecksResponse{Decks: decks, HasMore: cursor != "", Cursor: cursor}
+	if response.Decks == nil {
+		response.Decks = []models.PublicDeck{}
+	}
+
+	return response, nil
+}
+
+// AddToStudyList adds a public deck to the study list of a user.
+// Returns an error if the deck is not public or the operation fails.
+func (s *DeckService) AddToStudyList(
+	ctx context.Context,
+	deckID, userID string,
+) error {
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"public"})
+	if err != nil {
+		return err
+	}
+	if !deck.Public {
+		return errors.ErrUnauthorized
+	}
+
+	if _, err := s.repo.AddToStudyList(ctx, deckID, userID); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, utils.UserDecksKey(userID))
+
+	return nil
+}
+
+// RemoveFromStudyList removes a deck from the study list of a user.
+// Returns an error if the operation fails.
+func (s *DeckService) RemoveFromStudyList(
+	ctx context.Context,
+	deckID, userID string,
+) error {
+	if _, err := s.repo.RemoveFromStudyList(ctx, deckID, userID); err != nil {
+		return err
+	}
+
+	s.cache.Delete(ctx, utils.UserDecksKey(userID))
+
+	return nil
+}
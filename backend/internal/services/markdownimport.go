@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ImportMarkdown turns the blocks of a Markdown document into cards of a deck.
+// Cards made from the same document before are found by the ID of their block,
+// or by its question, term or cloze text when it has none: changed blocks update
+// their card, keeping its tags and progress, and new blocks create one.
+// Editing the text of a block without an ID makes it a new block, its old card being
+// removed with the missing ones, so the report warns about such blocks when removing.
+// Cards of blocks no longer in the document are deleted when asked for.
+// Nothing is changed when a block has an error.
+// Returns a report of the import, or an error if the options are not valid or the operation fails.
+func (s *CardService) ImportMarkdown(
+	ctx context.Context,
+	deckID, text string,
+	options models.MarkdownImportOptions,
+) (models.MarkdownImportReport, error) {
+	if err := s.validate.Struct(options); err != nil {
+		return models.MarkdownImportReport{}, errors.ErrInvalidImport
+	}
+	document := options.Source
+
+	report := models.MarkdownImportReport{Errors: []models.RowError{}}
+
+	blocks, blockErrs := utils.ParseMarkdownCards(text)
+	for _, blockErr := range blockErrs {
+		report.Errors = append(report.Errors, models.RowError{Row: blockErr.Line, Error: blockErr.Err})
+	}
+
+	if len(blocks) > utils.MAX_IMPORT_ROWS {
+		report.Errors = append(report.Errors, models.RowError{
+			Row:   blocks[utils.MAX_IMPORT_ROWS].Line,
+			Error: fmt.Sprintf("documents are limited to %d cards", utils.MAX_IMPORT_ROWS),
+		})
+		return report, nil
+	}
+
+	cards := make(map[string]models.Card, len(blocks))
+	var keys []string
+	firstLine := make(map[string]int, len(blocks))
+	for _, block := range blocks {
+		// The kind is part of the key, so a question never updates a cloze card
+		key := utils.BlockKeyHash(block.Kind + ":" + block.Key)
+		if block.ID != "" {
+			key = utils.BlockKeyHash("id:" + block.Kind + ":" + block.ID)
+		} else if options.RemoveMissing {
+			report.Warnings = append(report.Warnings, models.RowError{
+				Row:   block.Line,
+				Error: "block without an id, editing its text replaces its card",
+			})
+		}
+		if line, ok := firstLine[key]; ok {
+			report.Errors = append(report.Errors, models.RowError{
+				Row:   block.Line,
+				Error: fmt.Sprintf("same block as line %d", line),
+			})
+			continue
+		}
+		firstLine[key] = block.Line
+
+		card, err := s.markdownBlockToCard(block, document, key)
+		if err != nil {
+			report.Errors = append(report.Errors, models.RowError{
+				Row:   block.Line,
+				Error: describeCardError(err, csvFields(card), nil),
+			})
+			continue
+		}
+		cards[key] = card
+		keys = append(keys, key)
+	}
+
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	sources, err := s.repo.GetCardSources(ctx, deckID, document)
+	if err != nil {
+		return models.MarkdownImportReport{}, err
+	}
+
+	existing := make(map[string]string, len(sources))
+	var missing []string
+	for id, source := range sources {
+		if _, ok := cards[source.Key]; ok {
+			existing[source.Key] = id
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	var created []any
+	updates := make(map[string][]firestore.Update)
+	for _, key := range keys {
+		card := cards[key]
+
+		id, ok := existing[key]
+		if !ok {
+			created = append(created, card)
+			continue
+		}
+		if sources[id].Hash == card.Meta().Source.Hash {
+			report.Unchanged++
+			continue
+		}
+
+		// Tags are left out of the update, so the ones added since the last import are kept
+		update, err := utils.StructToUpdate(card)
+		if err != nil {
+			return models.MarkdownImportReport{}, err
+		}
+		updates[id] = update
+	}
+
+	// The updated and removed cards are read first to record what changed
+	changed := slices.Collect(maps.Keys(updates))
+	if options.RemoveMissing {
+		changed = append(changed, missing...)
+	}
+	before, err := s.repo.GetCardsByID(ctx, deckID, changed)
+	if err != nil {
+		return models.MarkdownImportReport{}, err
+	}
+
+	ids, err := s.repo.CreateCards(ctx, deckID, created)
+	if err != nil {
+		return models.MarkdownImportReport{}, err
+	}
+	report.Created = len(ids)
+
+	report.Updated, err = s.repo.UpdateCards(ctx, deckID, updates, newRevision(ctx, ""))
+	if err != nil {
+		return models.MarkdownImportReport{}, err
+	}
+
+	if options.RemoveMissing {
+		// Removed cards go to the trash, so an unintended removal can be restored
+		err := s.repo.DeleteCards(ctx, deckID, missing, utils.UserIDFromContext(ctx), time.Now().UTC())
+		if err != nil {
+			return models.MarkdownImportReport{}, err
+		}
+		report.Removed = len(missing)
+	}
+
+	for id := range updates {
+		s.cache.Delete(ctx, utils.DeckCardKey(deckID, id))
+	}
+	for _, id := range missing {
+		s.cache.Delete(ctx, utils.DeckCardKey(deckID, id))
+	}
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+
+	after := make(map[string]any, len(ids)+len(updates))
+	for i, id := range ids {
+		after[id] = created[i]
+	}
+	for id, update := range updates {
+		after[id] = applyUpdates(before[id], update)
+	}
+	s.audit.recordCardChanges(ctx, deckID, before, after)
+
+	return report, nil
+}
+
+// markdownBlockToCard converts a block to a Markdown card remembering its source,
+// and validates it.
+// Returns the card, and an error if it is not valid.
+func (s *CardService) markdownBlockToCard(
+	block utils.MarkdownBlock,
+	document, key string,
+) (models.Card, error) {
+	meta := models.CardMeta{Format: utils.FORMAT_MARKDOWN}
+
+	var card models.Card
+	if block.Kind == utils.BLOCK_CLOZE {
+		card = &models.BlanksCard{
+			Type:     utils.BLANKS_CARD,
+			Question: block.Front,
+			Answers:  block.Answers,
+			CardMeta: meta,
+		}
+	} else {
+		card = &models.FrontBackCard{
+			Type:     utils.FRONT_BACK_CARD,
+			Front:    block.Front,
+			Back:     block.Back,
+			CardMeta: meta,
+		}
+	}
+
+	if err := s.prepareNewCard(card); err != nil {
+		return card, err
+	}
+
+	// Hashed after sanitizing and before the source is set, so only the content counts
+	content, err := json.Marshal(card)
+	if err != nil {
+		return card, err
+	}
+	card.Meta().Source = &models.CardSource{
+		Document: document,
+		Key:      key,
+		Hash:     utils.ContentHash(content),
+	}
+
+	return card, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+)
+
+// GetDeckMembers lists the members of a deck with their role, sorted by email.
+// Returns the members or an error if the deck could not be fetched.
+func (s *DeckService) GetDeckMembers(
+	ctx context.Context,
+	deckID string,
+) (models.DeckMembers, error) {
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"owner_id", "memberships"})
+	if err != nil {
+		return models.DeckMembers{}, err
+	}
+
+	members := models.DeckMembers{
+		OwnerID: deck.OwnerID,
+		Members: make([]models.DeckMember, 0, len(deck.Memberships)),
+	}
+	for id, membership := range deck.Memberships {
+		members.Members = append(members.Members, models.DeckMember{
+			UserID: id,
+			Email:  membership.Email,
+			Role:   membership.Role,
+		})
+	}
+	slices.SortFunc(members.Members, func(a, b models.DeckMember) int {
+		return cmp.Compare(a.Email, b.Email)
+	})
+
+	return members, nil
+}
+
+// SetMemberRole changes the role of a member of a deck, found by their ID or email.
+// Returns the member, or an error if the role is not valid, the user is not a member
+// or the operation fails.
+func (s *DeckService) SetMemberRole(
+	ctx context.Context,
+	deckID, member string,
+	update models.UpdateMemberRole,
+) (models.DeckMember, error) {
+	if err := s.validate.Struct(update); err != nil {
+		return models.DeckMember{}, errors.ErrInvalidDeck
+	}
+
+	memberID, deck, err := s.findMember(ctx, deckID, member)
+	if err != nil {
+		return models.DeckMember{}, err
+	}
+	membership := deck.Memberships[memberID]
+
+	if err := s.repo.SetMemberRole(ctx, deckID, memberID, update.Role); err != nil {
+		return models.DeckMember{}, err
+	}
+
+	s.clearDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_SHARE, &deck)
+
+	return models.DeckMember{UserID: memberID, Email: membership.Email, Role: update.Role}, nil
+}
+
+// RemoveMember stops sharing a deck with a member, found by their ID or email.
+// Returns an error if the user is not a member or the operation fails.
+func (s *DeckService) RemoveMember(
+	ctx context.Context,
+	deckID, member string,
+) error {
+	memberID, deck, err := s.findMember(ctx, deckID, member)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.RemoveMembers(ctx, deckID, []string{memberID}); err != nil {
+		return err
+	}
+
+	s.invalidateUserDecks(ctx, []string{memberID})
+	s.invalidateDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_SHARE, &deck)
+
+	return nil
+}
+
+// findMember finds a member of a deck by their ID, or by their email ignoring case.
+// The deck is fetched with the fields of its audit log, to record the change made to the member.
+// Returns the ID of the member and the deck, or an error if the user is not a member.
+func (s *DeckService) findMember(
+	ctx context.Context,
+	deckID, member string,
+) (string, models.Deck, error) {
+	deck, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		return "", models.Deck{}, err
+	}
+
+	id, ok := deck.MemberID(member)
+	if !ok {
+		return "", models.Deck{}, errors.ErrNotMember
+	}
+
+	return id, deck, nil
+}
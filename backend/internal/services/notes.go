@@ -0,0 +1,506 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"memora/internal/errors"
+	"memora/internal/firebase"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NoteService provides methods for managing note types, and the notes generating cards.
+type NoteService struct {
+	repo     firebase.NoteRepository
+	decks    firebase.DeckRepository
+	cache    *CacheService
+	audit    *AuditService
+	validate *validator.Validate
+}
+
+// NewNoteService creates a new instance of NoteService.
+func NewNoteService(
+	deps *ServiceDeps,
+) *NoteService {
+	return &NoteService{
+		repo:     deps.NoteRepo,
+		decks:    deps.DeckRepo,
+		cache:    deps.Cache,
+		audit:    NewAuditService(deps),
+		validate: deps.Validate,
+	}
+}
+
+// CreateNoteType validates and stores a new note type owned by the user.
+// Returns the ID of the note type or an error if the operation fails.
+func (s *NoteService) CreateNoteType(
+	ctx context.Context,
+	ownerID string,
+	create models.CreateNoteType,
+) (string, error) {
+	if err := s.validateNoteType(create); err != nil {
+		return "", err
+	}
+
+	return s.repo.CreateNoteType(ctx, models.NoteType{
+		OwnerID:   ownerID,
+		Name:      create.Name,
+		Fields:    create.Fields,
+		Templates: create.Templates,
+		Format:    create.Format,
+	})
+}
+
+// GetNoteTypes lists the note types owned by the user.
+// Returns the note types or an error if the operation fails.
+func (s *NoteService) GetNoteTypes(
+	ctx context.Context,
+	ownerID string,
+) ([]models.NoteType, error) {
+	noteTypes, err := s.repo.GetNoteTypesByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(noteTypes, func(a, b models.NoteType) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return noteTypes, nil
+}
+
+// GetNoteType retrieves a note type owned by the user.
+// Returns the note type, or an error if it does not exist or belongs to someone else.
+func (s *NoteService) GetNoteType(
+	ctx context.Context,
+	id, ownerID string,
+) (models.NoteType, error) {
+	noteType, err := s.repo.GetNoteType(ctx, id)
+	if err != nil {
+		return models.NoteType{}, err
+	}
+
+	if noteType.OwnerID != ownerID {
+		return models.NoteType{}, errors.ErrUnauthorized
+	}
+
+	return noteType, nil
+}
+
+// deckNoteType retrieves a note type to use for a note in a deck the user can edit.
+// The note type must be owned by the user or by the owner of the deck, or already be used
+// by notes of the deck, so editors share the note types of the deck but not those of others.
+// Returns the note type, or an error if it does not exist, can not be used in the deck
+// or the operation fails.
+func (s *NoteService) deckNoteType(
+	ctx context.Context,
+	deckID, noteTypeID, userID string,
+) (models.NoteType, error) {
+	noteType, err := s.repo.GetNoteType(ctx, noteTypeID)
+	if err != nil {
+		return models.NoteType{}, err
+	}
+	if noteType.OwnerID == userID {
+		return noteType, nil
+	}
+
+	deck, err := s.decks.GetOneDeck(ctx, deckID, []string{"owner_id"})
+	if err != nil {
+		return models.NoteType{}, err
+	}
+	if noteType.OwnerID == deck.OwnerID {
+		return noteType, nil
+	}
+
+	used, err := s.repo.NoteTypeUsedInDeck(ctx, deckID, noteTypeID)
+	if err != nil {
+		return models.NoteType{}, err
+	}
+	if !used {
+		return models.NoteType{}, errors.ErrUnauthorized
+	}
+
+	return noteType, nil
+}
+
+// UpdateNoteType replaces the fields and templates of a note type owned by the user.
+// The cards of the notes using the note type are generated again, in the decks the user
+// can still edit. Notes in other decks keep their cards until they are edited.
+// Returns the updated note type or an error if the operation fails.
+func (s *NoteService) UpdateNoteType(
+	ctx context.Context,
+	id, ownerID string,
+	update models.CreateNoteType,
+) (models.NoteType, error) {
+	if err := s.validateNoteType(update); err != nil {
+		return models.NoteType{}, err
+	}
+
+	noteType, err := s.GetNoteType(ctx, id, ownerID)
+	if err != nil {
+		return models.NoteType{}, err
+	}
+
+	noteType.Name = update.Name
+	noteType.Fields = update.Fields
+	noteType.Templates = update.Templates
+	noteType.Format = update.Format
+
+	if err := s.repo.UpdateNoteType(ctx, noteType); err != nil {
+		return models.NoteType{}, err
+	}
+
+	notes, err := s.repo.GetNotesByType(ctx, id)
+	if err != nil {
+		return models.NoteType{}, err
+	}
+
+	changedDecks := make(map[string]bool)
+	editable := make(map[string]bool)
+	for _, note := range notes {
+		canEdit, checked := editable[note.DeckID]
+		if !checked {
+			canEdit = s.canEditDeck(ctx, note.DeckID, ownerID)
+			editable[note.DeckID] = canEdit
+		}
+		if !canEdit {
+			continue
+		}
+
+		// Notes no longer generating any card keep their old cards,
+		// so they can be fixed by editing the note
+		cards, err := generateCards(noteType, note)
+		if err != nil {
+			continue
+		}
+
+		byID := cardsByID(cards)
+		before, err := s.repo.SaveNote(ctx, note.DeckID, note, byID, utils.UserIDFromContext(ctx), time.Now().UTC())
+		if err != nil {
+			return models.NoteType{}, err
+		}
+		s.audit.recordCardChanges(ctx, note.DeckID, before, auditedCards(byID))
+		changedDecks[note.DeckID] = true
+	}
+
+	for deckID := range changedDecks {
+		s.invalidateCardCaches(ctx, deckID)
+	}
+
+	return noteType, nil
+}
+
+// DeleteNoteType deletes a note type owned by the user, if no note uses it.
+// Returns an error if the note type is in use or the operation fails.
+func (s *NoteService) DeleteNoteType(
+	ctx context.Context,
+	id, ownerID string,
+) error {
+	if _, err := s.GetNoteType(ctx, id, ownerID); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteNoteType(ctx, id)
+}
+
+// PreviewNoteType renders the cards a note with the given fields would generate,
+// without storing anything.
+// Returns the rendered cards or an error if the fields do not generate any card.
+func (s *NoteService) PreviewNoteType(
+	ctx context.Context,
+	id, ownerID string,
+	preview models.PreviewNote,
+) ([]models.NoteCard, error) {
+	if err := s.validate.Struct(preview); err != nil {
+		return nil, errors.ErrInvalidNote
+	}
+
+	noteType, err := s.GetNoteType(ctx, id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return generateCards(noteType, models.Note{NoteTypeID: id, Fields: preview.Fields})
+}
+
+// CreateNote stores a new note in a deck and generates its cards.
+// The note type must be one the user can use in the deck, see deckNoteType.
+// Returns the note with its cards or an error if the operation fails.
+func (s *NoteService) CreateNote(
+	ctx context.Context,
+	deckID, userID string,
+	create models.CreateNote,
+) (models.NoteWithCards, error) {
+	if err := s.validate.Struct(create); err != nil {
+		return models.NoteWithCards{}, errors.ErrInvalidNote
+	}
+
+	noteType, err := s.deckNoteType(ctx, deckID, create.NoteTypeID, userID)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	note := models.Note{
+		ID:         s.repo.NewNoteID(deckID),
+		NoteTypeID: create.NoteTypeID,
+		Fields:     create.Fields,
+		Tags:       normalizeTags(create.Tags),
+	}
+
+	return s.saveNote(ctx, deckID, noteType, note)
+}
+
+// GetNote retrieves a note in a deck along with its cards.
+// Returns the note or an error if the operation fails.
+func (s *NoteService) GetNote(
+	ctx context.Context,
+	deckID, noteID string,
+) (models.NoteWithCards, error) {
+	note, err := s.repo.GetNote(ctx, deckID, noteID)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	docs, err := s.repo.GetNoteCards(ctx, deckID, noteID)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	cards := make([]models.NoteCard, 0, len(docs))
+	for _, doc := range docs {
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return models.NoteWithCards{}, err
+		}
+
+		var card models.NoteCard
+		if err := json.Unmarshal(raw, &card); err != nil {
+			return models.NoteWithCards{}, err
+		}
+		cards = append(cards, card)
+	}
+
+	return models.NoteWithCards{Note: note, Cards: cards}, nil
+}
+
+// GetNotesInDeck retrieves the notes in a deck with cursor-based pagination.
+// Returns the notes and whether there are more, or an error if the operation fails.
+func (s *NoteService) GetNotesInDeck(
+	ctx context.Context,
+	deckID, limitStr, cursor string,
+) ([]models.Note, bool, error) {
+	return s.repo.GetNotesInDeck(ctx, deckID, utils.ParseLimit(limitStr), cursor)
+}
+
+// UpdateNote replaces the fields and tags of a note and generates its cards again.
+// Cards keep their progress, as long as their template still exists.
+// The note type is checked as when creating a note, see deckNoteType.
+// Returns the note with its cards or an error if the operation fails.
+func (s *NoteService) UpdateNote(
+	ctx context.Context,
+	deckID, noteID, userID string,
+	update models.UpdateNote,
+) (models.NoteWithCards, error) {
+	if err := s.validate.Struct(update); err != nil {
+		return models.NoteWithCards{}, errors.ErrInvalidNote
+	}
+
+	note, err := s.repo.GetNote(ctx, deckID, noteID)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	noteType, err := s.deckNoteType(ctx, deckID, note.NoteTypeID, userID)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	note.Fields = update.Fields
+	note.Tags = normalizeTags(update.Tags)
+
+	return s.saveNote(ctx, deckID, noteType, note)
+}
+
+// DeleteNote moves a note to the trash of its deck along with the cards generated from it.
+// Returns an error if the note does not exist or the operation fails.
+func (s *NoteService) DeleteNote(
+	ctx context.Context,
+	deckID, noteID string,
+) error {
+	before, err := s.repo.DeleteNote(ctx, deckID, noteID, utils.UserIDFromContext(ctx), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	s.invalidateCardCaches(ctx, deckID)
+	s.audit.recordCardChanges(ctx, deckID, before, nil)
+
+	return nil
+}
+
+// RestoreNote moves a note out of the trash of its deck along with the cards deleted with it.
+// Returns the note, or an error if it is not in the trash or the operation fails.
+func (s *NoteService) RestoreNote(
+	ctx context.Context,
+	deckID, noteID string,
+) (models.Note, error) {
+	note, restored, err := s.repo.RestoreNote(ctx, deckID, noteID)
+	if err != nil {
+		return models.Note{}, err
+	}
+
+	s.invalidateCardCaches(ctx, deckID)
+	events := make([]models.AuditEvent, 0, len(restored))
+	for _, id := range slices.Sorted(maps.Keys(restored)) {
+		events = append(events, auditEvent(utils.AUDIT_CARD_RESTORE, id, nil, restored[id]))
+	}
+	s.audit.Record(ctx, deckID, events...)
+
+	return note, nil
+}
+
+// saveNote generates the cards of a note and stores them along with the note.
+// Returns the note with its cards or an error if the note does not generate any card.
+func (s *NoteService) saveNote(
+	ctx context.Context,
+	deckID string,
+	noteType models.NoteType,
+	note models.Note,
+) (models.NoteWithCards, error) {
+	// Only keep the fields the note type has
+	for name := range note.Fields {
+		if !slices.Contains(noteType.Fields, name) {
+			return models.NoteWithCards{}, errors.ErrInvalidNote
+		}
+	}
+
+	cards, err := generateCards(noteType, note)
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	byID := cardsByID(cards)
+	before, err := s.repo.SaveNote(ctx, deckID, note, byID, utils.UserIDFromContext(ctx), time.Now().UTC())
+	if err != nil {
+		return models.NoteWithCards{}, err
+	}
+
+	s.invalidateCardCaches(ctx, deckID)
+	s.audit.recordCardChanges(ctx, deckID, before, auditedCards(byID))
+
+	return models.NoteWithCards{Note: note, Cards: cards}, nil
+}
+
+// validateNoteType checks a note type and that its templates only use its own fields.
+// Returns an error if the note type is not valid.
+func (s *NoteService) validateNoteType(noteType models.CreateNoteType) error {
+	if err := s.validate.Struct(noteType); err != nil {
+		return errors.ErrInvalidNoteType
+	}
+
+	if slices.Contains(noteType.Fields, utils.FRONT_SIDE_FIELD) {
+		return errors.ErrInvalidNoteType
+	}
+
+	for _, template := range noteType.Templates {
+		for _, field := range utils.TemplateFields(template.Front) {
+			if !slices.Contains(noteType.Fields, field) {
+				return errors.ErrInvalidNoteType
+			}
+		}
+		for _, field := range utils.TemplateFields(template.Back) {
+			if field != utils.FRONT_SIDE_FIELD && !slices.Contains(noteType.Fields, field) {
+				return errors.ErrInvalidNoteType
+			}
+		}
+	}
+
+	return nil
+}
+
+// canEditDeck checks if a user is at least an editor of a deck.
+// Users removed from a deck or who handed it over can no longer change its cards.
+func (s *NoteService) canEditDeck(ctx context.Context, deckID, userID string) bool {
+	deck, err := s.decks.GetOneDeck(ctx, deckID, []string{"owner_id", "memberships"})
+	if err != nil {
+		return false
+	}
+	return utils.RoleAllows(deckRole(deck, userID), utils.ROLE_EDITOR)
+}
+
+// invalidateCardCaches removes every cached card and card list of a deck.
+func (s *NoteService) invalidateCardCaches(ctx context.Context, deckID string) {
+	s.cache.DeletePattern(ctx, utils.DeckKey(deckID)+":card*")
+}
+
+// generateCards renders every card template of the note type with the fields of the note.
+// Templates rendering an empty front do not generate a card.
+// Returns the cards in template order, or an error if no card is generated.
+func generateCards(noteType models.NoteType, note models.Note) ([]models.NoteCard, error) {
+	fields := make(map[string]string, len(noteType.Fields)+1)
+	for _, name := range noteType.Fields {
+		fields[name] = note.Fields[name]
+	}
+
+	var cards []models.NoteCard
+	for _, template := range noteType.Templates {
+		front := utils.RenderTemplate(template.Front, fields)
+		fields[utils.FRONT_SIDE_FIELD] = front
+		back := utils.RenderTemplate(template.Back, fields)
+		delete(fields, utils.FRONT_SIDE_FIELD)
+
+		card := models.NoteCard{
+			ID:       utils.NoteCardID(note.ID, template.Name),
+			Type:     utils.NOTE_CARD,
+			NoteID:   note.ID,
+			Template: template.Name,
+			Front:    front,
+			Back:     back,
+			CardMeta: models.CardMeta{Tags: note.Tags, Format: noteType.Format},
+		}
+
+		if err := prepareContent(&card, noteType.Format); err != nil {
+			return nil, errors.ErrInvalidNote
+		}
+
+		// Checked after sanitizing, as removing HTML can leave the front empty
+		if strings.TrimSpace(card.Front) == "" {
+			continue
+		}
+
+		cards = append(cards, card)
+	}
+
+	if len(cards) == 0 {
+		return nil, errors.ErrInvalidNote
+	}
+
+	return cards, nil
+}
+
+// cardsByID maps the generated cards of a note by their ID.
+func cardsByID(cards []models.NoteCard) map[string]models.NoteCard {
+	result := make(map[string]models.NoteCard, len(cards))
+	for _, card := range cards {
+		result[card.ID] = card
+	}
+	return result
+}
+
+// auditedCards converts the generated cards of a note to the cards after a change,
+// as recorded in the audit log.
+func auditedCards(cards map[string]models.NoteCard) map[string]any {
+	result := make(map[string]any, len(cards))
+	for id, card := range cards {
+		// The ID is not a field of the stored card
+		card.ID = ""
+		result[id] = card
+	}
+	return result
+}
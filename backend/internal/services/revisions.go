@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+)
+
+// Name of the card as it is now, when diffing revisions
+const currentRevision = "current"
+
+// GetCardRevisions lists the revisions of a card in a deck, the latest first.
+// Returns the revisions or an error if the card does not exist or the operation fails.
+func (s *CardService) GetCardRevisions(
+	ctx context.Context,
+	deckID, cardID string,
+) ([]models.CardRevision, error) {
+	// Cards without revisions are told apart from cards that do not exist
+	if _, err := s.repo.GetCardInDeck(ctx, deckID, cardID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetCardRevisions(ctx, deckID, cardID)
+}
+
+// DiffCardRevisions compares the fields of two revisions of a card in a deck.
+// The card as it is now is compared when to is empty or "current".
+// Returns the fields that differ, or an error if a revision does not exist or the operation fails.
+func (s *CardService) DiffCardRevisions(
+	ctx context.Context,
+	deckID, cardID, from, to string,
+) (models.RevisionDiff, error) {
+	before, err := s.revisionFields(ctx, deckID, cardID, from)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+
+	if to == "" {
+		to = currentRevision
+	}
+	after, err := s.revisionFields(ctx, deckID, cardID, to)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+
+	changes, err := models.DiffFields(before, after)
+	if err != nil {
+		return models.RevisionDiff{}, err
+	}
+
+	return models.RevisionDiff{From: from, To: to, Changes: changes}, nil
+}
+
+// RestoreCardRevision updates a card in a deck back to the fields of one of its revisions.
+// The restore goes through the checks of any update, and is kept as a new revision.
+// Returns an error if the revision does not exist, its fields are no longer valid
+// or the operation fails.
+func (s *CardService) RestoreCardRevision(
+	ctx context.Context,
+	deckID, cardID, revisionID string,
+) error {
+	revision, err := s.repo.GetCardRevision(ctx, deckID, cardID, revisionID)
+	if err != nil {
+		return err
+	}
+
+	rawData, err := json.Marshal(revision.Fields)
+	if err != nil {
+		return err
+	}
+
+	return s.updateCard(ctx, rawData, deckID, cardID, revisionID)
+}
+
+// newRevision starts a revision of cards changed by the user of the context.
+// restoredFrom is the revision the change restores, empty for edits.
+func newRevision(ctx context.Context, restoredFrom string) models.CardRevision {
+	return models.CardRevision{
+		AuthorID:     utils.UserIDFromContext(ctx),
+		CreatedAt:    time.Now().UTC(),
+		RestoredFrom: restoredFrom,
+	}
+}
+
+// revisionFields fetches the fields of a revision of a card, or of the card as it is now.
+// Returns the fields or an error if the revision does not exist or the operation fails.
+func (s *CardService) revisionFields(
+	ctx context.Context,
+	deckID, cardID, revisionID string,
+) (map[string]any, error) {
+	if revisionID == currentRevision {
+		return s.repo.GetCardInDeck(ctx, deckID, cardID)
+	}
+
+	revision, err := s.repo.GetCardRevision(ctx, deckID, cardID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision.Fields, nil
+}
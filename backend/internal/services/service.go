@@ -8,21 +8,28 @@ import (
 )
 
 type ServiceDeps struct {
-	UserRepo firebase.UserRepository
-	CardRepo firebase.CardRepository
-	DeckRepo firebase.DeckRepository
-	AuthRepo firebase.FirebaseAuth
-	Redis    *redis.Client
-	Cache    *CacheService
-	Validate *validator.Validate
+	UserRepo   firebase.UserRepository
+	CardRepo   firebase.CardRepository
+	DeckRepo   firebase.DeckRepository
+	NoteRepo   firebase.NoteRepository
+	InviteRepo firebase.InviteRepository
+	LinkRepo   firebase.ShareLinkRepository
+	ClassRepo  firebase.ClassRepository
+	AuditRepo  firebase.AuditRepository
+	AuthRepo   firebase.FirebaseAuth
+	Redis      *redis.Client
+	Cache      *CacheService
+	Validate   *validator.Validate
 }
 
 // Services groups all service instances.
 type Services struct {
-	Users *UserService
-	Decks *DeckService
-	Auth  *AuthService
-	Rdb   *redis.Client
+	Users   *UserService
+	Decks   *DeckService
+	Notes   *NoteService
+	Classes *ClassService
+	Auth    *AuthService
+	Rdb     *redis.Client
 }
 
 // NewServices creates a new Services struct with the provided repositories and validator.
@@ -32,19 +39,26 @@ func NewServices(
 	rdb *redis.Client,
 ) *Services {
 	deps := &ServiceDeps{
-		UserRepo: repos.User,
-		CardRepo: repos.Card,
-		DeckRepo: repos.Deck,
-		AuthRepo: repos.Auth,
-		Redis:    rdb,
-		Cache:    NewCacheService(rdb),
-		Validate: validate,
+		UserRepo:   repos.User,
+		CardRepo:   repos.Card,
+		DeckRepo:   repos.Deck,
+		NoteRepo:   repos.Note,
+		InviteRepo: repos.Invite,
+		LinkRepo:   repos.Link,
+		ClassRepo:  repos.Class,
+		AuditRepo:  repos.Audit,
+		AuthRepo:   repos.Auth,
+		Redis:      rdb,
+		Cache:      NewCacheService(rdb),
+		Validate:   validate,
 	}
 
 	return &Services{
-		Users: NewUserService(deps),
-		Decks: NewDeckService(deps),
-		Auth:  NewAuthService(deps),
-		Rdb:   rdb,
+		Users:   NewUserService(deps),
+		Decks:   NewDeckService(deps),
+		Notes:   NewNoteService(deps),
+		Classes: NewClassService(deps),
+		Auth:    NewAuthService(deps),
+		Rdb:     rdb,
 	}
 }
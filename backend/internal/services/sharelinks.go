@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+)
+
+// Random bytes of a share link token
+const shareTokenSize = 32
+
+// CreateShareLink creates a link making anyone holding its token a member of a deck.
+// Returns the link with its token, which is not stored and can not be shown again,
+// or an error if the options are not valid or the operation fails.
+func (s *DeckService) CreateShareLink(
+	ctx context.Context,
+	deckID, userID string,
+	options models.CreateShareLink,
+) (models.CreatedShareLink, error) {
+	if err := s.validate.Struct(options); err != nil {
+		return models.CreatedShareLink{}, errors.ErrInvalidShareLink
+	}
+
+	now := time.Now().UTC()
+	if options.ExpiresAt != nil && !options.ExpiresAt.After(now) {
+		return models.CreatedShareLink{}, errors.ErrInvalidShareLink
+	}
+
+	secret := make([]byte, shareTokenSize)
+	if _, err := rand.Read(secret); err != nil {
+		return models.CreatedShareLink{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	link := models.ShareLink{
+		DeckID:    deckID,
+		Role:      options.Role,
+		CreatedBy: userID,
+		CreatedAt: now,
+		ExpiresAt: options.ExpiresAt,
+		MaxUses:   options.MaxUses,
+	}
+	id := shareLinkID(token)
+	if err := s.links.CreateShareLink(ctx, id, link); err != nil {
+		return models.CreatedShareLink{}, err
+	}
+	link.ID = id
+
+	s.audit.Record(ctx, deckID, auditEvent(
+		utils.AUDIT_DECK_SHARE, "",
+		nil, map[string]any{"share_links": map[string]models.ShareLink{id: link}},
+	))
+
+	return models.CreatedShareLink{ShareLink: link, Token: token}, nil
+}
+
+// GetShareLinks lists the share links of a deck, without their tokens.
+// Returns the links or an error if the operation fails.
+func (s *DeckService) GetShareLinks(
+	ctx context.Context,
+	deckID string,
+) ([]models.ShareLink, error) {
+	return s.links.GetDeckShareLinks(ctx, deckID)
+}
+
+// RevokeShareLink deletes a share link of a deck, users who joined through it stay members.
+// Returns an error if the link is not one of the deck or the operation fails.
+func (s *DeckService) RevokeShareLink(
+	ctx context.Context,
+	deckID, linkID string,
+) error {
+	link, err := s.links.GetShareLink(ctx, linkID)
+	if err != nil {
+		return err
+	}
+	if link.DeckID != deckID {
+		return errors.ErrNotFound
+	}
+
+	if err := s.links.DeleteShareLink(ctx, linkID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, deckID, auditEvent(
+		utils.AUDIT_DECK_SHARE, "",
+		map[string]any{"share_links": map[string]models.ShareLink{linkID: link}}, nil,
+	))
+
+	return nil
+}
+
+// RedeemShareLink makes a user a member of the deck of a share link with the role of the link.
+// Returns the deck and the role of the user on it, or an error if the token is not valid,
+// the link expired or is used up, or the operation fails.
+func (s *DeckService) RedeemShareLink(
+	ctx context.Context,
+	token, userID, userEmail string,
+) (models.ShareLinkRedemption, error) {
+	// The deck of the link is read first to record the member joining it
+	id := shareLinkID(token)
+	link, err := s.links.GetShareLink(ctx, id)
+	if err != nil {
+		return models.ShareLinkRedemption{}, err
+	}
+	before, err := s.auditedDeck(ctx, link.DeckID)
+	if err != nil {
+		return models.ShareLinkRedemption{}, err
+	}
+
+	redemption, err := s.links.RedeemShareLink(ctx, id, userID, userEmail, time.Now().UTC())
+	if err != nil {
+		return models.ShareLinkRedemption{}, err
+	}
+
+	s.cache.Delete(ctx, utils.DeckKey(redemption.DeckID), utils.UserDecksKey(userID))
+	s.cache.DeletePattern(ctx, utils.DeckRolesKey(redemption.DeckID)+"*")
+	s.recordDeckChange(ctx, redemption.DeckID, utils.AUDIT_DECK_SHARE, &before)
+
+	return redemption, nil
+}
+
+// shareLinkID hashes a share link token into the ID of the link,
+// so the stored links can not be used to join a deck.
+func shareLinkID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
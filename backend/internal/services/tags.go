@@ -0,0 +1,121 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"strings"
+)
+
+// UpdateTags adds, removes or renames tags on the cards of a deck.
+// Add and remove apply to the given cards, or every card in the deck if none are given.
+// Returns the number of cards updated or an error if the operation fails.
+func (s *CardService) UpdateTags(
+	ctx context.Context,
+	deckID string,
+	update models.UpdateTags,
+) (int, error) {
+	update.Tags = normalizeTags(update.Tags)
+	update.From = strings.TrimSpace(update.From)
+	update.To = strings.TrimSpace(update.To)
+
+	if err := s.validate.Struct(update); err != nil {
+		return 0, errors.ErrInvalidCard
+	}
+
+	var updated int
+	var err error
+	revision := newRevision(ctx, "")
+
+	// Perform the appropriate operation based on the Opp field
+	switch update.Opp {
+	case utils.OPP_ADD:
+		updated, err = s.repo.AddTagsToCards(ctx, deckID, update.CardIDs, update.Tags, revision)
+	case utils.OPP_REMOVE:
+		updated, err = s.repo.RemoveTagsFromCards(ctx, deckID, update.CardIDs, update.Tags, revision)
+	case utils.OPP_RENAME:
+		updated, err = s.repo.RenameTag(ctx, deckID, update.From, update.To, revision)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Both single cards and card lists may contain the changed tags
+	s.cache.DeletePattern(ctx, utils.DeckKey(deckID)+":card*")
+	s.recordTagChanges(ctx, deckID, update)
+
+	return updated, nil
+}
+
+// recordTagChanges records a change of tags in the audit log of a deck, once per card
+// it was applied to. Changes of every card in the deck are recorded once for the deck,
+// as the cards they changed are not known.
+func (s *CardService) recordTagChanges(
+	ctx context.Context,
+	deckID string,
+	update models.UpdateTags,
+) {
+	var before, after any
+	switch update.Opp {
+	case utils.OPP_ADD:
+		after = map[string]any{"tags": update.Tags}
+	case utils.OPP_REMOVE:
+		before = map[string]any{"tags": update.Tags}
+	case utils.OPP_RENAME:
+		before = map[string]any{"tags": []string{update.From}}
+		after = map[string]any{"tags": []string{update.To}}
+	}
+
+	if update.Opp == utils.OPP_RENAME || len(update.CardIDs) == 0 {
+		s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_CARD_UPDATE, "", before, after))
+		return
+	}
+
+	events := make([]models.AuditEvent, len(update.CardIDs))
+	for i, id := range update.CardIDs {
+		events[i] = auditEvent(utils.AUDIT_CARD_UPDATE, id, before, after)
+	}
+	s.audit.Record(ctx, deckID, events...)
+}
+
+// GetTags lists the tags used in a deck, most used first.
+// Returns the tags with their card count or an error if the operation fails.
+func (s *CardService) GetTags(
+	ctx context.Context,
+	deckID string,
+) ([]models.TagCount, error) {
+	counts, err := s.repo.GetTagCounts(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, models.TagCount{Tag: tag, Count: count})
+	}
+
+	slices.SortFunc(tags, func(a, b models.TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Tag, b.Tag))
+	})
+
+	return tags, nil
+}
+
+// normalizeTags trims whitespace from the tags and removes empty and duplicate tags.
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
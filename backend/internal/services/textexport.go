@@ -0,0 +1,242 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Shown in place of a blank in plain text exports
+const textBlank = "____"
+
+// Indents the lines following the first one of a plain text answer, to line up after "Q: "
+const textIndent = "   "
+
+// WriteDeckExport writes a deck to w as Markdown or plain text questions and answers.
+// Cards are read and written one page at a time, so large decks are never held in memory.
+// Returns an error if the deck can not be read, before anything is written when possible.
+func (s *DeckService) WriteDeckExport(
+	ctx context.Context,
+	deckID, format string,
+	w io.Writer,
+) error {
+	var writeCard func(w io.Writer, card models.Card) error
+	switch format {
+	case utils.EXPORT_FORMAT_MARKDOWN:
+		writeCard = writeMarkdownCard
+	case utils.EXPORT_FORMAT_TEXT:
+		writeCard = writeTextCard
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"title"})
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	if format == utils.EXPORT_FORMAT_MARKDOWN {
+		fmt.Fprintf(out, "# %s\n", utils.EscapeMarkdown(deck.Title))
+	} else {
+		fmt.Fprintf(out, "%s\n", deck.Title)
+	}
+
+	err = s.forEachCard(ctx, deckID, func(_ string, card models.Card, _ json.RawMessage) error {
+		if format == utils.EXPORT_FORMAT_MARKDOWN {
+			fmt.Fprint(out, "\n---\n")
+		}
+		fmt.Fprint(out, "\n")
+		return writeCard(out, card)
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
+
+// writeMarkdownCard writes a card as a Markdown question and answer block.
+// Multiple choice options are checkboxes, ordered cards numbered lists and blanks are bold.
+func writeMarkdownCard(w io.Writer, card models.Card) error {
+	// Plain text is escaped, so it is not read as Markdown
+	text := func(s string) string {
+		if card.Meta().Format == utils.FORMAT_MARKDOWN {
+			return s
+		}
+		return utils.EscapeMarkdown(s)
+	}
+
+	var b strings.Builder
+	question := func(s string) { fmt.Fprintf(&b, "**Q:** %s\n", text(s)) }
+	answer := func(s string) { fmt.Fprintf(&b, "\n**A:** %s\n", s) }
+
+	switch c := card.(type) {
+	case *models.FrontBackCard:
+		question(c.Front)
+		answer(text(c.Back))
+	case *models.NoteCard:
+		question(c.Front)
+		answer(text(c.Back))
+	case *models.MultipleChoiceCard:
+		question(c.Question)
+		b.WriteString("\n")
+		for _, option := range slices.Sorted(maps.Keys(c.Options)) {
+			check := " "
+			if c.Options[option] {
+				check = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", check, text(option))
+		}
+	case *models.OrderedCard:
+		question(c.Question)
+		b.WriteString("\n")
+		for i, option := range c.Options {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, text(option))
+		}
+	case *models.BlanksCard:
+		filled := text(c.Question)
+		for _, answer := range c.Answers {
+			filled = strings.Replace(filled, "{}", "**"+text(answer)+"**", 1)
+		}
+		fmt.Fprintf(&b, "**Q:** %s\n", filled)
+	case *models.NumericCard:
+		question(c.Question)
+		answer(utils.EscapeMarkdown(numericAnswer(c)))
+	case *models.MatchingCard:
+		question(c.Question)
+		b.WriteString("\n")
+		for _, pair := range c.Pairs {
+			fmt.Fprintf(&b, "- %s → %s\n", text(pair.Left), text(pair.Right))
+		}
+	case *models.CodeCard:
+		question(c.Question)
+		fence := codeFence(c.Code)
+		fmt.Fprintf(&b, "\n%s%s\n%s\n%s\n", fence, c.Language, strings.TrimRight(c.Code, "\n"), fence)
+		if c.Explanation != "" {
+			answer(text(c.Explanation))
+		}
+	default:
+		return fmt.Errorf("no markdown export for card type %q", card.GetType())
+	}
+
+	if tags := card.Meta().Tags; len(tags) > 0 {
+		fmt.Fprintf(&b, "\n*Tags: %s*\n", utils.EscapeMarkdown(strings.Join(tags, ", ")))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTextCard writes a card as plain "Q:" and "A:" lines.
+func writeTextCard(w io.Writer, card models.Card) error {
+	var q, a []string
+
+	switch c := card.(type) {
+	case *models.FrontBackCard:
+		q, a = []string{c.Front}, []string{c.Back}
+	case *models.NoteCard:
+		q, a = []string{c.Front}, []string{c.Back}
+	case *models.MultipleChoiceCard:
+		q = []string{c.Question}
+		for _, option := range slices.Sorted(maps.Keys(c.Options)) {
+			q = append(q, "- "+option)
+			if c.Options[option] {
+				a = append(a, option)
+			}
+		}
+	case *models.OrderedCard:
+		q = []string{c.Question}
+		for i, option := range c.Options {
+			a = append(a, strconv.Itoa(i+1)+". "+option)
+		}
+	case *models.BlanksCard:
+		q = []string{strings.ReplaceAll(c.Question, "{}", textBlank)}
+		a = []string{strings.Join(c.Answers, ", ")}
+	case *models.NumericCard:
+		q, a = []string{c.Question}, []string{numericAnswer(c)}
+	case *models.MatchingCard:
+		q = []string{c.Question}
+		for _, pair := range c.Pairs {
+			a = append(a, pair.Left+" = "+pair.Right)
+		}
+	case *models.CodeCard:
+		q = []string{c.Question, ""}
+		q = append(q, strings.Split(strings.TrimRight(c.Code, "\n"), "\n")...)
+		if c.Explanation != "" {
+			a = []string{c.Explanation}
+		}
+	default:
+		return fmt.Errorf("no text export for card type %q", card.GetType())
+	}
+
+	var b strings.Builder
+	writeTextLines(&b, "Q: ", q)
+	if len(a) > 0 {
+		writeTextLines(&b, "A: ", a)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTextLines writes the lines after a label, indenting every line after the first.
+// Lines holding several lines of their own are split, so they are indented as well.
+func writeTextLines(b *strings.Builder, label string, values []string) {
+	var lines []string
+	for _, value := range values {
+		lines = append(lines, strings.Split(value, "\n")...)
+	}
+
+	for i, line := range lines {
+		if i == 0 {
+			b.WriteString(label)
+		} else if line != "" {
+			b.WriteString(textIndent)
+		}
+		b.WriteString(line + "\n")
+	}
+}
+
+// numericAnswer formats the answer of a numeric card with its unit and tolerance.
+func numericAnswer(c *models.NumericCard) string {
+	answer := ""
+	if c.Answer != nil {
+		answer = strconv.FormatFloat(*c.Answer, 'g', -1, 64)
+	}
+	if c.Unit != "" {
+		answer += " " + c.Unit
+	}
+
+	switch {
+	case c.Tolerance == 0:
+	case c.ToleranceMode == utils.TOLERANCE_RELATIVE:
+		answer += fmt.Sprintf(" (± %s%%)", strconv.FormatFloat(c.Tolerance*100, 'g', -1, 64))
+	default:
+		answer += fmt.Sprintf(" (± %s)", strconv.FormatFloat(c.Tolerance, 'g', -1, 64))
+	}
+
+	return answer
+}
+
+// codeFence returns a fence longer than any run of backticks in the code.
+func codeFence(code string) string {
+	longest, run := 0, 0
+	for _, r := range code {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// RequestTransfer asks a member of a deck, found by their ID or email, to take it over.
+// A pending transfer to another member is replaced.
+// Returns the transfer, or an error if the user is not a member or the operation fails.
+func (s *DeckService) RequestTransfer(
+	ctx context.Context,
+	deckID string,
+	request models.RequestTransfer,
+) (models.OwnershipTransfer, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return models.OwnershipTransfer{}, errors.ErrInvalidDeck
+	}
+
+	memberID, deck, err := s.findMember(ctx, deckID, request.Member)
+	if err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+	membership := deck.Memberships[memberID]
+
+	now := time.Now().UTC()
+	if err := s.repo.RequestTransfer(ctx, deckID, memberID, now); err != nil {
+		return models.OwnershipTransfer{}, err
+	}
+
+	s.clearDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_TRANSFER, &deck)
+
+	return models.OwnershipTransfer{
+		ToID:        memberID,
+		ToEmail:     membership.Email,
+		RequestedAt: now,
+	}, nil
+}
+
+// CancelTransfer withdraws the pending transfer of a deck.
+// The owner can cancel it, and the member it was offered to can decline it.
+// Returns an error if no transfer is pending, the user can not cancel it
+// or the operation fails.
+func (s *DeckService) CancelTransfer(
+	ctx context.Context,
+	deckID, userID string,
+) error {
+	deck, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		return err
+	}
+	if deck.Transfer == nil {
+		return errors.ErrNoTransfer
+	}
+	if userID != deck.OwnerID && userID != deck.Transfer.ToID {
+		return errors.ErrUnauthorized
+	}
+
+	err = s.repo.UpdateDeck(ctx, []firestore.Update{
+		{Path: "transfer", Value: firestore.Delete},
+	}, deckID)
+	if err != nil {
+		return err
+	}
+
+	s.clearDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_TRANSFER, &deck)
+
+	return nil
+}
+
+// AcceptTransfer makes the user a deck was offered to its owner.
+// The former owner stays on the deck as a co-owner.
+// Returns an error if no transfer to the user is pending or the operation fails.
+func (s *DeckService) AcceptTransfer(
+	ctx context.Context,
+	deckID, userID string,
+) error {
+	before, err := s.auditedDeck(ctx, deckID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AcceptTransfer(ctx, deckID, userID); err != nil {
+		return err
+	}
+
+	// Read after the transfer, so the deck lists of both the new and former owner are cleared
+	s.invalidateDeckCaches(ctx, deckID)
+	s.recordDeckChange(ctx, deckID, utils.AUDIT_DECK_TRANSFER, &before)
+
+	return nil
+}
+
+// GetTransferOffers lists the decks a user was asked to take over.
+// Returns the offers or an error if the operation fails.
+func (s *UserService) GetTransferOffers(
+	ctx context.Context,
+	userID string,
+) ([]models.TransferOffer, error) {
+	return s.decks.GetTransferOffers(ctx, userID)
+}
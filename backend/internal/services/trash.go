@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"memora/internal/config"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"time"
+)
+
+// GetDeletedDecks lists the decks in the trash of a user, the latest deleted first,
+// with when each will be purged.
+// Returns the deleted decks or an error if the operation fails.
+func (s *DeckService) GetDeletedDecks(
+	ctx context.Context,
+	userID string,
+) ([]models.DeletedDeck, error) {
+	decks, err := s.repo.GetDeletedDecks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range decks {
+		decks[i].PurgeAt = decks[i].DeletedAt.Add(config.TrashRetention)
+	}
+
+	return decks, nil
+}
+
+// RestoreDeck moves a deck out of the trash of a user, with its members and cards.
+// Returns the restored deck, or an error if it is not in the trash of the user
+// or the operation fails.
+func (s *DeckService) RestoreDeck(
+	ctx context.Context,
+	deckID, userID string,
+) (models.Deck, error) {
+	deck, err := s.repo.RestoreDeck(ctx, deckID, userID)
+	if err != nil {
+		return models.Deck{}, err
+	}
+
+	// Roles cached while the deck was gone are cleared along with the deck lists
+	s.invalidateUserDecks(ctx, deckUsers(deck))
+	s.clearDeckCaches(ctx, deckID)
+	s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_DECK_RESTORE, "", nil, deck))
+
+	return s.GetOneDeck(ctx, deckID, defaultFilterDecks)
+}
+
+// GetDeletedCards lists the cards in the trash of a deck, the latest deleted first,
+// with when each will be purged.
+// Returns the deleted cards or an error if the operation fails.
+func (s *CardService) GetDeletedCards(
+	ctx context.Context,
+	deckID string,
+) ([]models.DeletedCard, error) {
+	cards, err := s.repo.GetDeletedCards(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cards {
+		cards[i].PurgeAt = cards[i].DeletedAt.Add(config.TrashRetention)
+	}
+
+	return cards, nil
+}
+
+// RestoreCard moves a card out of the trash of its deck, with its revisions.
+// Returns the restored card, or an error if it is not in the trash or the operation fails.
+func (s *CardService) RestoreCard(
+	ctx context.Context,
+	deckID, cardID string,
+) (map[string]any, error) {
+	card, err := s.repo.RestoreCard(ctx, deckID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, utils.DeckCardKey(deckID, cardID))
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+	s.audit.Record(ctx, deckID, auditEvent(utils.AUDIT_CARD_RESTORE, cardID, nil, card))
+
+	return card, nil
+}
+
+// clearCardCaches clears the cached cards and card lists of a deck.
+func (s *CardService) clearCardCaches(ctx context.Context, deckID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), CacheOpTimeout)
+	defer cancel()
+
+	s.cache.DeletePattern(ctx, utils.DeckKey(deckID)+":"+utils.CardKeyPrefix+":*")
+	s.cache.DeletePattern(ctx, utils.DeckCardsKey(deckID)+"*")
+}
+
+// PurgeTrash permanently deletes the decks and cards deleted longer ago
+// than the retention period.
+// Returns how many were purged, or an error if the operation fails.
+func (s *DeckService) PurgeTrash(
+	ctx context.Context,
+	now time.Time,
+) (models.TrashPurge, error) {
+	before := now.Add(-config.TrashRetention)
+
+	decks, err := s.repo.PurgeDeletedDecks(ctx, before)
+	if err != nil {
+		return models.TrashPurge{}, err
+	}
+
+	cards, err := s.Cards.repo.PurgeDeletedCards(ctx, before)
+	if err != nil {
+		return models.TrashPurge{Decks: decks}, err
+	}
+
+	return models.TrashPurge{Decks: decks, Cards: cards}, nil
+}
+
+// RunTrashPurge purges the trash every interval until the context is done.
+// Failed purges are only logged, and retried on the next run.
+func (s *DeckService) RunTrashPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeTrash(ctx, time.Now().UTC())
+		if err != nil {
+			slog.Error("failed to purge trash", "error", err)
+		} else if purged.Decks > 0 || purged.Cards > 0 {
+			slog.Info("purged trash", "decks", purged.Decks, "cards", purged.Cards)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
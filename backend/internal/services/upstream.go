@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"memora/internal/errors"
+	"memora/internal/models"
+	"memora/internal/utils"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// pendingChange is a change of the source deck along with what is needed to pull it.
+type pendingChange struct {
+	models.UpstreamChange
+	// pulled is the source card prepared for the copy, nil when it was removed
+	pulled models.Card
+	// localTags are kept when the local card is replaced
+	localTags []string
+}
+
+// linkedCard is a card of a linked copy with its source card.
+type linkedCard struct {
+	id     string
+	tags   []string
+	hash   string
+	edited bool
+}
+
+// SubscribeToDeck creates a linked copy of a deck owned by the user. The copy remembers
+// its source deck and the source of every card, so later changes can be pulled into it.
+// Returns a summary of the copy, or an error if the operation fails.
+func (s *DeckService) SubscribeToDeck(
+	ctx context.Context,
+	deckID, userID string,
+	options models.ForkDeck,
+) (models.ImportSummary, error) {
+	return s.copyDeck(ctx, deckID, userID, options, true)
+}
+
+// GetUpstreamChanges lists the cards added, modified and removed in the source deck
+// of a linked copy since they were last synced. Changes to cards also edited in the copy
+// are flagged as conflicts.
+// Returns the changes, or an error if the deck is not a linked copy or the user can no
+// longer access its source deck.
+func (s *DeckService) GetUpstreamChanges(
+	ctx context.Context,
+	deckID, userID string,
+) (models.UpstreamChanges, error) {
+	upstream, pending, err := s.pendingChanges(ctx, deckID, userID)
+	if err != nil {
+		return models.UpstreamChanges{}, err
+	}
+
+	changes := models.UpstreamChanges{
+		DeckID:   upstream.DeckID,
+		SyncedAt: upstream.SyncedAt,
+		Changes:  make([]models.UpstreamChange, 0, len(pending)),
+	}
+	for _, change := range pending {
+		changes.Changes = append(changes.Changes, change.UpstreamChange)
+	}
+
+	return changes, nil
+}
+
+// PullUpstreamChanges applies the selected changes of the source deck to a linked copy.
+// Conflicting changes are only pulled when asked to overwrite the local edits,
+// and the tags of the local cards are always kept. Cards without a pending change are ignored.
+// Returns what was pulled, or an error if the deck is not a linked copy or the operation fails.
+func (s *DeckService) PullUpstreamChanges(
+	ctx context.Context,
+	deckID, userID string,
+	pull models.UpstreamPull,
+) (models.UpstreamPullResult, error) {
+	if err := s.validate.Struct(pull); err != nil {
+		return models.UpstreamPullResult{}, errors.ErrInvalidId
+	}
+
+	_, pending, err := s.pendingChanges(ctx, deckID, userID)
+	if err != nil {
+		return models.UpstreamPullResult{}, err
+	}
+
+	result := models.UpstreamPullResult{Conflicts: []string{}}
+	var added []models.Card
+	replaced := make(map[string]models.Card)
+	var removed []string
+
+	for _, change := range pending {
+		if !slices.Contains(pull.CardIDs, change.CardID) {
+			continue
+		}
+		if change.Conflict && !pull.Overwrite {
+			result.Conflicts = append(result.Conflicts, change.CardID)
+			continue
+		}
+
+		switch change.Status {
+		case utils.CHANGE_ADDED:
+			added = append(added, change.pulled)
+		case utils.CHANGE_MODIFIED:
+			change.pulled.Meta().Tags = change.localTags
+			replaced[change.LocalCardID] = change.pulled
+		case utils.CHANGE_REMOVED:
+			removed = append(removed, change.LocalCardID)
+		}
+	}
+
+	ids, err := s.Cards.CreateCards(ctx, deckID, added)
+	if err != nil {
+		return models.UpstreamPullResult{}, err
+	}
+	result.Added = len(ids)
+
+	if err := s.Cards.ReplaceCards(ctx, deckID, replaced); err != nil {
+		return models.UpstreamPullResult{}, err
+	}
+	result.Updated = len(replaced)
+
+	if err := s.Cards.DeleteCards(ctx, deckID, removed); err != nil {
+		return models.UpstreamPullResult{}, err
+	}
+	result.Removed = len(removed)
+
+	update := []firestore.Update{{Path: "upstream.synced_at", Value: time.Now().UTC()}}
+	if err := s.repo.UpdateDeck(ctx, update, deckID); err != nil {
+		return models.UpstreamPullResult{}, err
+	}
+	s.cache.Delete(ctx, utils.DeckKey(deckID))
+
+	return result, nil
+}
+
+// pendingChanges compares a linked copy with its source deck.
+// Returns the source of the copy and its changes, in the order of the source deck
+// followed by the removed cards, or an error if they can not be compared.
+func (s *DeckService) pendingChanges(
+	ctx context.Context,
+	deckID, userID string,
+) (models.DeckUpstream, []pendingChange, error) {
+	deck, err := s.repo.GetOneDeck(ctx, deckID, []string{"upstream"})
+	if err != nil {
+		return models.DeckUpstream{}, nil, err
+	}
+	if deck.Upstream == nil {
+		return models.DeckUpstream{}, nil, errors.ErrNotLinked
+	}
+	upstream := *deck.Upstream
+
+	// Changes are only visible while the source deck can be accessed
+	role, err := s.GetDeckRole(ctx, upstream.DeckID, userID)
+	if err != nil || role == "" {
+		return models.DeckUpstream{}, nil, errors.ErrUnauthorized
+	}
+
+	local := make(map[string]linkedCard)
+	var order []string
+	err = s.forEachCard(ctx, deckID, func(id string, card models.Card, _ json.RawMessage) error {
+		link := card.Meta().Upstream
+		// Cards created in the copy have no source
+		if link == nil {
+			return nil
+		}
+
+		hash, err := syncHash(card)
+		if err != nil {
+			return err
+		}
+		local[link.CardID] = linkedCard{
+			id:     id,
+			tags:   card.Meta().Tags,
+			hash:   link.Hash,
+			edited: hash != link.Hash,
+		}
+		order = append(order, link.CardID)
+		return nil
+	})
+	if err != nil {
+		return models.DeckUpstream{}, nil, err
+	}
+
+	var changes []pendingChange
+	seen := make(map[string]bool)
+	err = s.forEachCard(ctx, upstream.DeckID, func(id string, card models.Card, _ json.RawMessage) error {
+		seen[id] = true
+
+		card = detachCard(card)
+		hash, err := syncHash(card)
+		if err != nil {
+			return err
+		}
+
+		change := pendingChange{pulled: card}
+		change.CardID = id
+		if linked, ok := local[id]; !ok {
+			change.Status = utils.CHANGE_ADDED
+		} else if linked.hash != hash {
+			change.Status = utils.CHANGE_MODIFIED
+			change.LocalCardID = linked.id
+			change.Conflict = linked.edited
+			change.localTags = linked.tags
+		} else {
+			return nil
+		}
+
+		// Linked before being shown, as the source ID is part of the pulled card
+		card.Meta().Upstream = &models.CardUpstream{CardID: id, Hash: hash}
+		change.Card, err = json.Marshal(card)
+		if err != nil {
+			return err
+		}
+
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return models.DeckUpstream{}, nil, err
+	}
+
+	for _, cardID := range order {
+		if seen[cardID] {
+			continue
+		}
+		linked := local[cardID]
+		change := pendingChange{}
+		change.CardID = cardID
+		change.LocalCardID = linked.id
+		change.Status = utils.CHANGE_REMOVED
+		change.Conflict = linked.edited
+		changes = append(changes, change)
+	}
+
+	return upstream, changes, nil
+}
+
+// syncHash hashes the content of a card as compared between a linked copy and its source.
+// The ID, tags and links of the card are left out, as they belong to each deck.
+func syncHash(card models.Card) (string, error) {
+	raw, err := json.Marshal(card)
+	if err != nil {
+		return "", err
+	}
+
+	var content map[string]any
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return "", err
+	}
+	for _, key := range []string{"id", "tags", "source", "upstream", "review_direction"} {
+		delete(content, key)
+	}
+
+	// Map keys are sorted when marshaled, so equal cards give equal hashes
+	raw, err = json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return utils.ContentHash(raw), nil
+}
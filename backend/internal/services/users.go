@@ -16,6 +16,8 @@ var defaultFilterUsers = "email,name"
 // UserService provides methods for managing users.
 type UserService struct {
 	repo     firebase.UserRepository
+	decks    firebase.DeckRepository
+	invites  firebase.InviteRepository
 	cache    *CacheService
 	validate *validator.Validate
 }
@@ -26,6 +28,8 @@ func NewUserService(
 ) *UserService {
 	return &UserService{
 		repo:     deps.UserRepo,
+		decks:    deps.DeckRepo,
+		invites:  deps.InviteRepo,
 		cache:    deps.Cache,
 		validate: deps.Validate,
 	}
@@ -69,14 +73,14 @@ func (s *UserService) GetUser(
 // Returns a list of decks or an error if the operation fails.
 func (s *UserService) GetDecks(
 	ctx context.Context,
-	id, filter, email string,
+	id, filter string,
 ) (models.UserDecks, error) {
 	filterParsed, err := utils.ParseFilter(filter)
 	if err != nil {
 		return models.UserDecks{}, err
 	}
 
-	cacheKey := utils.UserEmailDecksKey(email)
+	cacheKey := utils.UserDecksKey(id)
 	var decks models.UserDecks
 	err = s.cache.Get(ctx, cacheKey, &decks)
 	if err == nil {
@@ -94,31 +98,57 @@ func (s *UserService) GetDecks(
 }
 
 // RegisterNewUser creates a new user from the provided data.
+// The email is stored lowercase, and its pending invites become memberships
+// when the account has verified it. They stay pending otherwise.
 // Returns the new user's ID or an error if the operation fails.
 func (s *UserService) RegisterNewUser(
 	ctx context.Context,
 	user models.CreateUser,
 	id string,
+	emailVerified bool,
 ) error {
 	// Validate the input struct
 	if err := s.validate.Struct(user); err != nil {
 		return errors.ErrInvalidUser
 	}
+	user.Email = utils.NormalizeEmail(user.Email)
+
+	if err := s.repo.AddUser(ctx, user, id); err != nil {
+		return err
+	}
+
+	if user.Email == "" || !emailVerified {
+		return nil
+	}
+
+	invites, err := s.invites.GetEmailInvites(ctx, user.Email)
+	if err != nil {
+		return err
+	}
 
-	return s.repo.AddUser(ctx, user, id)
+	return s.acceptInvites(ctx, id, invites, emailVerified)
 }
 
 // UpdateUser updates fields of an existing user.
+// A new email must be the email of the signed-in account, tokenEmail, and be verified,
+// as members and invites are resolved by it. It is stored lowercase and shown
+// on the decks the user is a member of, and its pending invites become memberships.
 // Validates the input and returns an error if the operation fails.
 func (s *UserService) UpdateUser(
 	ctx context.Context,
 	updateStruct models.PatchUser,
-	id string,
+	id, tokenEmail string,
+	emailVerified bool,
 ) (models.User, error) {
 	// Validate the input struct
 	if err := s.validate.Struct(updateStruct); err != nil {
 		return models.User{}, errors.ErrInvalidUser
 	}
+	updateStruct.Email = utils.NormalizeEmail(updateStruct.Email)
+	if updateStruct.Email != "" &&
+		(!emailVerified || updateStruct.Email != utils.NormalizeEmail(tokenEmail)) {
+		return models.User{}, errors.ErrEmailNotVerified
+	}
 
 	// Convert the struct to Firestore update format
 	update, err := utils.StructToUpdate(updateStruct)
@@ -134,21 +164,68 @@ func (s *UserService) UpdateUser(
 
 	s.cache.Delete(ctx, utils.UserKey(id))
 
+	if updateStruct.Email != "" {
+		if err := s.changeMemberEmail(ctx, id, updateStruct.Email, emailVerified); err != nil {
+			return models.User{}, err
+		}
+	}
+
 	return s.GetUser(ctx, id, defaultFilterUsers)
 }
 
-// DeleteUser removes a user by their ID.
+// changeMemberEmail shows the new email of a user on the decks the user is a member of,
+// and accepts the pending invites of the email.
+// Returns an error if the operation fails.
+func (s *UserService) changeMemberEmail(
+	ctx context.Context,
+	id, email string,
+	emailVerified bool,
+) error {
+	// Decks updated before a failure are cleared from the cache as well
+	updated, err := s.decks.UpdateMemberEmail(ctx, id, email)
+	for _, deckID := range updated {
+		s.cache.Delete(ctx, utils.DeckKey(deckID))
+	}
+	if err != nil {
+		return err
+	}
+
+	invites, err := s.invites.GetEmailInvites(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	return s.acceptInvites(ctx, id, invites, emailVerified)
+}
+
+// DeleteUser removes a user by their ID, with the decks the user owns, the classes
+// the user teaches and their enrolment in other classes.
+// When a member to transfer to is given, by user ID or email, the decks the member
+// belongs to are handed over to the member instead of being deleted.
 // Returns an error if the operation fails.
 func (s *UserService) DeleteUser(
 	ctx context.Context,
-	id string,
+	id, transferTo string,
 ) error {
-	err := s.repo.DeleteUser(ctx, id)
+	transferred, err := s.repo.DeleteUser(ctx, id, transferTo)
 	if err != nil {
 		return err
 	}
 
-	s.cache.Delete(ctx, utils.UserKey(id))
+	keys := []string{utils.UserKey(id), utils.UserDecksKey(id)}
+	for _, deckID := range transferred {
+		keys = append(keys, utils.DeckKey(deckID))
+		s.cache.DeletePattern(ctx, utils.DeckRolesKey(deckID)+"*")
+
+		deck, err := s.decks.GetOneDeck(ctx, deckID, []string{"owner_id", "member_ids"})
+		if err != nil {
+			continue
+		}
+		for _, userID := range deckUsers(deck) {
+			keys = append(keys, utils.UserDecksKey(userID))
+		}
+	}
+	s.cache.Delete(ctx, keys...)
 
 	return nil
 }
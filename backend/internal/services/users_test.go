@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"memora/internal/firebase"
+	"memora/internal/models"
+	"memora/internal/services"
+	"testing"
+
+	apperrors "memora/internal/errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeUserRepo accepts every new user.
+type fakeUserRepo struct {
+	firebase.UserRepository
+}
+
+func (fakeUserRepo) AddUser(context.Context, models.CreateUser, string) error {
+	return nil
+}
+
+// fakeInviteRepo keeps a single pending invite and the invites accepted.
+type fakeInviteRepo struct {
+	firebase.InviteRepository
+	invite   models.Invite
+	accepted []models.Invite
+}
+
+func (r *fakeInviteRepo) GetInvite(context.Context, string) (models.Invite, error) {
+	return r.invite, nil
+}
+
+func (r *fakeInviteRepo) GetEmailInvites(context.Context, string) ([]models.Invite, error) {
+	return []models.Invite{r.invite}, nil
+}
+
+func (r *fakeInviteRepo) AcceptInvites(
+	_ context.Context,
+	_ string,
+	invites []models.Invite,
+) ([]string, error) {
+	r.accepted = append(r.accepted, invites...)
+	return []string{r.invite.DeckID}, nil
+}
+
+func newInvitedUserService(invites *fakeInviteRepo) *services.UserService {
+	return services.NewUserService(&services.ServiceDeps{
+		UserRepo:   fakeUserRepo{},
+		InviteRepo: invites,
+		Cache:      services.NewCacheService(redis.NewClient(&redis.Options{Addr: "127.0.0.1:0", MaxRetries: -1})),
+		Validate:   validator.New(),
+	})
+}
+
+func TestAcceptInvite(t *testing.T) {
+	invite := models.Invite{ID: "invite", DeckID: "deck", Email: "new@user.com", Role: "viewer"}
+
+	tests := []struct {
+		name          string
+		email         string
+		emailVerified bool
+		wantErr       error
+		wantAccepted  int
+	}{
+		{"verified email", "New@User.com", true, nil, 1},
+		{"unverified email", "new@user.com", false, apperrors.ErrEmailNotVerified, 0},
+		{"another email", "other@user.com", true, apperrors.ErrNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invites := &fakeInviteRepo{invite: invite}
+			users := newInvitedUserService(invites)
+
+			err := users.AcceptInvite(context.Background(), invite.ID, "user", tt.email, tt.emailVerified)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AcceptInvite() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(invites.accepted) != tt.wantAccepted {
+				t.Errorf("accepted %d invites, want %d", len(invites.accepted), tt.wantAccepted)
+			}
+		})
+	}
+}
+
+func TestRegisterNewUserInvites(t *testing.T) {
+	invite := models.Invite{ID: "invite", DeckID: "deck", Email: "new@user.com", Role: "viewer"}
+	user := models.CreateUser{Name: "New", Email: "new@user.com"}
+
+	for _, emailVerified := range []bool{true, false} {
+		invites := &fakeInviteRepo{invite: invite}
+		users := newInvitedUserService(invites)
+
+		if err := users.RegisterNewUser(context.Background(), user, "user", emailVerified); err != nil {
+			t.Fatalf("RegisterNewUser() error = %v", err)
+		}
+
+		// The invites of an unverified email stay pending
+		want := 0
+		if emailVerified {
+			want = 1
+		}
+		if len(invites.accepted) != want {
+			t.Errorf("verified %v: accepted %d invites, want %d", emailVerified, len(invites.accepted), want)
+		}
+	}
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	users := newInvitedUserService(&fakeInviteRepo{})
+
+	tests := []struct {
+		name          string
+		tokenEmail    string
+		emailVerified bool
+	}{
+		{"unverified email of the account", "new@user.com", false},
+		{"verified email of another account", "other@user.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := models.PatchUser{Email: "new@user.com"}
+
+			_, err := users.UpdateUser(context.Background(), update, "user", tt.tokenEmail, tt.emailVerified)
+			if !errors.Is(err, apperrors.ErrEmailNotVerified) {
+				t.Errorf("UpdateUser() error = %v, want %v", err, apperrors.ErrEmailNotVerified)
+			}
+		})
+	}
+}
@@ -5,8 +5,78 @@ const MULTIPLE_CHOICE_CARD = "multiple_choice"
 const FRONT_BACK_CARD = "front_back"
 const ORDERED_CARD = "ordered"
 const BLANKS_CARD = "blanks"
+const NOTE_CARD = "note"
+const NUMERIC_CARD = "numeric"
+const MATCHING_CARD = "matching"
+const CODE_CARD = "code"
+
+const DIRECTION_FORWARD = "forward"
+const DIRECTION_REVERSE = "reverse"
+const DIRECTION_BOTH = "both"
+
+const TOLERANCE_ABSOLUTE = "absolute"
+const TOLERANCE_RELATIVE = "relative"
+
+const FORMAT_PLAIN = "plain"
+const FORMAT_MARKDOWN = "markdown"
+
+// Template field holding the rendered front of a card, only usable on the back
+const FRONT_SIDE_FIELD = "FrontSide"
 
 const OPP_ADD = "add"
 const OPP_REMOVE = "remove"
+const OPP_RENAME = "rename"
 
 const REQUESTS_PER_MINUTE = 80
+
+// Largest file accepted when importing a deck, in bytes
+const MAX_IMPORT_SIZE = 100 << 20
+
+const EXPORT_FORMAT_JSON = "json"
+const EXPORT_FORMAT_MARKDOWN = "markdown"
+const EXPORT_FORMAT_TEXT = "text"
+
+// Version of the JSON deck export, increased on every change to its schema
+const EXPORT_SCHEMA_VERSION = 1
+
+// Most rows read from an imported CSV file
+const MAX_IMPORT_ROWS = 5000
+
+// Status of a change of the source deck of a linked copy
+const CHANGE_ADDED = "added"
+const CHANGE_MODIFIED = "modified"
+const CHANGE_REMOVED = "removed"
+
+// Orders of the public deck library
+const LIBRARY_SORT_POPULAR = "popular"
+const LIBRARY_SORT_RECENT = "recent"
+
+// Most decks in a page of the public library, and most decks read to fill
+// a page when searching, as the search is done on the decks read
+const MAX_LIBRARY_LIMIT = 100
+const MAX_LIBRARY_SCAN = 500
+
+// Most cards skipped by a tag filter while filling a page of cards, as the
+// filters Firestore can not apply are applied on the cards read
+const MAX_CARD_SCAN = 1000
+
+// Roles on a deck, from the least to the most permissions.
+// Readers are not members, they read a public deck
+const ROLE_READER = "reader"
+const ROLE_VIEWER = "viewer"
+const ROLE_EDITOR = "editor"
+const ROLE_CO_OWNER = "co_owner"
+const ROLE_OWNER = "owner"
+
+// Actions recorded in the audit log of a deck
+const AUDIT_DECK_CREATE = "deck.create"
+const AUDIT_DECK_UPDATE = "deck.update"
+const AUDIT_DECK_DELETE = "deck.delete"
+const AUDIT_DECK_SHARE = "deck.share"
+const AUDIT_DECK_PUBLISH = "deck.publish"
+const AUDIT_DECK_TRANSFER = "deck.transfer"
+const AUDIT_DECK_RESTORE = "deck.restore"
+const AUDIT_CARD_CREATE = "card.create"
+const AUDIT_CARD_UPDATE = "card.update"
+const AUDIT_CARD_DELETE = "card.delete"
+const AUDIT_CARD_RESTORE = "card.restore"
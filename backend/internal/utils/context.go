@@ -0,0 +1,36 @@
+package utils
+
+import "context"
+
+// contextKey keys the values the middleware puts on the context of a request,
+// so services can read them without the Gin context.
+type contextKey string
+
+const (
+	userIDContextKey    contextKey = "uid"
+	requestIDContextKey contextKey = "reqID"
+)
+
+// WithUserID returns a copy of the context carrying the ID of the authenticated user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext retrieves the ID of the authenticated user from a context.
+// Returns an empty string if the request was not authenticated.
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDContextKey).(string)
+	return userID
+}
+
+// WithRequestID returns a copy of the context carrying the ID of the request.
+func WithRequestID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, reqID)
+}
+
+// RequestIDFromContext retrieves the ID the Logging middleware gave the request from a context.
+// Returns an empty string outside of a request.
+func RequestIDFromContext(ctx context.Context) string {
+	reqID, _ := ctx.Value(requestIDContextKey).(string)
+	return reqID
+}
@@ -127,23 +127,27 @@ func GetDocumentIfExists(
 	return snap, nil
 }
 
-// UserExistsByEmail checks if a user with the specified email exists in Firestore.
-// Returns true if the user exists, false otherwise, along with any error encountered.
-func UserExistsByEmail(
+// UserIDByEmail finds the ID of the user with the specified email in Firestore.
+// Emails are stored normalized, so the lookup ignores case.
+// Returns the ID of the user, empty if there is none, along with any error encountered.
+func UserIDByEmail(
 	client *firestore.Client,
 	ctx context.Context,
 	email string,
-) (bool, error) {
+) (string, error) {
 	// Query Firestore for a user with the given email
 	iter := client.Collection(config.UsersCollection).
-		Where("email", "==", email).
+		Where("email", "==", NormalizeEmail(email)).
 		Limit(1).
 		Documents(ctx)
 
 	// Check if any document was returned
 	doc, err := iter.Next()
-	if err != nil && err != iterator.Done {
-		return false, err
+	if err == iterator.Done {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
 	}
-	return doc != nil, nil
+	return doc.Ref.ID, nil
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"memora/internal/errors"
 	"net/url"
-	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -16,6 +15,9 @@ import (
 
 const defaultLimitSize = 20
 
+// Suffix added to the card ID for progress on the reverse side of a card
+const reverseProgressSuffix = "_reverse"
+
 // ParseLimit parses a limit string and returns it as an integer.
 // Returns an error if the string is not a valid integer.
 func ParseLimit(limitStr string) int {
@@ -51,23 +53,38 @@ func ParseFilter(filter string) ([]string, error) {
 	return result, nil
 }
 
-// CheckIfUserCanAccessDeck checks if the user making the request
-// is either the owner of the deck or has been granted access via shared emails.
-// Returns true if the user can access the deck, false otherwise.
-func CheckIfUserCanAccessDeck(c *gin.Context, ownerID string, sharedEmails []string) bool {
-	uid, err := GetUID(c)
-	if err != nil {
-		return false
+// ParseTags splits a comma separated list of tags, ignoring empty entries.
+// Returns nil if there are no tags.
+func ParseTags(tags string) []string {
+	var result []string
+	for tag := range strings.SplitSeq(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
 	}
-	email, err := GetEmail(c)
+	return result
+}
 
-	if err != nil {
-		return false
-	}
-	if uid != ownerID && !slices.Contains(sharedEmails, email) {
-		return false
-	}
-	return true
+// roleRanks orders the roles on a deck, a role has the permissions of every role ranked below it
+var roleRanks = map[string]int{
+	ROLE_READER:   1,
+	ROLE_VIEWER:   2,
+	ROLE_EDITOR:   3,
+	ROLE_CO_OWNER: 4,
+	ROLE_OWNER:    5,
+}
+
+// RoleAllows checks if a role on a deck has the permissions of the required role.
+// Returns false for unknown roles, including the empty role of users without access.
+func RoleAllows(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// NormalizeEmail lowercases an email and trims its spaces, so emails differing only
+// in case are the same user.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 // GetUID retrieves the user ID (UID) from the Gin context.
@@ -90,6 +107,45 @@ func GetEmail(c *gin.Context) (string, error) {
 	return email.(string), nil
 }
 
+// EmailVerified returns whether the email of the signed-in account is verified.
+func EmailVerified(c *gin.Context) bool {
+	return c.GetBool("email_verified")
+}
+
+// ReviewDirections returns the directions a card is reviewed in, based on its raw data.
+// Only front/back cards can be reviewed in reverse, every other card is reviewed forward.
+func ReviewDirections(card map[string]any) []string {
+	if card["type"] != FRONT_BACK_CARD {
+		return []string{DIRECTION_FORWARD}
+	}
+
+	switch card["direction"] {
+	case DIRECTION_REVERSE:
+		return []string{DIRECTION_REVERSE}
+	case DIRECTION_BOTH:
+		return []string{DIRECTION_FORWARD, DIRECTION_REVERSE}
+	default:
+		return []string{DIRECTION_FORWARD}
+	}
+}
+
+// ProgressID returns the ID of the progress document for a card reviewed in a direction.
+// Forward progress uses the card ID itself, so progress made before directions existed is kept.
+func ProgressID(cardID, direction string) string {
+	if direction == DIRECTION_REVERSE {
+		return cardID + reverseProgressSuffix
+	}
+	return cardID
+}
+
+// SplitProgressID splits the ID of a progress document into the card ID and direction.
+func SplitProgressID(progressID string) (string, string) {
+	if cardID, ok := strings.CutSuffix(progressID, reverseProgressSuffix); ok {
+		return cardID, DIRECTION_REVERSE
+	}
+	return progressID, DIRECTION_FORWARD
+}
+
 // ReadDataFromIterator reads data from a Firestore DocumentIterator
 // and unmarshals it into a slice of the specified type T.
 // Returns the slice of T or an error if the operation fails.
@@ -144,6 +200,19 @@ func StructToUpdate(data any) ([]firestore.Update, error) {
 	return updates, nil
 }
 
+// SetUpdate sets the value of a path in a slice of Firestore updates,
+// adding the path if it is not updated yet.
+// Used for zero values StructToUpdate skips, but that should still be stored.
+func SetUpdate(updates []firestore.Update, path string, value any) []firestore.Update {
+	for i := range updates {
+		if updates[i].Path == path {
+			updates[i].Value = value
+			return updates
+		}
+	}
+	return append(updates, firestore.Update{Path: path, Value: value})
+}
+
 // validateValue checks if a value is non-zero based on its type.
 // Returns true if the value is non-zero, false otherwise.
 func validateValue(v any) bool {
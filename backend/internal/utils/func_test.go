@@ -0,0 +1,40 @@
+package utils_test
+
+import (
+	"memora/internal/utils"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		want     bool
+	}{
+		{utils.ROLE_OWNER, utils.ROLE_OWNER, true},
+		{utils.ROLE_OWNER, utils.ROLE_READER, true},
+		{utils.ROLE_CO_OWNER, utils.ROLE_OWNER, false},
+		{utils.ROLE_CO_OWNER, utils.ROLE_EDITOR, true},
+		{utils.ROLE_EDITOR, utils.ROLE_EDITOR, true},
+		{utils.ROLE_EDITOR, utils.ROLE_CO_OWNER, false},
+		{utils.ROLE_VIEWER, utils.ROLE_READER, true},
+		{utils.ROLE_VIEWER, utils.ROLE_EDITOR, false},
+		{utils.ROLE_READER, utils.ROLE_VIEWER, false},
+		{"", utils.ROLE_READER, false},
+		{"admin", utils.ROLE_READER, false},
+	}
+
+	for _, tt := range tests {
+		if got := utils.RoleAllows(tt.role, tt.required); got != tt.want {
+			t.Errorf("RoleAllows(%q, %q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	for _, email := range []string{"alice@x.com", "Alice@X.com", " ALICE@x.com "} {
+		if got := utils.NormalizeEmail(email); got != "alice@x.com" {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", email, got, "alice@x.com")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Style used when highlighting code without a style given
+const DefaultCodeStyle = "github"
+
+// IsKnownLanguage reports whether code in the language can be highlighted.
+func IsKnownLanguage(language string) bool {
+	return lexers.Get(language) != nil
+}
+
+// IsKnownCodeStyle reports whether code can be highlighted with the named style.
+func IsKnownCodeStyle(style string) bool {
+	_, ok := styles.Registry[strings.ToLower(style)]
+	return ok
+}
+
+// CountLines returns the number of lines in a code body.
+func CountLines(code string) int {
+	return strings.Count(strings.TrimRight(code, "\n"), "\n") + 1
+}
+
+// HighlightCode renders code in the given language to HTML with inline styles, so it looks
+// the same without any stylesheet. Lines from highlightFrom to highlightTo are marked,
+// nothing is marked when they are 0.
+// Returns an error if the language is unknown or the code can not be highlighted.
+func HighlightCode(code, language, style string, highlightFrom, highlightTo int) (string, error) {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		return "", fmt.Errorf("unknown language %q", language)
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	options := []chromahtml.Option{chromahtml.WithLineNumbers(true)}
+	if highlightFrom > 0 {
+		options = append(options, chromahtml.HighlightLines([][2]int{{highlightFrom, highlightTo}}))
+	}
+
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	formatter := chromahtml.New(options...)
+	if err := formatter.Format(&b, styles.Get(style), iterator); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,44 @@
+package utils_test
+
+import (
+	"memora/internal/utils"
+	"strings"
+	"testing"
+)
+
+func TestHighlightCode(t *testing.T) {
+	code := "package main\n\nfunc main() {\n\tprintln(\"<b>hi</b>\")\n}\n"
+
+	got, err := utils.HighlightCode(code, "go", utils.DefaultCodeStyle, 3, 4)
+	if err != nil {
+		t.Fatalf("HighlightCode() returned error %v", err)
+	}
+
+	if strings.Contains(got, "<b>hi</b>") {
+		t.Errorf("HighlightCode() = %q, want the code escaped", got)
+	}
+	if !strings.Contains(got, "style=") {
+		t.Errorf("HighlightCode() = %q, want inline styles", got)
+	}
+
+	// Only the highlighted lines get a background
+	unmarked, err := utils.HighlightCode(code, "go", utils.DefaultCodeStyle, 0, 0)
+	if err != nil {
+		t.Fatalf("HighlightCode() returned error %v", err)
+	}
+	if got == unmarked {
+		t.Error("HighlightCode() with a line range returned the same HTML as without one")
+	}
+
+	if _, err := utils.HighlightCode(code, "not-a-language", utils.DefaultCodeStyle, 0, 0); err == nil {
+		t.Error("HighlightCode() with an unknown language returned no error")
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	for code, want := range map[string]int{"a": 1, "a\nb": 2, "a\nb\n": 2, "a\n\nb": 3} {
+		if got := utils.CountLines(code); got != want {
+			t.Errorf("CountLines(%q) = %d, want %d", code, got, want)
+		}
+	}
+}
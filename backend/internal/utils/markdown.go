@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Maximum number of times text is sanitized while looking for escaped tags
+const maxSanitizePasses = 5
+
+// Math delimiters, longest first so "$$" is not read as two inline "$"
+var mathDelimiters = []struct {
+	open, close string
+}{
+	{"$$", "$$"},
+	{`\[`, `\]`},
+	{`\(`, `\)`},
+	{"$", "$"},
+}
+
+var (
+	// Markdown keeps the HTML users are allowed to write, plain text keeps none
+	markdownPolicy = bluemonday.UGCPolicy()
+	plainPolicy    = bluemonday.StrictPolicy()
+
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(goldmarkhtml.WithHardWraps(), goldmarkhtml.WithUnsafe()),
+	)
+)
+
+// Characters with a meaning anywhere in markdown text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`,
+)
+
+// segmentKind tells how a part of a text is handled
+type segmentKind int
+
+const (
+	textSegment segmentKind = iota
+	mathSegment
+	codeSegment
+)
+
+// segment is a part of a text, either regular content, math or markdown code
+type segment struct {
+	text string
+	kind segmentKind
+}
+
+// ValidateMarkdown checks that markdown content can be rendered as intended.
+// Returns an error if display math or \( math is opened without being closed.
+func ValidateMarkdown(text string) error {
+	for _, segment := range splitSegments(text, true) {
+		if segment.kind != textSegment {
+			continue
+		}
+		for _, d := range mathDelimiters[:3] {
+			if strings.Contains(strings.ReplaceAll(segment.text, `\$`, ""), d.open) {
+				return fmt.Errorf("unclosed math delimiter %q", d.open)
+			}
+		}
+	}
+	return nil
+}
+
+// SanitizeContent strips dangerous HTML from a card field in the given format.
+// Markdown keeps safe HTML, while plain text keeps no tags at all.
+// Math can not open tags either, as clients may hand it to a renderer as is,
+// and markdown code is left untouched as it is never rendered as HTML.
+func SanitizeContent(text, format string) string {
+	policy := plainPolicy
+	if format == FORMAT_MARKDOWN {
+		policy = markdownPolicy
+	}
+
+	var b strings.Builder
+	for _, segment := range splitSegments(text, format == FORMAT_MARKDOWN) {
+		switch segment.kind {
+		case mathSegment:
+			b.WriteString(sanitizeMath(segment.text))
+		case codeSegment:
+			b.WriteString(segment.text)
+		default:
+			b.WriteString(sanitizeText(policy, segment.text))
+		}
+	}
+	return b.String()
+}
+
+// RenderHTML renders a card field in the given format to sanitized HTML.
+// Math keeps its delimiters, so it can be rendered by the client.
+func RenderHTML(text, format string) string {
+	// Replace math with placeholders so it is not read as markdown
+	var source strings.Builder
+	var math []string
+	for _, segment := range splitSegments(text, format == FORMAT_MARKDOWN) {
+		if segment.kind != mathSegment {
+			source.WriteString(segment.text)
+			continue
+		}
+		fmt.Fprintf(&source, "memoramath%dplaceholder", len(math))
+		math = append(math, segment.text)
+	}
+
+	var rendered string
+	if format == FORMAT_MARKDOWN {
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(source.String()), &buf); err != nil {
+			rendered = plainToHTML(source.String())
+		} else {
+			rendered = markdownPolicy.Sanitize(buf.String())
+		}
+	} else {
+		rendered = plainToHTML(source.String())
+	}
+
+	// Put the math back, escaped so it is shown as written
+	for i := len(math) - 1; i >= 0; i-- {
+		placeholder := fmt.Sprintf("memoramath%dplaceholder", i)
+		rendered = strings.ReplaceAll(rendered, placeholder, html.EscapeString(math[i]))
+	}
+
+	return rendered
+}
+
+// EscapeMarkdown escapes plain text so it is shown as written when read as markdown.
+// Math is kept as is, as plain text cards render math too.
+func EscapeMarkdown(text string) string {
+	var result strings.Builder
+	for _, segment := range splitSegments(text, false) {
+		if segment.kind == mathSegment {
+			result.WriteString(segment.text)
+			continue
+		}
+
+		lines := strings.Split(markdownEscaper.Replace(segment.text), "\n")
+		for i, line := range lines {
+			lines[i] = escapeLineStart(line)
+		}
+		result.WriteString(strings.Join(lines, "\n"))
+	}
+	return result.String()
+}
+
+// escapeLineStart escapes the markers starting a heading, list or ordered list item,
+// which are only markers when followed by a space or the end of the line.
+// The first line of a segment is escaped as well, which is harmless mid-line.
+func escapeLineStart(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+
+	markerEnds := func(i int) bool { return i == len(trimmed) || trimmed[i] == ' ' }
+
+	if trimmed == "" {
+		return line
+	}
+
+	switch trimmed[0] {
+	case '-', '+':
+		if markerEnds(1) {
+			return indent + `\` + trimmed
+		}
+	case '#':
+		if markerEnds(len(trimmed) - len(strings.TrimLeft(trimmed, "#"))) {
+			return indent + `\` + trimmed
+		}
+	}
+
+	// Ordered list items start with digits followed by "." or ")"
+	digits := len(trimmed) - len(strings.TrimLeft(trimmed, "0123456789"))
+	if digits > 0 && digits < len(trimmed) &&
+		(trimmed[digits] == '.' || trimmed[digits] == ')') && markerEnds(digits+1) {
+		return indent + trimmed[:digits] + `\` + trimmed[digits:]
+	}
+
+	return line
+}
+
+// plainToHTML escapes plain text and keeps its line breaks
+func plainToHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>\n")
+}
+
+// sanitizeText removes the HTML not allowed by the policy, without escaping the text kept.
+// Unescaping can reveal new tags, so the text is sanitized until it no longer changes.
+func sanitizeText(policy *bluemonday.Policy, text string) string {
+	for range maxSanitizePasses {
+		clean := html.UnescapeString(policy.Sanitize(text))
+		if clean == text {
+			return text
+		}
+		text = clean
+	}
+
+	// Still changing, keep the escaped text which is always safe
+	return policy.Sanitize(text)
+}
+
+// sanitizeMath keeps math from being read as HTML, by spacing out every "<"
+// that would open a tag. Spaces have no meaning in math, so it still renders the same.
+func sanitizeMath(math string) string {
+	var b strings.Builder
+	for i := 0; i < len(math); i++ {
+		b.WriteByte(math[i])
+		if math[i] == '<' && i+1 < len(math) && opensTag(math[i+1]) {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// opensTag tells if a character right after "<" makes it the start of an HTML tag,
+// a closing tag, a comment or a processing instruction
+func opensTag(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '/' || c == '!' || c == '?'
+}
+
+// splitSegments splits a text into regular, math and, for markdown, code segments.
+// A single "$" only starts math when followed by a non-space, and is ended on the same line,
+// so amounts like "$5 and $10" are not read as math. "\$" is never a delimiter.
+func splitSegments(text string, isMarkdown bool) []segment {
+	var segments []segment
+	start := 0
+
+	for i := 0; i < len(text); {
+		if strings.HasPrefix(text[i:], `\$`) || strings.HasPrefix(text[i:], "\\`") {
+			i += 2
+			continue
+		}
+
+		end, kind := -1, mathSegment
+		if isMarkdown && text[i] == '`' {
+			end, kind = codeEnd(text, i), codeSegment
+		} else {
+			end = mathEnd(text, i)
+		}
+
+		if end < 0 {
+			i++
+			continue
+		}
+
+		if start < i {
+			segments = append(segments, segment{text: text[start:i], kind: textSegment})
+		}
+		segments = append(segments, segment{text: text[i:end], kind: kind})
+		start, i = end, end
+	}
+
+	if start < len(text) {
+		segments = append(segments, segment{text: text[start:], kind: textSegment})
+	}
+	return segments
+}
+
+// mathEnd returns the index right after the math starting at i, or -1 if there is none
+func mathEnd(text string, i int) int {
+	for _, d := range mathDelimiters {
+		if !strings.HasPrefix(text[i:], d.open) {
+			continue
+		}
+
+		content := text[i+len(d.open):]
+		closing := strings.Index(content, d.close)
+		if d.open == "$" {
+			closing = inlineMathEnd(content)
+		}
+		if closing > 0 {
+			return i + len(d.open) + closing + len(d.close)
+		}
+		return -1
+	}
+	return -1
+}
+
+// inlineMathEnd returns the index of the "$" ending inline math, or -1 if there is none
+func inlineMathEnd(content string) int {
+	if content == "" || content[0] == ' ' {
+		return -1
+	}
+
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\n':
+			return -1
+		case '\\':
+			i++
+		case '$':
+			if i == 0 || content[i-1] == ' ' {
+				return -1
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+// codeEnd returns the index right after the markdown code starting at i, or -1 if there is none.
+// Code is closed by a run of backticks as long as the one opening it, which covers both
+// inline code and fenced code blocks.
+func codeEnd(text string, i int) int {
+	n := 0
+	for i+n < len(text) && text[i+n] == '`' {
+		n++
+	}
+
+	for j := i + n; j < len(text); {
+		if text[j] != '`' {
+			j++
+			continue
+		}
+
+		run := 0
+		for j+run < len(text) && text[j+run] == '`' {
+			run++
+		}
+		if run == n {
+			return j + run
+		}
+		j += run
+	}
+	return -1
+}
@@ -0,0 +1,99 @@
+package utils_test
+
+import (
+	"memora/internal/utils"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		format string
+		want   string
+	}{
+		{"plain text is kept", "3 < 4 & 5 > 2", utils.FORMAT_PLAIN, "3 < 4 & 5 > 2"},
+		{"script is removed", "Paris<script>alert(1)</script>", utils.FORMAT_PLAIN, "Paris"},
+		{"escaped script is removed", "&lt;script&gt;alert(1)&lt;/script&gt;", utils.FORMAT_PLAIN, ""},
+		{"safe html kept in markdown", "H<sub>2</sub>O", utils.FORMAT_MARKDOWN, "H<sub>2</sub>O"},
+		{"handlers removed in markdown", `<b onclick="x()">bold</b>`, utils.FORMAT_MARKDOWN, "<b>bold</b>"},
+		{"markdown syntax is kept", "> **quote** and `a<b`", utils.FORMAT_MARKDOWN, "> **quote** and `a<b`"},
+		{"math is kept", `$a < b$ and \(x \leq y\)`, utils.FORMAT_MARKDOWN, `$a < b$ and \(x \leq y\)`},
+		{"math opens no tags", `$a<b$ and \(x<y\)`, utils.FORMAT_MARKDOWN, `$a< b$ and \(x< y\)`},
+		{"tags in $ math are broken up", "$<img src=x onerror=alert(1)>$", utils.FORMAT_PLAIN, "$< img src=x onerror=alert(1)>$"},
+		{"tags in \\( math are broken up", `\(<script>alert(1)</script>\)`, utils.FORMAT_PLAIN, `\(< script>alert(1)< /script>\)`},
+		{"tags in markdown math are broken up", "$$<!--x-->$$", utils.FORMAT_MARKDOWN, "$$< !--x-->$$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.SanitizeContent(tt.text, tt.format); got != tt.want {
+				t.Errorf("SanitizeContent(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateMarkdown(t *testing.T) {
+	valid := []string{
+		"$x^2$",
+		"$$\\int_0^1 x\\,dx$$",
+		"costs $5 and $10",
+		`\$ not math`,
+		"`echo $$` prints the process ID",
+	}
+	for _, text := range valid {
+		if err := utils.ValidateMarkdown(text); err != nil {
+			t.Errorf("ValidateMarkdown(%q) returned error %v", text, err)
+		}
+	}
+
+	invalid := []string{"$$x^2", `\(x`, `\[x`}
+	for _, text := range invalid {
+		if err := utils.ValidateMarkdown(text); err == nil {
+			t.Errorf("ValidateMarkdown(%q) returned no error", text)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	got := utils.RenderHTML("**Energy** is $E = mc^2$ and $x_1 < x_2$", utils.FORMAT_MARKDOWN)
+	for _, want := range []string{"<strong>Energy</strong>", "$E = mc^2$", "$x_1 &lt; x_2$"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHTML() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	got = utils.RenderHTML("<b>not bold</b>\nnext line", utils.FORMAT_PLAIN)
+	want := "&lt;b&gt;not bold&lt;/b&gt;<br>\nnext line"
+	if got != want {
+		t.Errorf("RenderHTML() = %q, want %q", got, want)
+	}
+
+	got = utils.RenderHTML(`<img src=x onerror="alert(1)">`, utils.FORMAT_MARKDOWN)
+	if strings.Contains(got, "onerror") {
+		t.Errorf("RenderHTML() = %q, want event handlers removed", got)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain words.", "plain words."},
+		{"2 * 3 = 6", `2 \* 3 = 6`},
+		{"snake_case and [link]", `snake\_case and \[link\]`},
+		{"# not a heading\n- not a list\n1. not ordered", "\\# not a heading\n\\- not a list\n1\\. not ordered"},
+		{"$a_1 * b$ stays math", "$a_1 * b$ stays math"},
+		{"<b>tag</b>", `\<b\>tag\</b\>`},
+		{"9.81 m/s² and -5 #1", "9.81 m/s² and -5 #1"},
+	}
+
+	for _, tt := range tests {
+		if got := utils.EscapeMarkdown(tt.text); got != tt.want {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kinds of blocks turned into cards when importing Markdown notes
+const (
+	BLOCK_QUESTION = "question"
+	BLOCK_TERM     = "term"
+	BLOCK_CLOZE    = "cloze"
+)
+
+// Matches a cloze span such as {{Paris}}, also accepting Anki's {{c1::Paris::hint}}
+var clozeSpan = regexp.MustCompile(`\{\{(?:c\d+::)?(.+?)(?:::[^{}]*)?\}\}`)
+
+// Matches a "term :: definition" line, the spaces keep code such as "std::vector" out
+var termLine = regexp.MustCompile(`^(\S.*?)\s+::\s+(\S.*)$`)
+
+// Matches a list marker at the start of a line, such as "- ", "* " or "1. "
+var listMarker = regexp.MustCompile(`^(?:[-*+]|\d+[.)])\s+`)
+
+// Matches an "<!-- id: name -->" comment giving the next block a stable ID
+var idComment = regexp.MustCompile(`^<!--\s*id:\s*(\S+)\s*-->$`)
+
+// MarkdownBlock is a part of a Markdown document that becomes a card.
+type MarkdownBlock struct {
+	// Line of the document the block starts on, from 1
+	Line int
+	Kind string
+	// Key identifies the block between imports when it has no ID: the question,
+	// the term, or the cloze text with its spans replaced by "{}"
+	Key string
+	// ID is given by an "<!-- id: name -->" comment on the line before the block,
+	// so the block keeps its card when its text is edited
+	ID string
+
+	Front   string
+	Back    string
+	Answers []string
+}
+
+// MarkdownBlockError tells why a line of a Markdown document could not become a card.
+type MarkdownBlockError struct {
+	Line int
+	Err  string
+}
+
+// ParseMarkdownCards finds the cards written in a Markdown document:
+// "Q:" lines followed by "A:" lines, "term :: definition" lines and lines with
+// {{cloze}} spans. Questions and answers continue until a blank line.
+// An "<!-- id: name -->" comment on its own line gives an ID to the block after it.
+// Fenced code blocks and every other line are ignored.
+// Returns the blocks in order, and the errors of the blocks that are not complete.
+func ParseMarkdownCards(document string) ([]MarkdownBlock, []MarkdownBlockError) {
+	var blocks []MarkdownBlock
+	var errs []MarkdownBlockError
+
+	var current *MarkdownBlock
+	inAnswer := false
+	fence := ""
+
+	// The ID waiting for the next block, and the line it was given on
+	id, idLine := "", 0
+	takeID := func() string {
+		taken := id
+		id = ""
+		return taken
+	}
+	dropID := func() {
+		if id != "" {
+			errs = append(errs, MarkdownBlockError{Line: idLine, Err: "id without a block after it"})
+			id = ""
+		}
+	}
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		if inAnswer {
+			current.Front = strings.TrimSpace(current.Front)
+			current.Back = strings.TrimSpace(current.Back)
+			current.Key = current.Front
+			blocks = append(blocks, *current)
+		} else {
+			errs = append(errs, MarkdownBlockError{Line: current.Line, Err: "question without answer"})
+		}
+		current, inAnswer = nil, false
+	}
+
+	for i, line := range strings.Split(strings.ReplaceAll(document, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Nothing inside fenced code is a card
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			finish()
+			dropID()
+			fence = trimmed[:3]
+			continue
+		}
+
+		if match := idComment.FindStringSubmatch(trimmed); match != nil {
+			finish()
+			dropID()
+			id, idLine = match[1], i+1
+			continue
+		}
+
+		if rest, ok := cutLabel(trimmed, "Q:"); ok {
+			finish()
+			current = &MarkdownBlock{Line: i + 1, Kind: BLOCK_QUESTION, Front: rest, ID: takeID()}
+			continue
+		}
+
+		if current != nil {
+			if rest, ok := cutLabel(trimmed, "A:"); ok && !inAnswer {
+				inAnswer = true
+				current.Back = rest
+				continue
+			}
+			if trimmed == "" {
+				finish()
+				continue
+			}
+			if inAnswer {
+				current.Back += "\n" + line
+			} else {
+				current.Front += "\n" + line
+			}
+			continue
+		}
+
+		item := listMarker.ReplaceAllString(trimmed, "")
+
+		if clozeSpan.MatchString(item) {
+			if strings.Contains(item, "{}") {
+				errs = append(errs, MarkdownBlockError{Line: i + 1, Err: `cloze text can not contain "{}"`})
+				takeID()
+				continue
+			}
+
+			var answers []string
+			question := clozeSpan.ReplaceAllStringFunc(item, func(span string) string {
+				answers = append(answers, strings.TrimSpace(clozeSpan.FindStringSubmatch(span)[1]))
+				return "{}"
+			})
+			blocks = append(blocks, MarkdownBlock{
+				Line:    i + 1,
+				Kind:    BLOCK_CLOZE,
+				Key:     question,
+				ID:      takeID(),
+				Front:   question,
+				Answers: answers,
+			})
+			continue
+		}
+
+		if match := termLine.FindStringSubmatch(item); match != nil {
+			blocks = append(blocks, MarkdownBlock{
+				Line:  i + 1,
+				Kind:  BLOCK_TERM,
+				Key:   match[1],
+				ID:    takeID(),
+				Front: match[1],
+				Back:  match[2],
+			})
+		}
+	}
+	finish()
+	dropID()
+
+	return blocks, errs
+}
+
+// BlockKeyHash returns a stable hash of the key of a block, ignoring differences in whitespace.
+func BlockKeyHash(key string) string {
+	return shortHash(strings.Join(strings.Fields(key), " "))
+}
+
+// ContentHash returns a short hash of the content of a card, to tell when it changed.
+func ContentHash(content []byte) string {
+	return shortHash(string(content))
+}
+
+// shortHash returns the first 16 hexadecimal characters of the SHA-256 of a text.
+func shortHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// cutLabel removes a label such as "Q:" from the start of a line, in any case.
+// Returns the rest of the line, and whether the label was there.
+func cutLabel(line, label string) (string, bool) {
+	if len(line) < len(label) || !strings.EqualFold(line[:len(label)], label) {
+		return "", false
+	}
+	return strings.TrimSpace(line[len(label):]), true
+}
+
+func (e MarkdownBlockError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
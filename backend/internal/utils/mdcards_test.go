@@ -0,0 +1,80 @@
+package utils_test
+
+import (
+	"memora/internal/utils"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdownCards(t *testing.T) {
+	document := "# Geography\n" +
+		"\n" +
+		"Q: Capital of France?\n" +
+		"A: Paris\n" +
+		"on the Seine\n" +
+		"\n" +
+		"- Madrid :: capital of Spain\n" +
+		"- {{Rome}} is the capital of {{c2::Italy::country}}\n" +
+		"\n" +
+		"```cpp\n" +
+		"std::vector<int> v; // Q: not a card\n" +
+		"```\n" +
+		"\n" +
+		"Q: Capital of Peru?\n" +
+		"\n" +
+		"Uses std::vector and {} {{here}}\n"
+
+	blocks, errs := utils.ParseMarkdownCards(document)
+
+	want := []utils.MarkdownBlock{
+		{Line: 3, Kind: utils.BLOCK_QUESTION, Key: "Capital of France?", Front: "Capital of France?", Back: "Paris\non the Seine"},
+		{Line: 7, Kind: utils.BLOCK_TERM, Key: "Madrid", Front: "Madrid", Back: "capital of Spain"},
+		{Line: 8, Kind: utils.BLOCK_CLOZE, Key: "{} is the capital of {}", Front: "{} is the capital of {}", Answers: []string{"Rome", "Italy"}},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("blocks = %+v, want %+v", blocks, want)
+	}
+
+	wantErrs := []utils.MarkdownBlockError{
+		{Line: 14, Err: "question without answer"},
+		{Line: 16, Err: `cloze text can not contain "{}"`},
+	}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Errorf("errors = %+v, want %+v", errs, wantErrs)
+	}
+}
+
+func TestParseMarkdownCardsIDs(t *testing.T) {
+	document := "<!-- id: france -->\n" +
+		"Q: Capital of France?\n" +
+		"A: Paris\n" +
+		"<!-- id:madrid -->\n" +
+		"- Madrid :: capital of Spain\n" +
+		"- Lima :: capital of Peru\n" +
+		"<!-- id: orphan -->\n"
+
+	blocks, errs := utils.ParseMarkdownCards(document)
+
+	want := []utils.MarkdownBlock{
+		{Line: 2, Kind: utils.BLOCK_QUESTION, Key: "Capital of France?", ID: "france", Front: "Capital of France?", Back: "Paris"},
+		{Line: 5, Kind: utils.BLOCK_TERM, Key: "Madrid", ID: "madrid", Front: "Madrid", Back: "capital of Spain"},
+		{Line: 6, Kind: utils.BLOCK_TERM, Key: "Lima", Front: "Lima", Back: "capital of Peru"},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("blocks = %+v, want %+v", blocks, want)
+	}
+
+	wantErrs := []utils.MarkdownBlockError{{Line: 7, Err: "id without a block after it"}}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Errorf("errors = %+v, want %+v", errs, wantErrs)
+	}
+}
+
+func TestBlockKeyHash(t *testing.T) {
+	if utils.BlockKeyHash("Capital of\n France?") != utils.BlockKeyHash("Capital of France?") {
+		t.Error("BlockKeyHash() should ignore whitespace")
+	}
+	if utils.BlockKeyHash("Capital of France?") == utils.BlockKeyHash("Capital of Spain?") {
+		t.Error("BlockKeyHash() should differ for different keys")
+	}
+}
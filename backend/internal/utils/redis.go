@@ -18,12 +18,20 @@ func DeckCardsKey(deckID string) string {
 	return DeckKeyPrefix + ":" + deckID + ":cards"
 }
 
+func DeckRolesKey(deckID string) string {
+	return DeckKeyPrefix + ":" + deckID + ":roles"
+}
+
+func DeckRoleKey(deckID, userID string) string {
+	return DeckRolesKey(deckID) + ":" + userID
+}
+
 func DeckCardKey(deckID, cardID string) string {
 	return DeckKeyPrefix + ":" + deckID + ":" + CardKeyPrefix + ":" + cardID
 }
 
-func UserEmailDecksKey(email string) string {
-	return "user:email:" + email + ":decks"
+func UserDecksKey(userID string) string {
+	return UserKeyPrefix + ":" + userID + ":decks"
 }
 
 func UserKeyRateLimit(userID string) string {
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+)
+
+// Matches a {{Field}} reference in a card template, spaces around the name are allowed
+var templateField = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+// RenderTemplate replaces every {{Field}} in a card template with the value of the field.
+// Fields without a value are replaced with an empty string.
+func RenderTemplate(template string, fields map[string]string) string {
+	return templateField.ReplaceAllStringFunc(template, func(match string) string {
+		name := templateField.FindStringSubmatch(match)[1]
+		return fields[name]
+	})
+}
+
+// TemplateFields returns the names of the fields referenced in a card template, in order.
+func TemplateFields(template string) []string {
+	var fields []string
+	for _, match := range templateField.FindAllStringSubmatch(template, -1) {
+		fields = append(fields, match[1])
+	}
+	return fields
+}
+
+// NoteCardID returns the ID of the card generated for a note from the named template.
+// The ID only depends on the template name, so reordering templates keeps the progress of cards.
+func NoteCardID(noteID, templateName string) string {
+	hash := sha1.Sum([]byte(templateName))
+	return noteID + "-" + hex.EncodeToString(hash[:4])
+}
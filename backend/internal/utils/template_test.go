@@ -0,0 +1,39 @@
+package utils_test
+
+import (
+	"memora/internal/utils"
+	"slices"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]string{"Word": "猫", "Reading": "ねこ", "Meaning": "cat"}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"single field", "{{Word}}", "猫"},
+		{"several fields", "{{Word}} ({{Reading}})", "猫 (ねこ)"},
+		{"spaces around name", "{{ Meaning }}", "cat"},
+		{"unknown field is empty", "{{Word}}{{Example}}", "猫"},
+		{"text without fields", "plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.RenderTemplate(tt.template, fields); got != tt.want {
+				t.Errorf("RenderTemplate(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFields(t *testing.T) {
+	got := utils.TemplateFields("{{FrontSide}}<hr>{{ Meaning }} - {{Example}}")
+	want := []string{"FrontSide", "Meaning", "Example"}
+	if !slices.Equal(got, want) {
+		t.Errorf("TemplateFields() = %v, want %v", got, want)
+	}
+}
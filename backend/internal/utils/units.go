@@ -0,0 +1,250 @@
+package utils
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dimensions holds the exponent of each SI base unit: m, kg, s, A, K, mol and cd
+type Dimensions [7]int
+
+// Unit is a parsed unit, converting values in the unit to SI base units
+type Unit struct {
+	Factor     float64
+	Dimensions Dimensions
+}
+
+var (
+	meter    = Dimensions{1, 0, 0, 0, 0, 0, 0}
+	kilogram = Dimensions{0, 1, 0, 0, 0, 0, 0}
+	second   = Dimensions{0, 0, 1, 0, 0, 0, 0}
+	ampere   = Dimensions{0, 0, 0, 1, 0, 0, 0}
+	kelvin   = Dimensions{0, 0, 0, 0, 1, 0, 0}
+	mole     = Dimensions{0, 0, 0, 0, 0, 1, 0}
+	candela  = Dimensions{0, 0, 0, 0, 0, 0, 1}
+
+	newton = Dimensions{1, 1, -2, 0, 0, 0, 0}
+	joule  = Dimensions{2, 1, -2, 0, 0, 0, 0}
+	watt   = Dimensions{2, 1, -3, 0, 0, 0, 0}
+	volt   = Dimensions{2, 1, -3, -1, 0, 0, 0}
+)
+
+// Units that can be written with an SI prefix
+var prefixableUnits = map[string]Unit{
+	"m":   {1, meter},
+	"g":   {1e-3, kilogram},
+	"s":   {1, second},
+	"A":   {1, ampere},
+	"K":   {1, kelvin},
+	"mol": {1, mole},
+	"cd":  {1, candela},
+	"N":   {1, newton},
+	"J":   {1, joule},
+	"W":   {1, watt},
+	"Pa":  {1, Dimensions{-1, 1, -2, 0, 0, 0, 0}},
+	"Hz":  {1, Dimensions{0, 0, -1, 0, 0, 0, 0}},
+	"C":   {1, Dimensions{0, 0, 1, 1, 0, 0, 0}},
+	"V":   {1, volt},
+	"Ω":   {1, Dimensions{2, 1, -3, -2, 0, 0, 0}},
+	"ohm": {1, Dimensions{2, 1, -3, -2, 0, 0, 0}},
+	"L":   {1e-3, Dimensions{3, 0, 0, 0, 0, 0, 0}},
+	"l":   {1e-3, Dimensions{3, 0, 0, 0, 0, 0, 0}},
+	"eV":  {1.602176634e-19, joule},
+	"bar": {1e5, Dimensions{-1, 1, -2, 0, 0, 0, 0}},
+}
+
+// Units that are never written with a prefix
+var plainUnits = map[string]Unit{
+	"min": {60, second},
+	"h":   {3600, second},
+	"d":   {86400, second},
+	"atm": {101325, Dimensions{-1, 1, -2, 0, 0, 0, 0}},
+	"%":   {1e-2, Dimensions{}},
+}
+
+var siPrefixes = map[string]float64{
+	"Y": 1e24, "Z": 1e21, "E": 1e18, "P": 1e15, "T": 1e12, "G": 1e9, "M": 1e6,
+	"k": 1e3, "h": 1e2, "da": 1e1, "d": 1e-1, "c": 1e-2, "m": 1e-3,
+	"µ": 1e-6, "μ": 1e-6, "u": 1e-6, "n": 1e-9, "p": 1e-12, "f": 1e-15, "a": 1e-18,
+}
+
+// SI prefixes in the order they are tried, longest first so "dam" is a decameter
+var siPrefixOrder = slices.SortedFunc(maps.Keys(siPrefixes), func(a, b string) int {
+	return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+})
+
+var superscripts = strings.NewReplacer(
+	"⁻", "-", "⁰", "0", "¹", "1", "²", "2", "³", "3", "⁴", "4",
+	"⁵", "5", "⁶", "6", "⁷", "7", "⁸", "8", "⁹", "9",
+)
+
+// Matches a number at the start of an answer, the rest is read as its unit
+var numberPrefix = regexp.MustCompile(`^\s*([-+]?)(\d[\d,]*(?:\.\d*)?|[.,]\d+)((?:[eE][-+]?\d+)?)\s*`)
+
+// Matches a number with its thousands separated by commas, such as "1,000,000.5"
+var thousandsNumber = regexp.MustCompile(`^[1-9]\d{0,2}(?:,\d{3})+(?:\.\d*)?$`)
+
+// ParseUnit parses a unit such as "km/h", "m/s²" or "kg·m/s^2".
+// Terms are separated by "*", "·" or spaces, and "/" divides by the term following it.
+// An empty unit is dimensionless.
+func ParseUnit(unit string) (Unit, error) {
+	result := Unit{Factor: 1}
+
+	unit = strings.TrimSpace(superscripts.Replace(unit))
+	if unit == "" {
+		return result, nil
+	}
+
+	divide := false
+	for _, token := range splitUnitTerms(unit) {
+		if token == "/" {
+			if divide {
+				return Unit{}, fmt.Errorf("unexpected \"/\" in unit %q", unit)
+			}
+			divide = true
+			continue
+		}
+
+		term, err := parseUnitTerm(token)
+		if err != nil {
+			return Unit{}, err
+		}
+		if divide {
+			term = term.pow(-1)
+			divide = false
+		}
+		result = result.multiply(term)
+	}
+
+	if divide {
+		return Unit{}, fmt.Errorf("unit %q ends with \"/\"", unit)
+	}
+
+	return result, nil
+}
+
+// ParseQuantity splits an answer such as "9.81 m/s²" into its value and unit.
+// A comma followed by groups of three digits separates thousands, as in "1,000 m",
+// otherwise a single comma is the decimal separator, as in "1,5 m".
+// Returns an error for numbers whose commas are neither, such as "1,00,0".
+func ParseQuantity(quantity string) (float64, string, error) {
+	match := numberPrefix.FindStringSubmatch(quantity)
+	if match == nil {
+		return 0, "", fmt.Errorf("no number in %q", quantity)
+	}
+
+	number := match[2]
+	switch {
+	case !strings.Contains(number, ","):
+	case thousandsNumber.MatchString(number):
+		number = strings.ReplaceAll(number, ",", "")
+	case strings.Count(number, ",") == 1 && !strings.Contains(number, "."):
+		number = strings.Replace(number, ",", ".", 1)
+	default:
+		return 0, "", fmt.Errorf("ambiguous number %q", match[2])
+	}
+
+	value, err := strconv.ParseFloat(match[1]+number+match[3], 64)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return value, strings.TrimSpace(quantity[len(match[0]):]), nil
+}
+
+// splitUnitTerms splits a unit into its terms and "/" separators.
+func splitUnitTerms(unit string) []string {
+	var tokens []string
+	for _, part := range strings.FieldsFunc(unit, func(r rune) bool {
+		return r == '*' || r == '·' || r == '⋅' || unicode.IsSpace(r)
+	}) {
+		for i, term := range strings.Split(part, "/") {
+			if i > 0 {
+				tokens = append(tokens, "/")
+			}
+			if term != "" {
+				tokens = append(tokens, term)
+			}
+		}
+	}
+	return tokens
+}
+
+// parseUnitTerm parses a single unit with an optional prefix and exponent, such as "km^2".
+func parseUnitTerm(term string) (Unit, error) {
+	symbol, exponent := term, 1
+
+	// The exponent is either written with "^" or directly after the symbol
+	if i := strings.IndexFunc(term, func(r rune) bool {
+		return r == '^' || r == '-' || unicode.IsDigit(r)
+	}); i > 0 {
+		var err error
+		symbol = term[:i]
+		exponent, err = strconv.Atoi(strings.TrimPrefix(term[i:], "^"))
+		if err != nil || exponent == 0 {
+			return Unit{}, fmt.Errorf("invalid exponent in %q", term)
+		}
+	}
+
+	unit, ok := lookupUnit(symbol)
+	if !ok {
+		return Unit{}, fmt.Errorf("unknown unit %q", symbol)
+	}
+
+	return unit.pow(exponent), nil
+}
+
+// lookupUnit finds a unit by its symbol, trying the symbol without a prefix first,
+// so "m" is a meter and "min" a minute.
+func lookupUnit(symbol string) (Unit, bool) {
+	if unit, ok := plainUnits[symbol]; ok {
+		return unit, true
+	}
+	if unit, ok := prefixableUnits[symbol]; ok {
+		return unit, true
+	}
+
+	for _, prefix := range siPrefixOrder {
+		rest, ok := strings.CutPrefix(symbol, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if unit, ok := prefixableUnits[rest]; ok {
+			return Unit{Factor: unit.Factor * siPrefixes[prefix], Dimensions: unit.Dimensions}, true
+		}
+	}
+
+	return Unit{}, false
+}
+
+// multiply returns the product of two units.
+func (u Unit) multiply(other Unit) Unit {
+	result := Unit{Factor: u.Factor * other.Factor}
+	for i := range result.Dimensions {
+		result.Dimensions[i] = u.Dimensions[i] + other.Dimensions[i]
+	}
+	return result
+}
+
+// pow returns the unit raised to an integer power.
+func (u Unit) pow(exponent int) Unit {
+	result := Unit{Factor: 1}
+	for i := range result.Dimensions {
+		result.Dimensions[i] = u.Dimensions[i] * exponent
+	}
+
+	factor := u.Factor
+	if exponent < 0 {
+		factor, exponent = 1/factor, -exponent
+	}
+	for range exponent {
+		result.Factor *= factor
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package utils_test
+
+import (
+	"math"
+	"memora/internal/utils"
+	"testing"
+)
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		unit   string
+		factor float64
+		dims   utils.Dimensions
+	}{
+		{"", 1, utils.Dimensions{}},
+		{"km", 1e3, utils.Dimensions{1}},
+		{"m/s²", 1, utils.Dimensions{1, 0, -2}},
+		{"m/s^2", 1, utils.Dimensions{1, 0, -2}},
+		{"km/h", 1e3 / 3600, utils.Dimensions{1, 0, -1}},
+		{"kg·m/s^2", 1, utils.Dimensions{1, 1, -2}},
+		{"N", 1, utils.Dimensions{1, 1, -2}},
+		{"cm2", 1e-4, utils.Dimensions{2}},
+		{"min", 60, utils.Dimensions{0, 0, 1}},
+		{"mmol", 1e-3, utils.Dimensions{0, 0, 0, 0, 0, 1}},
+		{"µs", 1e-6, utils.Dimensions{0, 0, 1}},
+		{"kΩ", 1e3, utils.Dimensions{2, 1, -3, -2}},
+		{"dam", 10, utils.Dimensions{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.unit, func(t *testing.T) {
+			got, err := utils.ParseUnit(tt.unit)
+			if err != nil {
+				t.Fatalf("ParseUnit(%q) returned error %v", tt.unit, err)
+			}
+			if math.Abs(got.Factor-tt.factor) > 1e-12*tt.factor || got.Dimensions != tt.dims {
+				t.Errorf("ParseUnit(%q) = %v, want {%v %v}", tt.unit, got, tt.factor, tt.dims)
+			}
+		})
+	}
+
+	for _, unit := range []string{"parsec", "m/", "m//s", "m^x", "<b>m</b>"} {
+		if _, err := utils.ParseUnit(unit); err == nil {
+			t.Errorf("ParseUnit(%q) returned no error", unit)
+		}
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		quantity string
+		value    float64
+		unit     string
+	}{
+		{"9.81 m/s²", 9.81, "m/s²"},
+		{"1,5 m", 1.5, "m"},
+		{"1,000 m", 1000, "m"},
+		{"1,000,000.5 m", 1000000.5, "m"},
+		{"0,125 m", 0.125, "m"},
+		{"1,2345 m", 1.2345, "m"},
+		{"-2,5e3", -2500, ""},
+		{",5 kg", 0.5, "kg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.quantity, func(t *testing.T) {
+			value, unit, err := utils.ParseQuantity(tt.quantity)
+			if err != nil {
+				t.Fatalf("ParseQuantity(%q) returned error %v", tt.quantity, err)
+			}
+			if value != tt.value || unit != tt.unit {
+				t.Errorf("ParseQuantity(%q) = %v, %q, want %v, %q", tt.quantity, value, unit, tt.value, tt.unit)
+			}
+		})
+	}
+
+	for _, quantity := range []string{"m", "1,00,0 m", "1,5.5 m", "12,34,567 m"} {
+		if _, _, err := utils.ParseQuantity(quantity); err == nil {
+			t.Errorf("ParseQuantity(%q) returned no error", quantity)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 
@@ -19,12 +21,27 @@ func init() {
 
 // Main entry point of the application
 func main() {
+	migrateMembers := flag.Bool("migrate-members", false, "move decks shared by email to user IDs and exit")
+	flag.Parse()
+
 	// Initialize Firebase client
 	client, app, rbd, err := firebase.Init()
 	if err != nil {
 		log.Panic(err)
 	}
 
+	if *migrateMembers {
+		result, err := firebase.MigrateMembers(context.Background(), client)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf(
+			"migrated %d decks, lowercased %d user emails, invited %d emails",
+			result.Decks, result.Users, result.Invites,
+		)
+		return
+	}
+
 	auth, err := firebase.NewFirebaseAuth(app)
 	if err != nil {
 		log.Panic(err)
@@ -37,6 +54,9 @@ func main() {
 	repos := firebase.NewRepositories(client, auth)
 	services := services.NewServices(repos, validate, rbd)
 
+	// Purge the decks and cards kept in the trash past the retention period
+	go services.Decks.RunTrashPurge(context.Background(), config.TrashPurgeInterval)
+
 	// Set up and run the router
 	r := router.New()
 